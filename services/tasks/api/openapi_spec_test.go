@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/tasks"
 )
 
 func Test_OpenAPI_SpecIsValid_And_DeclaresGETTasks(t *testing.T) {
@@ -16,11 +18,72 @@ func Test_OpenAPI_SpecIsValid_And_DeclaresGETTasks(t *testing.T) {
 	if err := doc.Validate(context.Background()); err != nil {
 		t.Fatalf("spec validation error: %v", err)
 	}
-	// Path + GET and POST must exist; deeper response checks are exercised by the runtime test.
+	// Paths + methods must exist; deeper response checks are exercised by the runtime test.
 	if pi := doc.Paths.Find("/api/tasks"); pi == nil || pi.Get == nil {
 		t.Fatalf("GET /api/tasks not declared in openapi.yaml")
 	}
 	if pi := doc.Paths.Find("/api/tasks"); pi == nil || pi.Post == nil {
 		t.Fatalf("POST /api/tasks not declared in openapi.yaml")
 	}
+	if pi := doc.Paths.Find("/api/tasks:batch"); pi == nil || pi.Post == nil {
+		t.Fatalf("POST /api/tasks:batch not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/tasks/stream"); pi == nil || pi.Get == nil {
+		t.Fatalf("GET /api/tasks/stream not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/tasks/search"); pi == nil || pi.Get == nil {
+		t.Fatalf("GET /api/tasks/search not declared in openapi.yaml")
+	}
+	pi := doc.Paths.Find("/api/tasks/{id}")
+	if pi == nil || pi.Get == nil || pi.Patch == nil || pi.Delete == nil {
+		t.Fatalf("GET/PATCH/DELETE /api/tasks/{id} not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/tasks/{id}/restore"); pi == nil || pi.Post == nil {
+		t.Fatalf("POST /api/tasks/{id}/restore not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/webhooks"); pi == nil || pi.Get == nil || pi.Post == nil {
+		t.Fatalf("GET/POST /api/webhooks not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/webhooks/{id}/deliveries/{delivery_id}/redeliver"); pi == nil || pi.Post == nil {
+		t.Fatalf("POST /api/webhooks/{id}/deliveries/{delivery_id}/redeliver not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/tasks/{id}/move"); pi == nil || pi.Post == nil {
+		t.Fatalf("POST /api/tasks/{id}/move not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/lists"); pi == nil || pi.Get == nil || pi.Post == nil {
+		t.Fatalf("GET/POST /api/lists not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/lists/{id}/tasks"); pi == nil || pi.Get == nil || pi.Post == nil {
+		t.Fatalf("GET/POST /api/lists/{id}/tasks not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/tasks/{id}/blockers/{blocker_id}"); pi == nil || pi.Put == nil || pi.Delete == nil {
+		t.Fatalf("PUT/DELETE /api/tasks/{id}/blockers/{blocker_id} not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/tasks/next"); pi == nil || pi.Get == nil {
+		t.Fatalf("GET /api/tasks/next not declared in openapi.yaml")
+	}
+	if pi := doc.Paths.Find("/api/reminders/preferences"); pi == nil || pi.Get == nil || pi.Put == nil {
+		t.Fatalf("GET/PUT /api/reminders/preferences not declared in openapi.yaml")
+	}
+	for _, path := range []string{"/api/audit", "/api/tasks/{id}/history"} {
+		if pi := doc.Paths.Find(path); pi == nil || pi.Get == nil {
+			t.Fatalf("GET %s not declared in openapi.yaml", path)
+		}
+	}
+	for _, path := range []string{"/api/tasks/{id}/undo", "/api/tasks/{id}/redo"} {
+		if pi := doc.Paths.Find(path); pi == nil || pi.Post == nil {
+			t.Fatalf("POST %s not declared in openapi.yaml", path)
+		}
+	}
+}
+
+func Test_OpenAPI_TaskTitleMatchesValidation(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromFile("openapi.yaml")
+	if err != nil {
+		t.Fatalf("load openapi.yaml: %v", err)
+	}
+	s := doc.Components.Schemas["TaskTitle"]
+	if s == nil || s.Value.MaxLength == nil || *s.Value.MaxLength != tasks.MaxTitleLength || s.Value.MinLength != 1 {
+		t.Fatalf("TaskTitle must declare minLength 1 and maxLength %d", tasks.MaxTitleLength)
+	}
 }
@@ -2,18 +2,62 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/graph"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/auth"
 	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/config"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/health"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/notify"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/outbox"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/requestid"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/safehttp"
 	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/tasks"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/webhook"
 )
 
 func main() {
 	// Read config from services/tasks/internal/config/config.go.
 	cfg := config.Load()
 
+	// `server migrate up|down|status` manages the schema and exits instead
+	// of serving. See cmd/server/migrate.go.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), cfg, os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	// Optionally bring the schema up to date before we start taking traffic.
+	// The migration runner holds a Postgres advisory lock, so several replicas
+	// starting together is safe: one applies, the others wait and find nothing to do.
+	if cfg.MigrateOnStart {
+		if err := runMigrate(context.Background(), cfg, []string{"up"}, log.Writer()); err != nil {
+			log.Fatalf("migrate on start: %v", err)
+		}
+	}
+
+	// run() only returns once the server has stopped. Keeping the real work out
+	// of main() means its deferred cleanup (closing the DB pool) always runs;
+	// log.Fatalf would exit the process without running defers.
+	if err := run(cfg); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// run wires the repository, service and HTTP server together and serves until
+// SIGINT/SIGTERM (or a fatal listener error), then shuts down gracefully.
+func run(cfg config.Config) error {
 	// The Repository design pattern
 	// (https://martinfowler.com/eaaCatalog/repository.html)
 	//
@@ -36,16 +80,27 @@ func main() {
 	//
 	repo, err := tasks.NewRepo(context.Background(), cfg)
 	if err != nil {
-		log.Fatalf("repository init: %v", err)
+		return fmt.Errorf("repository init: %w", err)
 	}
 
 	// defer repo.Close() is Go’s way of guaranteeing that the database pool is cleaned
-	// up when the program ends, avoiding resource leaks and ensuring graceful shutdowns.
+	// up when run() returns — which, thanks to the shutdown handling below, now also
+	// happens on SIGTERM, after in-flight requests have drained.
 	defer repo.Close()
 
+	// The transactional outbox relay (see internal/outbox) delivers the task
+	// events that Repo records alongside each change. Build the sink up front
+	// so a misconfiguration fails startup rather than silently queueing.
+	sink, closeSink, err := newOutboxSink(cfg)
+	if err != nil {
+		return fmt.Errorf("outbox sink: %w", err)
+	}
+	defer closeSink()
+
 	// Builds the domain/business layer. The service coordinates use-cases and calls into
 	// the repo. (For this PoC it’s thin: it just forwards to the repo.)
 	svc := tasks.NewService(repo)
+	svc.SetIdempotencyTTL(cfg.IdempotencyKeyTTL)
 
 	// Creates the HTTP router with default middleware (logger + recovery).
 	//
@@ -62,23 +117,189 @@ func main() {
 	//
 	r := gin.Default()
 
-	// Health check endpoint. Returns {"ok": true} with 200 status.
-	// Useful for Docker/Kubernetes probes or for a quick curl check.
-	r.GET("/healthz", func(c *gin.Context) { c.JSON(200, gin.H{"ok": true}) })
+	// Tag every request with an X-Request-Id (the caller's, or a new one) so
+	// audit entries can be traced back to it (see internal/requestid).
+	r.Use(requestid.Middleware())
+
+	// Health endpoints (see internal/health):
+	//   /livez, /healthz → 200 {"ok": true} while the process is up (liveness probe).
+	//   /readyz          → 200 only if Postgres answers a ping and we're not shutting
+	//                      down; otherwise 503. Includes per-dependency status and pool stats.
+	probes := health.New(cfg.ReadinessTimeout)
+	probes.Add("postgres", func(ctx context.Context) (any, error) { return repo.HealthCheck(ctx) })
+	probes.Register(r)
+
+	// Identify the caller of every API request (bearer JWT, Kong's consumer
+	// headers, or anonymous in dev; see internal/auth). The service scopes all
+	// reads and writes to that caller, so several users can share one deployment.
+	authn := auth.New(auth.Options{
+		JWTSecret:           []byte(cfg.AuthJWTSecret),
+		JWTIssuer:           cfg.AuthJWTIssuer,
+		TrustGatewayHeaders: cfg.AuthTrustGatewayHeaders,
+		AllowAnonymous:      cfg.AuthAllowAnonymous,
+	})
 
 	// Group all API endpoints under the /api prefix.
-	api := r.Group("/api")
+	api := r.Group("/api", authn.Middleware())
 
 	// Register the task-related routes (e.g., GET /api/tasks).
 	// We inject `svc` here: this allows tests to inject fakes instead of real DB.
 	tasks.RegisterRoutes(api, svc)
 
-	log.Printf("listening on :%s", cfg.Port)
+	// GraphQL endpoint (schema in graph/schema.graphqls, code generated by gqlgen).
+	// It resolves against the same `svc` as the REST routes, so clients can use
+	// either API and see the same data. gin.WrapH adapts a plain net/http handler.
+	gql := gin.WrapH(graph.NewHandler(svc))
+	r.GET("/graphql", authn.Middleware(), gql)
+	r.POST("/graphql", authn.Middleware(), gql)
+
+	// The interactive playground is a developer convenience, so only expose it in dev.
+	if cfg.Env == "dev" {
+		r.GET("/playground", gin.WrapH(graph.NewPlaygroundHandler("/graphql")))
+	}
+
+	// An explicit http.Server (instead of r.Run) lets us set timeouts and shut down
+	// gracefully. Without timeouts a slow or stalled client can hold a connection
+	// (and a goroutine) open forever:
+	//
+	// ReadHeaderTimeout → max time to read request headers (Slowloris protection).
+	// ReadTimeout       → max time to read the whole request, body included.
+	// WriteTimeout      → max time from end of request read to end of response write.
+	// IdleTimeout       → how long a keep-alive connection may sit idle between requests.
+	srv := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+	// Shutdown waits for active requests, and a /api/tasks/stream subscriber
+	// never finishes on its own, so end the streams as shutdown begins.
+	srv.RegisterOnShutdown(svc.CloseSubscriptions)
+
+	// ctx is cancelled when the process receives SIGINT (Ctrl-C) or SIGTERM (what
+	// Docker/Kubernetes send on stop/restart).
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The background workers below run until workCtx is cancelled: on a
+	// signal, or when run returns for any other reason. Before the deferred
+	// repo.Close (and closeSink) run, stopWorkers waits for them, so none
+	// is cut off mid-transaction by a closed pool. Defers run last-in
+	// first-out, so registering it here puts it ahead of both.
+	workCtx, cancelWork := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	goWork := func(fn func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			fn(workCtx)
+		}()
+	}
+	defer stopWorkers(cancelWork, &workers, cfg.ShutdownTimeout)
+
+	// Relay task change events from Postgres LISTEN/NOTIFY to this replica's
+	// stream subscribers. Runs until cancelled; reconnects by itself.
+	goWork(func(ctx context.Context) {
+		if err := svc.RunChangeFeed(ctx); err != nil {
+			log.Printf("change feed: %v", err)
+		}
+	})
+
+	// Deliver outbox events until cancelled. Several replicas may run a
+	// relay at once: each claims different rows (FOR UPDATE SKIP LOCKED).
+	if sink != nil {
+		relay := outbox.NewRelay(repo, sink, outbox.Options{PollInterval: cfg.OutboxPollInterval})
+		goWork(func(ctx context.Context) {
+			if err := relay.Run(ctx); err != nil {
+				log.Printf("outbox relay: %v", err)
+			}
+		})
+	}
+
+	// Webhooks and reminder webhooks POST to user-supplied URLs, so both go
+	// through one client that keeps off private and local addresses.
+	userURLs := safehttp.NewClient()
+
+	// Send queued webhook deliveries (see internal/webhook). Like the relay,
+	// it is safe to run on every replica.
+	goWork(func(ctx context.Context) {
+		if err := webhook.NewWorker(repo, webhook.Options{Client: userURLs}).Run(ctx); err != nil {
+			log.Printf("webhook worker: %v", err)
+		}
+	})
+
+	// Send due-date reminders (see internal/notify). Like the webhook worker
+	// it is safe to run on every replica: each reminder is queued once.
+	goWork(func(ctx context.Context) {
+		w := notify.NewWorker(repo, newNotifiers(cfg, userURLs), notify.Options{PollInterval: cfg.ReminderPollInterval})
+		if err := w.Run(ctx); err != nil {
+			log.Printf("reminder worker: %v", err)
+		}
+	})
+
+	// Expired Idempotency-Keys are already ignored; this just deletes them.
+	goWork(func(ctx context.Context) { svc.RunIdempotencyKeyPurge(ctx, time.Hour) })
+
+	// Hard-delete tasks that have sat in the trash longer than the retention.
+	goWork(func(ctx context.Context) { svc.RunTrashPurge(ctx, cfg.TrashRetention, time.Hour) })
+
+	// Create the next occurrences of recurring tasks. Every replica runs it,
+	// but only the one holding the scheduler's advisory lock does any work.
+	goWork(func(ctx context.Context) { svc.RunScheduler(ctx, cfg.SchedulerInterval) })
+
+	// Serve in a goroutine so this one can wait for either a signal or a listener
+	// error. ListenAndServe always returns a non-nil error; after Shutdown it is
+	// http.ErrServerClosed, which is not a failure.
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("listening on :%s", cfg.Port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// e.g. the port is already in use.
+		return fmt.Errorf("server error: %w", err)
+	case <-ctx.Done():
+		stop() // a second Ctrl-C now kills the process immediately
+	}
+
+	// Fail readiness first, so load balancers / Kubernetes stop sending new
+	// traffic, and optionally give them ShutdownDelay to notice before we stop
+	// accepting connections.
+	probes.SetDraining()
+	if cfg.ShutdownDelay > 0 {
+		log.Printf("not ready; waiting %s before shutdown", cfg.ShutdownDelay)
+		time.Sleep(cfg.ShutdownDelay)
+	}
+
+	// Shutdown stops accepting new connections, then waits for in-flight requests
+	// to finish — but no longer than ShutdownTimeout, after which they are cut off.
+	log.Printf("shutting down (draining for up to %s)", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("graceful shutdown: %w", err)
+	}
+	log.Printf("server stopped")
+	return nil
+}
 
-	// Start the HTTP server — this blocks forever, handling requests until shutdown.
-	// If the port is unavailable, Run() returns an error immediately.
-	// On error, log.Fatalf prints the message and exits the process.
-	if err := r.Run(":" + cfg.Port); err != nil {
-		log.Fatalf("server error: %v", err)
+// stopWorkers cancels the background workers and waits for them to finish
+// what they are doing, but no longer than timeout: past that the pool is
+// closed under them anyway, as the process is about to exit.
+func stopWorkers(cancel context.CancelFunc, workers *sync.WaitGroup, timeout time.Duration) {
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		log.Printf("background workers still running after %s; closing anyway", timeout)
 	}
 }
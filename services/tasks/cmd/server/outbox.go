@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/config"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/outbox"
+)
+
+// newOutboxSink builds the sink selected by OUTBOX_SINK. A nil sink (for
+// "none") means the relay should not run. The returned close func releases
+// the sink's connections and is always safe to call.
+func newOutboxSink(cfg config.Config) (outbox.Sink, func(), error) {
+	noop := func() {}
+	switch cfg.OutboxSink {
+	case "none":
+		return nil, noop, nil
+	case "stdout":
+		return outbox.NewWriterSink(os.Stdout), noop, nil
+	case "webhook":
+		if cfg.OutboxWebhookURL == "" {
+			return nil, noop, errors.New("OUTBOX_SINK=webhook requires OUTBOX_WEBHOOK_URL")
+		}
+		return &outbox.WebhookSink{URL: cfg.OutboxWebhookURL}, noop, nil
+	case "nats":
+		sink, err := outbox.NewNATSSink(cfg.OutboxNATSURL, cfg.OutboxNATSPrefix)
+		if err != nil {
+			return nil, noop, fmt.Errorf("nats connect: %w", err)
+		}
+		return sink, sink.Close, nil
+	default:
+		return nil, noop, fmt.Errorf("unknown OUTBOX_SINK %q", cfg.OutboxSink)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/smtp"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/config"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/notify"
+)
+
+// newNotifiers builds the reminder notifier for each channel. Email is
+// only available with REMINDER_SMTP_ADDR set, and authenticates only with
+// REMINDER_SMTP_USERNAME set. Webhooks are sent with client.
+func newNotifiers(cfg config.Config, client *http.Client) map[notify.Channel]notify.Notifier {
+	ns := map[notify.Channel]notify.Notifier{
+		notify.ChannelLog:     &notify.LogNotifier{},
+		notify.ChannelWebhook: &notify.WebhookNotifier{Client: client},
+	}
+	if cfg.ReminderSMTPAddr != "" {
+		n := &notify.SMTPNotifier{Addr: cfg.ReminderSMTPAddr, From: cfg.ReminderSMTPFrom}
+		if cfg.ReminderSMTPUsername != "" {
+			host, _, _ := net.SplitHostPort(cfg.ReminderSMTPAddr)
+			n.Auth = smtp.PlainAuth("", cfg.ReminderSMTPUsername, cfg.ReminderSMTPPassword, host)
+		}
+		ns[notify.ChannelEmail] = n
+	}
+	return ns
+}
@@ -0,0 +1,43 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// presentError gives GraphQL clients the same stable codes as the REST API,
+// under extensions.code, and hides the cause of unexpected errors.
+//
+// Errors raised by gqlgen itself (parse/validation failures) are passed
+// through unchanged.
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	gerr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var ae *apperr.Error
+	if !errors.As(err, &ae) {
+		var ge *gqlerror.Error
+		if errors.As(err, &ge) {
+			return gerr
+		}
+		ae = apperr.Internal(err)
+	}
+	if ae.Kind == apperr.KindInternal || ae.Kind == apperr.KindUnavailable {
+		log.Printf("graphql %v: %v", gerr.Path, err)
+	}
+
+	gerr.Message = ae.Message
+	if gerr.Extensions == nil {
+		gerr.Extensions = map[string]any{}
+	}
+	gerr.Extensions["code"] = ae.Code
+	if len(ae.Fields) > 0 {
+		gerr.Extensions["errors"] = ae.Fields
+	}
+	return gerr
+}
@@ -0,0 +1,35 @@
+package graph
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/99designs/gqlgen/graphql/playground"
+)
+
+// NewHandler builds the /graphql HTTP handler on top of the generated
+// executable schema, with resolvers backed by svc.
+//
+// Only the transports our clients use are enabled (GET for simple queries,
+// POST for everything else, and WebSocket for subscriptions; the latter
+// speaks both graphql-ws and graphql-transport-ws). Introspection is left on so tools like the
+// playground and codegen can read the schema. Errors carry the REST API's
+// error codes in extensions.code (see presentError).
+func NewHandler(svc TaskService) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: &Resolver{Service: svc}}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.Websocket{KeepAlivePingInterval: 10 * time.Second})
+	srv.Use(extension.Introspection{})
+	srv.SetErrorPresenter(presentError)
+	return srv
+}
+
+// NewPlaygroundHandler serves the GraphQL playground UI pointed at endpoint.
+// It is only mounted in dev (see cmd/server/main.go).
+func NewPlaygroundHandler(endpoint string) http.Handler {
+	return playground.Handler("Tasks GraphQL", endpoint)
+}
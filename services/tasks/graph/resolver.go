@@ -1,6 +1,58 @@
 package graph
 
-// This file wires your services into gqlgen resolvers later.
-// After running `go run github.com/99designs/gqlgen generate`
-// you'll implement Query.Tasks to call tasks.Service.List(...)
-type Resolver struct{}
+import (
+	"context"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/tasks"
+)
+
+// TaskService is the narrow slice of tasks.Service the resolvers depend on.
+// Like tasks.TaskLister on the REST side, it lets tests inject a fake.
+type TaskService interface {
+	List(ctx context.Context, opts tasks.ListOptions) (tasks.TaskPage, error)
+	Create(ctx context.Context, n tasks.NewTask) (tasks.Task, error)
+	// Subscribe feeds the taskChanged subscription; the channel closes when
+	// ctx is done (the client unsubscribed or went away).
+	Subscribe(ctx context.Context) (<-chan tasks.Event, error)
+}
+
+// Resolver is the root gqlgen resolver. It holds the dependencies shared by
+// every field resolver; generated code calls into it via schema.resolvers.go.
+type Resolver struct {
+	Service TaskService
+}
+
+// listManager returns the service's optional list capability. As on the
+// REST side, the list fields fail with a not-implemented error without it.
+func (r *Resolver) listManager() (tasks.ListManager, error) {
+	lm, ok := r.Service.(tasks.ListManager)
+	if !ok {
+		return nil, apperr.NotImplemented("lists_not_supported", "lists not supported")
+	}
+	return lm, nil
+}
+
+// dependencyManager returns the service's optional subtask and blocker
+// capability, like listManager.
+func (r *Resolver) dependencyManager() (tasks.DependencyManager, error) {
+	dm, ok := r.Service.(tasks.DependencyManager)
+	if !ok {
+		return nil, apperr.NotImplemented("dependencies_not_supported", "dependencies not supported")
+	}
+	return dm, nil
+}
+
+// int32ID parses an optional ID argument, reporting a malformed one as an
+// invalid_<name> validation error.
+func int32ID(name string, s *string) (*int32, error) {
+	if s == nil {
+		return nil, nil
+	}
+	id, err := UnmarshalInt32ID(*s)
+	if err != nil || id < 1 {
+		return nil, apperr.Validation("invalid_"+name, "invalid "+name,
+			apperr.FieldError{Field: name, Reason: "invalid"})
+	}
+	return &id, nil
+}
@@ -0,0 +1,351 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/tasks"
+)
+
+// Fake that satisfies TaskService
+type fakeSvc struct {
+	created []string
+}
+
+func (f *fakeSvc) List(ctx context.Context, opts tasks.ListOptions) (tasks.TaskPage, error) {
+	now := time.Now().UTC()
+	return tasks.TaskPage{Items: []tasks.Task{
+		{ID: 1, Title: "First", Done: false, CreatedAt: now, UpdatedAt: now},
+		{ID: 2, Title: "Second", Done: true, CreatedAt: now, UpdatedAt: now},
+	}}, nil
+}
+
+func (f *fakeSvc) Create(ctx context.Context, n tasks.NewTask) (tasks.Task, error) {
+	f.created = append(f.created, n.Title)
+	now := time.Now().UTC()
+	return tasks.Task{
+		ID: 3, Title: n.Title, CreatedAt: now, UpdatedAt: now,
+		Description: n.Description, DueAt: n.DueAt, RemindAt: n.RemindAt, Priority: n.Priority, Tags: n.Tags, RRule: n.RRule,
+	}, nil
+}
+
+func (f *fakeSvc) Subscribe(ctx context.Context) (<-chan tasks.Event, error) {
+	ch := make(chan tasks.Event, 1)
+	ch <- tasks.Event{Type: tasks.EventUpdated, Task: tasks.Task{ID: 7, Title: "Streamed", Done: true}}
+	close(ch)
+	return ch, nil
+}
+
+type gqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string `json:"message"`
+		Extensions struct {
+			Code string `json:"code"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func postQuery(t *testing.T, h http.Handler, query string) gqlResponse {
+	t.Helper()
+	body, _ := json.Marshal(map[string]string{"query": query})
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", rec.Code, rec.Body.String())
+	}
+	var out gqlResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("json: %v; body=%s", err, rec.Body.String())
+	}
+	return out
+}
+
+func TestQueryTasks(t *testing.T) {
+	h := NewHandler(&fakeSvc{})
+
+	res := postQuery(t, h, `{ tasks { id title done } }`)
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", res.Errors)
+	}
+	var data struct {
+		Tasks []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+			Done  bool   `json:"done"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("data: %v", err)
+	}
+	if len(data.Tasks) != 2 || data.Tasks[0].ID != "1" || data.Tasks[1].Title != "Second" || !data.Tasks[1].Done {
+		t.Fatalf("unexpected tasks: %+v", data.Tasks)
+	}
+}
+
+func TestMutationAddTask(t *testing.T) {
+	svc := &fakeSvc{}
+	h := NewHandler(svc)
+
+	res := postQuery(t, h, `mutation { addTask(title: "  From GraphQL ") { id title done } }`)
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", res.Errors)
+	}
+	if len(svc.created) != 1 || svc.created[0] != "From GraphQL" {
+		t.Fatalf("expected trimmed title to reach the service, got %q", svc.created)
+	}
+
+	res = postQuery(t, h, `mutation { addTask(title: "   ") { id } }`)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions.Code != "invalid_title" {
+		t.Fatalf("expected an invalid_title error for a blank title, got %+v", res.Errors)
+	}
+	if len(svc.created) != 1 {
+		t.Fatalf("blank title must not reach the service")
+	}
+}
+
+func TestMutationAddTask_WithDetails(t *testing.T) {
+	h := NewHandler(&fakeSvc{})
+
+	res := postQuery(t, h, `mutation {
+		addTask(title: "Plan", description: "Agenda", dueAt: "2026-05-01T09:00:00Z", priority: HIGH, tags: ["Work", "q2", "work"], rrule: "freq=weekly", remindAt: "2026-05-01T08:30:00Z") {
+			description dueAt remindAt priority tags rrule
+		}
+	}`)
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", res.Errors)
+	}
+	var data struct {
+		AddTask struct {
+			Description string   `json:"description"`
+			DueAt       string   `json:"dueAt"`
+			RemindAt    string   `json:"remindAt"`
+			Priority    string   `json:"priority"`
+			Tags        []string `json:"tags"`
+			RRule       string   `json:"rrule"`
+		} `json:"addTask"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("data: %v", err)
+	}
+	got := data.AddTask
+	if got.Description != "Agenda" || got.DueAt != "2026-05-01T09:00:00Z" || got.RemindAt != "2026-05-01T08:30:00Z" || got.Priority != "HIGH" || strings.Join(got.Tags, ",") != "q2,work" || got.RRule != "FREQ=WEEKLY" {
+		t.Fatalf("unexpected task: %+v", got)
+	}
+
+	for query, code := range map[string]string{
+		`mutation { addTask(title: "Plan", tags: ["a,b"]) { id } }`:          "invalid_tags",
+		`mutation { addTask(title: "Plan", rrule: "FREQ=SECONDLY") { id } }`: "invalid_rrule",
+	} {
+		res = postQuery(t, h, query)
+		if len(res.Errors) != 1 || res.Errors[0].Extensions.Code != code {
+			t.Fatalf("%s: expected an %s error, got %+v", query, code, res.Errors)
+		}
+	}
+}
+
+// fakeListSvc adds the optional list capabilities to fakeSvc.
+type fakeListSvc struct {
+	fakeSvc
+	moved []tasks.TaskMove
+}
+
+func (f *fakeListSvc) Lists(ctx context.Context) ([]tasks.List, error) {
+	return []tasks.List{{ID: 4, Name: "Home"}}, nil
+}
+
+func (f *fakeListSvc) CreateList(ctx context.Context, in tasks.ListInput) (tasks.List, error) {
+	return tasks.List{ID: 5, Name: in.Name}, nil
+}
+
+func (f *fakeListSvc) GetList(ctx context.Context, id int32) (tasks.List, error) {
+	return tasks.List{ID: id, Name: "Home"}, nil
+}
+
+func (f *fakeListSvc) UpdateList(ctx context.Context, id int32, in tasks.ListInput) (tasks.List, error) {
+	return tasks.List{ID: id, Name: in.Name}, nil
+}
+
+func (f *fakeListSvc) DeleteList(ctx context.Context, id int32) error { return nil }
+
+func (f *fakeListSvc) TasksInList(ctx context.Context, id int32) ([]tasks.Task, error) {
+	pos := 1.5
+	return []tasks.Task{{ID: 1, Title: "Paint", ListID: &id, Position: &pos, Priority: tasks.PriorityNormal, Tags: []string{}}}, nil
+}
+
+func (f *fakeListSvc) Move(ctx context.Context, id int32, m tasks.TaskMove) (tasks.Task, error) {
+	f.moved = append(f.moved, m)
+	return tasks.Task{ID: id, Title: "Paint", ListID: m.ListID, Priority: tasks.PriorityNormal, Tags: []string{}}, nil
+}
+
+func TestLists(t *testing.T) {
+	svc := &fakeListSvc{}
+	h := NewHandler(svc)
+
+	res := postQuery(t, h, `{ lists { id name tasks { id listId position } } }`)
+	if len(res.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", res.Errors)
+	}
+	if want := `{"lists":[{"id":"4","name":"Home","tasks":[{"id":"1","listId":"4","position":1.5}]}]}`; string(res.Data) != want {
+		t.Fatalf("expected %s, got %s", want, res.Data)
+	}
+
+	res = postQuery(t, h, `mutation { moveTask(id: "1", listId: "4", afterId: "2") { id listId } }`)
+	if len(res.Errors) > 0 || len(svc.moved) != 1 || *svc.moved[0].ListID != 4 || *svc.moved[0].AfterID != 2 || svc.moved[0].BeforeID != nil {
+		t.Fatalf("moveTask: %+v, moves %+v", res.Errors, svc.moved)
+	}
+	res = postQuery(t, h, `mutation { moveTask(id: "1", listId: "four") { id } }`)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions.Code != "invalid_listId" {
+		t.Fatalf("expected an invalid_listId error, got %+v", res.Errors)
+	}
+
+	res = postQuery(t, NewHandler(&fakeSvc{}), `{ lists { id } }`)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions.Code != "lists_not_supported" {
+		t.Fatalf("expected lists_not_supported without the capability, got %+v", res.Errors)
+	}
+}
+
+// fakeDepSvc adds the optional dependency capabilities to fakeSvc,
+// rejecting any link to task 9 as a cycle.
+type fakeDepSvc struct {
+	fakeSvc
+	blockers [][2]int32 // {task, blocker}
+	limit    int
+}
+
+func (f *fakeDepSvc) SetParent(ctx context.Context, id int32, parentID *int32) (tasks.Task, error) {
+	return tasks.Task{ID: id, Title: "Sub", ParentID: parentID, Priority: tasks.PriorityNormal, Tags: []string{}}, nil
+}
+
+func (f *fakeDepSvc) AddBlocker(ctx context.Context, id, blockerID int32) error {
+	if blockerID == 9 {
+		return apperr.Conflict("dependency_cycle", "would create a cycle: 1 → 9 → 1", nil)
+	}
+	f.blockers = append(f.blockers, [2]int32{id, blockerID})
+	return nil
+}
+
+func (f *fakeDepSvc) RemoveBlocker(ctx context.Context, id, blockerID int32) error { return nil }
+
+func (f *fakeDepSvc) Subtasks(ctx context.Context, id int32) ([]tasks.Task, error) { return nil, nil }
+
+func (f *fakeDepSvc) Blockers(ctx context.Context, id int32) ([]tasks.Task, error) { return nil, nil }
+
+func (f *fakeDepSvc) Next(ctx context.Context, limit int) ([]tasks.Task, error) {
+	f.limit = limit
+	return []tasks.Task{{ID: 3, Title: "Now", Priority: tasks.PriorityHigh, Tags: []string{}}}, nil
+}
+
+func TestDependencies(t *testing.T) {
+	svc := &fakeDepSvc{}
+	h := NewHandler(svc)
+
+	res := postQuery(t, h, `mutation { setParent(id: "2", parentId: "1") { id parentId } }`)
+	if want := `{"setParent":{"id":"2","parentId":"1"}}`; len(res.Errors) > 0 || string(res.Data) != want {
+		t.Fatalf("setParent: expected %s, got %s %+v", want, res.Data, res.Errors)
+	}
+	res = postQuery(t, h, `mutation { addBlocker(id: "1", blockerId: "2") }`)
+	if len(res.Errors) > 0 || len(svc.blockers) != 1 || svc.blockers[0] != [2]int32{1, 2} {
+		t.Fatalf("addBlocker: %+v, blockers %v", res.Errors, svc.blockers)
+	}
+	res = postQuery(t, h, `mutation { addBlocker(id: "1", blockerId: "9") }`)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions.Code != "dependency_cycle" {
+		t.Fatalf("expected a dependency_cycle error, got %+v", res.Errors)
+	}
+
+	res = postQuery(t, h, `{ nextTasks { id priority } }`)
+	if want := `{"nextTasks":[{"id":"3","priority":"HIGH"}]}`; len(res.Errors) > 0 || string(res.Data) != want || svc.limit != tasks.DefaultNextLimit {
+		t.Fatalf("nextTasks: expected %s with the default limit, got %s (limit %d) %+v", want, res.Data, svc.limit, res.Errors)
+	}
+
+	res = postQuery(t, NewHandler(&fakeSvc{}), `mutation { removeBlocker(id: "1", blockerId: "2") }`)
+	if len(res.Errors) != 1 || res.Errors[0].Extensions.Code != "dependencies_not_supported" {
+		t.Fatalf("expected dependencies_not_supported without the capability, got %+v", res.Errors)
+	}
+}
+
+func TestSubscriptionTaskChanged(t *testing.T) {
+	srv := httptest.NewServer(NewHandler(&fakeSvc{}))
+	defer srv.Close()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/graphql", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	type message struct {
+		ID      string          `json:"id,omitempty"`
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload,omitempty"`
+	}
+	send := func(m message) {
+		t.Helper()
+		if err := conn.WriteJSON(m); err != nil {
+			t.Fatalf("write %s: %v", m.Type, err)
+		}
+	}
+	read := func() message {
+		t.Helper()
+		for {
+			var m message
+			if err := conn.ReadJSON(&m); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if m.Type != "ping" && m.Type != "pong" {
+				return m
+			}
+		}
+	}
+
+	send(message{Type: "connection_init"})
+	if m := read(); m.Type != "connection_ack" {
+		t.Fatalf("expected connection_ack, got %+v", m)
+	}
+	query, _ := json.Marshal(map[string]string{"query": `subscription { taskChanged { type task { id title done } } }`})
+	send(message{ID: "1", Type: "subscribe", Payload: query})
+
+	m := read()
+	if m.Type != "next" || m.ID != "1" {
+		t.Fatalf("expected next, got %+v", m)
+	}
+	var res gqlResponse
+	if err := json.Unmarshal(m.Payload, &res); err != nil || len(res.Errors) > 0 {
+		t.Fatalf("payload: %v %+v", err, res.Errors)
+	}
+	var data struct {
+		TaskChanged struct {
+			Type string `json:"type"`
+			Task struct {
+				ID    string `json:"id"`
+				Title string `json:"title"`
+				Done  bool   `json:"done"`
+			} `json:"task"`
+		} `json:"taskChanged"`
+	}
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("data: %v", err)
+	}
+	if ev := data.TaskChanged; ev.Type != "UPDATED" || ev.Task.ID != "7" || ev.Task.Title != "Streamed" || !ev.Task.Done {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	// The fake's feed ends after one event, which completes the subscription.
+	if m := read(); m.Type != "complete" || m.ID != "1" {
+		t.Fatalf("expected complete, got %+v", m)
+	}
+}
@@ -0,0 +1,27 @@
+package graph
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalInt32ID and UnmarshalInt32ID let the GraphQL ID scalar bind to the
+// int32 primary keys used by the domain model (see models.ID in gqlgen.yml).
+// IDs are serialized as strings, as the GraphQL spec recommends.
+func MarshalInt32ID(id int32) graphql.Marshaler {
+	return graphql.MarshalID(strconv.FormatInt(int64(id), 10))
+}
+
+func UnmarshalInt32ID(v any) (int32, error) {
+	s, err := graphql.UnmarshalID(v)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return int32(id), nil
+}
@@ -0,0 +1,195 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.55
+
+import (
+	"context"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/tasks"
+)
+
+// Tasks is the resolver for the tasks field.
+func (r *listResolver) Tasks(ctx context.Context, obj *tasks.List) ([]tasks.Task, error) {
+	lm, err := r.listManager()
+	if err != nil {
+		return nil, err
+	}
+	return lm.TasksInList(ctx, obj.ID)
+}
+
+// AddTask is the resolver for the addTask field.
+func (r *mutationResolver) AddTask(ctx context.Context, title string, description *string, dueAt *time.Time, priority *tasks.Priority, tags []string, listID *string, rrule *string, remindAt *time.Time) (tasks.Task, error) {
+	list, err := int32ID("listId", listID)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	n := tasks.NewTask{Title: title, DueAt: dueAt, RemindAt: remindAt, Tags: tags, ListID: list}
+	if description != nil {
+		n.Description = *description
+	}
+	if priority != nil {
+		n.Priority = *priority
+	}
+	if rrule != nil {
+		n.RRule = *rrule
+	}
+	n, err = tasks.NormalizeNewTask(n)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	return r.Service.Create(ctx, n)
+}
+
+// AddList is the resolver for the addList field.
+func (r *mutationResolver) AddList(ctx context.Context, name string) (tasks.List, error) {
+	lm, err := r.listManager()
+	if err != nil {
+		return tasks.List{}, err
+	}
+	return lm.CreateList(ctx, tasks.ListInput{Name: name})
+}
+
+// MoveTask is the resolver for the moveTask field.
+func (r *mutationResolver) MoveTask(ctx context.Context, id string, listID *string, beforeID *string, afterID *string) (tasks.Task, error) {
+	mv, ok := r.Service.(tasks.TaskMover)
+	if !ok {
+		return tasks.Task{}, apperr.NotImplemented("move_not_supported", "move not supported")
+	}
+	var m tasks.TaskMove
+	taskID, err := int32ID("id", &id)
+	if err == nil {
+		m.ListID, err = int32ID("listId", listID)
+	}
+	if err == nil {
+		m.BeforeID, err = int32ID("beforeId", beforeID)
+	}
+	if err == nil {
+		m.AfterID, err = int32ID("afterId", afterID)
+	}
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	return mv.Move(ctx, *taskID, m)
+}
+
+// SetParent is the resolver for the setParent field.
+func (r *mutationResolver) SetParent(ctx context.Context, id string, parentID *string) (tasks.Task, error) {
+	dm, err := r.dependencyManager()
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	taskID, err := int32ID("id", &id)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	parent, err := int32ID("parentId", parentID)
+	if err != nil {
+		return tasks.Task{}, err
+	}
+	return dm.SetParent(ctx, *taskID, parent)
+}
+
+// AddBlocker is the resolver for the addBlocker field.
+func (r *mutationResolver) AddBlocker(ctx context.Context, id string, blockerID string) (bool, error) {
+	dm, err := r.dependencyManager()
+	if err != nil {
+		return false, err
+	}
+	taskID, err := int32ID("id", &id)
+	if err != nil {
+		return false, err
+	}
+	blocker, err := int32ID("blockerId", &blockerID)
+	if err != nil {
+		return false, err
+	}
+	if err := dm.AddBlocker(ctx, *taskID, *blocker); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RemoveBlocker is the resolver for the removeBlocker field.
+func (r *mutationResolver) RemoveBlocker(ctx context.Context, id string, blockerID string) (bool, error) {
+	dm, err := r.dependencyManager()
+	if err != nil {
+		return false, err
+	}
+	taskID, err := int32ID("id", &id)
+	if err != nil {
+		return false, err
+	}
+	blocker, err := int32ID("blockerId", &blockerID)
+	if err != nil {
+		return false, err
+	}
+	if err := dm.RemoveBlocker(ctx, *taskID, *blocker); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Tasks is the resolver for the tasks field.
+func (r *queryResolver) Tasks(ctx context.Context, tag *string, dueBefore *time.Time) ([]tasks.Task, error) {
+	opts := tasks.ListOptions{DueBefore: dueBefore}
+	if tag != nil {
+		t, err := tasks.NormalizeTag(*tag)
+		if err != nil {
+			return nil, err
+		}
+		opts.Tag = t
+	}
+	page, err := r.Service.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// Lists is the resolver for the lists field.
+func (r *queryResolver) Lists(ctx context.Context) ([]tasks.List, error) {
+	lm, err := r.listManager()
+	if err != nil {
+		return nil, err
+	}
+	return lm.Lists(ctx)
+}
+
+// NextTasks is the resolver for the nextTasks field.
+func (r *queryResolver) NextTasks(ctx context.Context, limit *int) ([]tasks.Task, error) {
+	p, ok := r.Service.(tasks.TaskPlanner)
+	if !ok {
+		return nil, apperr.NotImplemented("next_not_supported", "next not supported")
+	}
+	n := tasks.DefaultNextLimit
+	if limit != nil {
+		n = *limit
+	}
+	return p.Next(ctx, n)
+}
+
+// TaskChanged is the resolver for the taskChanged field.
+func (r *subscriptionResolver) TaskChanged(ctx context.Context) (<-chan tasks.Event, error) {
+	return r.Service.Subscribe(ctx)
+}
+
+// List returns ListResolver implementation.
+func (r *Resolver) List() ListResolver { return &listResolver{r} }
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type listResolver struct{ *Resolver }
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,143 @@
+// Package apperr is the service's error model. Repo and Service return
+// *Error values that say what went wrong in domain terms (a Kind plus a
+// stable machine-readable Code); the HTTP layer maps them to a status code
+// and an RFC 7807 problem+json body in one place (see Write).
+//
+// Codes are part of the API contract: clients branch on them, so once
+// published a code must not change meaning.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind classifies an error. Each Kind maps to exactly one HTTP status.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindNotFound
+	KindConflict
+	KindUnavailable
+	KindNotImplemented
+	KindPreconditionFailed
+	KindUnauthenticated
+	KindUnprocessable
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindUnavailable:
+		return "unavailable"
+	case KindNotImplemented:
+		return "not_implemented"
+	case KindPreconditionFailed:
+		return "precondition_failed"
+	case KindUnauthenticated:
+		return "unauthenticated"
+	case KindUnprocessable:
+		return "unprocessable"
+	}
+	return "internal"
+}
+
+// FieldError pinpoints a validation failure on one input field,
+// e.g. {"field":"title","reason":"too_long"}.
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
+}
+
+// Error is a classified application error.
+type Error struct {
+	Kind    Kind
+	Code    string       // stable, machine-readable, e.g. "task_not_found"
+	Message string       // human-readable; safe to show to clients
+	Fields  []FieldError // validation details, if any
+	Err     error        // underlying cause; logged, never sent to clients
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Validation reports bad client input.
+func Validation(code, msg string, fields ...FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: msg, Fields: fields}
+}
+
+// NotFound reports that the addressed resource does not exist.
+func NotFound(code, msg string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: msg}
+}
+
+// Conflict reports that the request clashes with the current state,
+// e.g. a uniqueness violation.
+func Conflict(code, msg string, err error) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: msg, Err: err}
+}
+
+// Unavailable reports that a dependency (usually Postgres) can't be reached.
+// Clients may retry.
+func Unavailable(code, msg string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: msg, Err: err}
+}
+
+// NotImplemented reports that the configured service lacks a capability.
+func NotImplemented(code, msg string) *Error {
+	return &Error{Kind: KindNotImplemented, Code: code, Message: msg}
+}
+
+// PreconditionFailed reports that a conditional request (If-Match) did not
+// match the resource's current state, e.g. someone else updated it first.
+func PreconditionFailed(code, msg string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Code: code, Message: msg}
+}
+
+// Unauthenticated reports missing or invalid credentials.
+func Unauthenticated(code, msg string) *Error {
+	return &Error{Kind: KindUnauthenticated, Code: code, Message: msg}
+}
+
+// Unprocessable reports a well-formed request that cannot be applied as
+// sent, e.g. an Idempotency-Key reused with a different payload.
+func Unprocessable(code, msg string) *Error {
+	return &Error{Kind: KindUnprocessable, Code: code, Message: msg}
+}
+
+// Internal wraps an unexpected error. Its details are hidden from clients.
+func Internal(err error) *Error {
+	return &Error{Kind: KindInternal, Code: "internal", Message: "internal server error", Err: err}
+}
+
+// From returns err as an *Error, classifying anything unrecognized as
+// internal. It returns nil for a nil err.
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return Internal(err)
+}
+
+// IsKind reports whether err is (or wraps) an *Error of the given kind.
+func IsKind(err error, k Kind) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Kind == k
+}
@@ -0,0 +1,37 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestFrom_ClassifiesWrappedAndUnknownErrors(t *testing.T) {
+	nf := NotFound("task_not_found", "task not found")
+	if got := From(fmt.Errorf("get: %w", nf)); got != nf {
+		t.Fatalf("expected the wrapped *Error, got %#v", got)
+	}
+	if got := From(errors.New("boom")); got.Kind != KindInternal || got.Code != "internal" {
+		t.Fatalf("expected internal, got %#v", got)
+	}
+	if From(nil) != nil {
+		t.Fatalf("From(nil) must be nil")
+	}
+}
+
+func TestProblemFor(t *testing.T) {
+	err := Validation("invalid_title", "title is required", FieldError{Field: "title", Reason: "required"})
+	p := ProblemFor(err, "/api/tasks")
+	if p.Status != http.StatusBadRequest || p.Title != "Bad Request" || p.Code != "invalid_title" ||
+		p.Type != "urn:problem:tasks:invalid_title" || p.Instance != "/api/tasks" || len(p.Errors) != 1 {
+		t.Fatalf("unexpected problem: %#v", p)
+	}
+}
+
+func TestProblemFor_HidesInternalCause(t *testing.T) {
+	p := ProblemFor(errors.New(`ERROR: relation "tasks" does not exist`), "/api/tasks")
+	if p.Status != http.StatusInternalServerError || p.Detail != "internal server error" {
+		t.Fatalf("unexpected problem: %#v", p)
+	}
+}
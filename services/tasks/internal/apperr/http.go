@@ -0,0 +1,74 @@
+package apperr
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the RFC 7807 media type for error responses.
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 "problem details" body, extended with our
+// machine-readable Code and field-level validation Errors.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+// Status maps a Kind to its HTTP status code.
+func Status(k Kind) int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindNotImplemented:
+		return http.StatusNotImplemented
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case KindUnauthenticated:
+		return http.StatusUnauthorized
+	case KindUnprocessable:
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// ProblemFor builds the problem body for err as seen at the given request path.
+func ProblemFor(err error, instance string) Problem {
+	e := From(err)
+	status := Status(e.Kind)
+	return Problem{
+		Type:     "urn:problem:tasks:" + e.Code,
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		Errors:   e.Fields,
+	}
+}
+
+// Write is the single place handlers turn an error into a response.
+// Internal and unavailable errors are logged with their cause, since the
+// client only sees the generic message.
+func Write(c *gin.Context, err error) {
+	e := From(err)
+	if e.Kind == KindInternal || e.Kind == KindUnavailable {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	}
+	p := ProblemFor(e, c.Request.URL.Path)
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
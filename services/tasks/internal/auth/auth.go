@@ -0,0 +1,162 @@
+// Package auth resolves who is calling the tasks service, so the service can
+// scope every operation to the caller's own tasks.
+//
+// Three sources are supported, tried in this order:
+//
+//  1. Authorization: Bearer <JWT>, HS256-signed with AUTH_JWT_SECRET; the
+//     caller is the token's "sub" claim.
+//  2. Headers set by the Kong gateway after it has authenticated the request
+//     (X-Consumer-Username / X-Authenticated-Userid, as in orders-api). Only
+//     honoured with AUTH_TRUST_GATEWAY_HEADERS=true, i.e. when the service is
+//     reachable only through Kong; otherwise any client could set them.
+//  3. The shared "anonymous" identity, if AUTH_ALLOW_ANONYMOUS is on (the
+//     default in dev, so the local stack and frontends work without login).
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// Anonymous is the subject used for unauthenticated callers when allowed.
+const Anonymous = "anonymous"
+
+// Identity is the authenticated caller.
+type Identity struct {
+	Subject string   `json:"subject"`
+	Scopes  []string `json:"scopes"`
+}
+
+type ctxKey struct{}
+
+// WithIdentity returns a copy of ctx carrying id.
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the identity stored by the middleware, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(ctxKey{}).(Identity)
+	return id, ok
+}
+
+// IdentityFromHeaders reads the identity Kong forwards upstream once it has
+// authenticated a request. ok is false if no consumer header is present.
+func IdentityFromHeaders(h http.Header) (id Identity, ok bool) {
+	subject := firstNonEmpty(h.Get("X-Consumer-Username"), h.Get("X-Authenticated-Userid"))
+	if subject == "" {
+		return Identity{}, false
+	}
+	return Identity{Subject: subject, Scopes: splitScopes(h.Get("X-Authenticated-Scope"))}, true
+}
+
+// Options configures an Authenticator (see config.Config for the env vars).
+type Options struct {
+	JWTSecret           []byte // HS256 key; empty disables bearer tokens
+	JWTIssuer           string // required "iss" claim, if set
+	TrustGatewayHeaders bool
+	AllowAnonymous      bool
+}
+
+// Authenticator resolves the caller of an HTTP request.
+type Authenticator struct {
+	opts   Options
+	parser *jwt.Parser
+}
+
+// New returns an Authenticator for opts.
+func New(opts Options) *Authenticator {
+	popts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if opts.JWTIssuer != "" {
+		popts = append(popts, jwt.WithIssuer(opts.JWTIssuer))
+	}
+	return &Authenticator{opts: opts, parser: jwt.NewParser(popts...)}
+}
+
+// claims are the JWT claims we read.
+type claims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"` // space-separated, as in OAuth 2.0
+}
+
+// Authenticate resolves the caller of r. It returns an apperr
+// KindUnauthenticated error if the request carries a bad token, or carries
+// no credentials and anonymous access is off.
+//
+// Without a JWT secret, a trusted gateway has already checked whatever
+// Authorization header it passes through (Kong's jwt and openid-connect
+// plugins do), so the header is ignored in favour of the gateway's
+// consumer headers.
+func (a *Authenticator) Authenticate(r *http.Request) (Identity, error) {
+	h := r.Header.Get("Authorization")
+	viaGateway := len(a.opts.JWTSecret) == 0 && a.opts.TrustGatewayHeaders
+	if h != "" && !viaGateway {
+		token, ok := strings.CutPrefix(h, "Bearer ")
+		if !ok || len(a.opts.JWTSecret) == 0 {
+			return Identity{}, apperr.Unauthenticated("unsupported_credentials", "unsupported authorization scheme")
+		}
+		return a.identityFromJWT(strings.TrimSpace(token))
+	}
+	if a.opts.TrustGatewayHeaders {
+		if id, ok := IdentityFromHeaders(r.Header); ok {
+			return id, nil
+		}
+	}
+	if h != "" {
+		// Credentials we can't check, and no gateway identity to go by.
+		return Identity{}, apperr.Unauthenticated("unsupported_credentials", "unsupported authorization scheme")
+	}
+	if a.opts.AllowAnonymous {
+		return Identity{Subject: Anonymous, Scopes: []string{}}, nil
+	}
+	return Identity{}, apperr.Unauthenticated("unauthenticated", "authentication required")
+}
+
+func (a *Authenticator) identityFromJWT(token string) (Identity, error) {
+	var c claims
+	_, err := a.parser.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return a.opts.JWTSecret, nil
+	})
+	if err != nil || c.Subject == "" {
+		return Identity{}, apperr.Unauthenticated("invalid_token", "invalid or expired bearer token")
+	}
+	return Identity{Subject: c.Subject, Scopes: splitScopes(c.Scope)}, nil
+}
+
+// Middleware authenticates every request in the group and stores the
+// identity in the request context for the service layer (FromContext).
+// Unauthenticated requests are rejected with a 401 problem response.
+func (a *Authenticator) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := a.Authenticate(c.Request)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="tasks"`)
+			apperr.Write(c, err)
+			return
+		}
+		c.Request = c.Request.WithContext(WithIdentity(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+func splitScopes(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool { return r == ' ' || r == ',' })
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
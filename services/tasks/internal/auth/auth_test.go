@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+var testSecret = []byte("test-secret")
+
+func signed(t *testing.T, method jwt.SigningMethod, key any, c jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(method, c).SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func TestIdentityFromHeaders(t *testing.T) {
+	h := http.Header{
+		"X-Consumer-Username":   []string{"partner-app"},
+		"X-Authenticated-Scope": []string{"tasks:read tasks:write"},
+	}
+	id, ok := IdentityFromHeaders(h)
+	if !ok || id.Subject != "partner-app" || !reflect.DeepEqual(id.Scopes, []string{"tasks:read", "tasks:write"}) {
+		t.Fatalf("unexpected identity: %#v, %v", id, ok)
+	}
+	if _, ok := IdentityFromHeaders(http.Header{}); ok {
+		t.Fatalf("expected no identity without consumer headers")
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	exp := time.Now().Add(time.Hour).Unix()
+	valid := signed(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "alice", "exp": exp, "scope": "tasks:read"})
+	expired := signed(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()})
+	noExp := signed(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"sub": "alice"})
+	wrongKey := signed(t, jwt.SigningMethodHS256, []byte("other"), jwt.MapClaims{"sub": "alice", "exp": exp})
+	noSub := signed(t, jwt.SigningMethodHS256, testSecret, jwt.MapClaims{"exp": exp})
+
+	a := New(Options{JWTSecret: testSecret, TrustGatewayHeaders: true})
+	cases := []struct {
+		name    string
+		headers map[string]string
+		want    string // subject; "" means unauthenticated
+	}{
+		{"valid jwt", map[string]string{"Authorization": "Bearer " + valid}, "alice"},
+		{"jwt wins over gateway headers", map[string]string{"Authorization": "Bearer " + valid, "X-Consumer-Username": "bob"}, "alice"},
+		{"gateway headers", map[string]string{"X-Authenticated-Userid": "bob"}, "bob"},
+		{"expired", map[string]string{"Authorization": "Bearer " + expired}, ""},
+		{"no exp", map[string]string{"Authorization": "Bearer " + noExp}, ""},
+		{"wrong key", map[string]string{"Authorization": "Bearer " + wrongKey}, ""},
+		{"no sub", map[string]string{"Authorization": "Bearer " + noSub}, ""},
+		{"basic auth", map[string]string{"Authorization": "Basic Ym9iOnB3"}, ""},
+		{"nothing, anonymous off", nil, ""},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+		id, err := a.Authenticate(r)
+		if tc.want == "" {
+			if !apperr.IsKind(err, apperr.KindUnauthenticated) {
+				t.Errorf("%s: expected unauthenticated, got %#v, %v", tc.name, id, err)
+			}
+			continue
+		}
+		if err != nil || id.Subject != tc.want {
+			t.Errorf("%s: expected %q, got %#v, %v", tc.name, tc.want, id, err)
+		}
+	}
+}
+
+func TestAuthenticate_GatewayHeadersWithBearerAndNoSecret(t *testing.T) {
+	a := New(Options{TrustGatewayHeaders: true, AllowAnonymous: true})
+
+	// Kong passes the Authorization header it verified through, next to
+	// the consumer headers.
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer kong-checked-this")
+	r.Header.Set("X-Consumer-Username", "partner-app")
+	if id, err := a.Authenticate(r); err != nil || id.Subject != "partner-app" {
+		t.Fatalf("expected the gateway consumer, got %#v, %v", id, err)
+	}
+
+	// A bearer token alone is not silently downgraded to anonymous.
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer unchecked")
+	if id, err := a.Authenticate(r); !apperr.IsKind(err, apperr.KindUnauthenticated) {
+		t.Fatalf("expected unauthenticated, got %#v, %v", id, err)
+	}
+}
+
+func TestAuthenticate_GatewayHeadersIgnoredUnlessTrusted(t *testing.T) {
+	a := New(Options{AllowAnonymous: true})
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Consumer-Username", "mallory")
+
+	id, err := a.Authenticate(r)
+	if err != nil || id.Subject != Anonymous {
+		t.Fatalf("expected anonymous, got %#v, %v", id, err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/whoami", New(Options{}).Middleware(), func(c *gin.Context) {
+		id, _ := FromContext(c.Request.Context())
+		c.String(http.StatusOK, id.Subject)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with WWW-Authenticate, got %d %v", w.Code, w.Header())
+	}
+
+	r = gin.New()
+	r.GET("/whoami", New(Options{AllowAnonymous: true}).Middleware(), func(c *gin.Context) {
+		id, _ := FromContext(c.Request.Context())
+		c.String(http.StatusOK, id.Subject)
+	})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/whoami", nil))
+	if w.Code != http.StatusOK || w.Body.String() != Anonymous {
+		t.Fatalf("expected anonymous caller, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoad_DefaultsAndOverrides(t *testing.T) {
+	t.Setenv("HTTP_WRITE_TIMEOUT", "")
+	t.Setenv("SHUTDOWN_TIMEOUT", "3s")
+	t.Setenv("MIGRATE_ON_START", "true")
+
+	c := Load()
+
+	if c.WriteTimeout != 15*time.Second {
+		t.Fatalf("expected default write timeout 15s, got %s", c.WriteTimeout)
+	}
+	if c.ShutdownTimeout != 3*time.Second {
+		t.Fatalf("expected shutdown timeout 3s, got %s", c.ShutdownTimeout)
+	}
+	if !c.MigrateOnStart {
+		t.Fatalf("expected MigrateOnStart to be true")
+	}
+}
+
+func TestLookupDuration_ZeroInterval(t *testing.T) {
+	t.Setenv("TASKS_SCHEDULER_INTERVAL", "0s")
+	if _, err := lookupDuration("TASKS_SCHEDULER_INTERVAL", time.Second, true); err == nil {
+		t.Fatalf("expected a zero interval to be rejected")
+	}
+	// Zero is fine where it means "none", e.g. SHUTDOWN_DELAY.
+	if d, err := lookupDuration("TASKS_SCHEDULER_INTERVAL", time.Second, false); err != nil || d != 0 {
+		t.Fatalf("expected 0, got %s, %v", d, err)
+	}
+	t.Setenv("OUTBOX_POLL_INTERVAL", "0")
+	if _, err := lookupDuration("OUTBOX_POLL_INTERVAL", time.Second, true); err == nil {
+		t.Fatalf("expected a zero poll interval to be rejected")
+	}
+	t.Setenv("TASKS_SCHEDULER_INTERVAL", "-1s")
+	if _, err := lookupDuration("TASKS_SCHEDULER_INTERVAL", time.Second, false); err == nil {
+		t.Fatalf("expected a negative duration to be rejected")
+	}
+}
+
+func TestLoad_AnonymousAuthDefaultsToDevOnly(t *testing.T) {
+	t.Setenv("AUTH_ALLOW_ANONYMOUS", "")
+
+	t.Setenv("ENV", "dev")
+	if !Load().AuthAllowAnonymous {
+		t.Fatalf("expected anonymous access in dev")
+	}
+	t.Setenv("ENV", "prod")
+	if Load().AuthAllowAnonymous {
+		t.Fatalf("expected no anonymous access outside dev")
+	}
+}
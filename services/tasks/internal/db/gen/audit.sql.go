@@ -0,0 +1,275 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: audit.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const appendTaskAudit = `-- name: AppendTaskAudit :exec
+INSERT INTO task_audit (task_id, owner_id, actor, action, request_id, before, after)
+VALUES ($1, $2, $3, $4, $5,
+        $6, $7)
+`
+
+type AppendTaskAuditParams struct {
+	TaskID    int32
+	OwnerID   string
+	Actor     string
+	Action    string
+	RequestID string
+	Before    []byte
+	After     []byte
+}
+
+func (q *Queries) AppendTaskAudit(ctx context.Context, arg AppendTaskAuditParams) error {
+	_, err := q.db.Exec(ctx, appendTaskAudit,
+		arg.TaskID,
+		arg.OwnerID,
+		arg.Actor,
+		arg.Action,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+	)
+	return err
+}
+
+const appendTaskAuditBatch = `-- name: AppendTaskAuditBatch :exec
+INSERT INTO task_audit (task_id, owner_id, actor, action, request_id, after)
+SELECT unnest($1::int[]), $2, $3, 'created', $4,
+       unnest($5::text[])::jsonb
+`
+
+type AppendTaskAuditBatchParams struct {
+	TaskIds   []int32
+	OwnerID   string
+	Actor     string
+	RequestID string
+	Afters    []string
+}
+
+// The bulk form of AppendTaskAudit for created tasks (no before).
+func (q *Queries) AppendTaskAuditBatch(ctx context.Context, arg AppendTaskAuditBatchParams) error {
+	_, err := q.db.Exec(ctx, appendTaskAuditBatch,
+		arg.TaskIds,
+		arg.OwnerID,
+		arg.Actor,
+		arg.RequestID,
+		arg.Afters,
+	)
+	return err
+}
+
+const listTaskAudit = `-- name: ListTaskAudit :many
+SELECT a.id, a.task_id, a.actor, a.action, a.request_id, a.before, a.after, a.created_at
+FROM task_audit a
+WHERE a.owner_id = $1
+  AND ($2::int IS NULL OR a.task_id = $2)
+  AND ($3::text IS NULL OR a.actor = $3)
+  AND ($4::text IS NULL OR a.action = $4)
+  AND ($5::text IS NULL OR a.request_id = $5)
+  AND ($6::timestamptz IS NULL OR a.created_at >= $6)
+  AND ($7::timestamptz IS NULL OR a.created_at < $7)
+  AND ($8::bigint IS NULL OR a.id < $8)
+ORDER BY a.id DESC
+LIMIT $9::int
+`
+
+type ListTaskAuditParams struct {
+	OwnerID   string
+	TaskID    pgtype.Int4
+	Actor     pgtype.Text
+	Action    pgtype.Text
+	RequestID pgtype.Text
+	Since     pgtype.Timestamptz
+	Until     pgtype.Timestamptz
+	BeforeID  pgtype.Int8
+	Lim       int32
+}
+
+type ListTaskAuditRow struct {
+	ID        int64
+	TaskID    int32
+	Actor     string
+	Action    string
+	RequestID string
+	Before    []byte
+	After     []byte
+	CreatedAt pgtype.Timestamptz
+}
+
+// owner_id's audit entries, newest first, narrowed by any of the optional
+// filters. before_id is the keyset cursor: the last id of the previous page.
+func (q *Queries) ListTaskAudit(ctx context.Context, arg ListTaskAuditParams) ([]ListTaskAuditRow, error) {
+	rows, err := q.db.Query(ctx, listTaskAudit,
+		arg.OwnerID,
+		arg.TaskID,
+		arg.Actor,
+		arg.Action,
+		arg.RequestID,
+		arg.Since,
+		arg.Until,
+		arg.BeforeID,
+		arg.Lim,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTaskAuditRow
+	for rows.Next() {
+		var i ListTaskAuditRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.Actor,
+			&i.Action,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const snapshotListTasks = `-- name: SnapshotListTasks :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE list_id = $1 AND owner_id = $2
+ORDER BY id
+FOR UPDATE
+`
+
+type SnapshotListTasksParams struct {
+	ListID  pgtype.Int4
+	OwnerID string
+}
+
+type SnapshotListTasksRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// SnapshotTask for every task in a list, trashed ones included.
+func (q *Queries) SnapshotListTasks(ctx context.Context, arg SnapshotListTasksParams) ([]SnapshotListTasksRow, error) {
+	rows, err := q.db.Query(ctx, snapshotListTasks, arg.ListID, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SnapshotListTasksRow
+	for rows.Next() {
+		var i SnapshotListTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const snapshotTask = `-- name: SnapshotTask :one
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE id = $1 AND owner_id = $2
+FOR UPDATE
+`
+
+type SnapshotTaskParams struct {
+	ID      int32
+	OwnerID string
+}
+
+type SnapshotTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// A task as it is before a change, trashed or not, locked until the end of
+// the transaction so the change applies to exactly this version.
+func (q *Queries) SnapshotTask(ctx context.Context, arg SnapshotTaskParams) (SnapshotTaskRow, error) {
+	row := q.db.QueryRow(ctx, snapshotTask, arg.ID, arg.OwnerID)
+	var i SnapshotTaskRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
@@ -0,0 +1,53 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: copyfrom.go
+
+package db
+
+import (
+	"context"
+)
+
+// iteratorForCopyTasks implements pgx.CopyFromSource.
+type iteratorForCopyTasks struct {
+	rows                 []CopyTasksParams
+	skippedFirstNextCall bool
+}
+
+func (r *iteratorForCopyTasks) Next() bool {
+	if len(r.rows) == 0 {
+		return false
+	}
+	if !r.skippedFirstNextCall {
+		r.skippedFirstNextCall = true
+		return true
+	}
+	r.rows = r.rows[1:]
+	return len(r.rows) > 0
+}
+
+func (r iteratorForCopyTasks) Values() ([]interface{}, error) {
+	return []interface{}{
+		r.rows[0].ID,
+		r.rows[0].Title,
+		r.rows[0].OwnerID,
+		r.rows[0].Description,
+		r.rows[0].DueAt,
+		r.rows[0].Priority,
+		r.rows[0].Rrule,
+		r.rows[0].RruleStartAt,
+		r.rows[0].NextOccurrenceAt,
+		r.rows[0].RemindAt,
+		r.rows[0].ListID,
+		r.rows[0].Position,
+	}, nil
+}
+
+func (r iteratorForCopyTasks) Err() error {
+	return nil
+}
+
+func (q *Queries) CopyTasks(ctx context.Context, arg []CopyTasksParams) (int64, error) {
+	return q.db.CopyFrom(ctx, []string{"tasks"}, []string{"id", "title", "owner_id", "description", "due_at", "priority", "rrule", "rrule_start_at", "next_occurrence_at", "remind_at", "list_id", "position"}, &iteratorForCopyTasks{rows: arg})
+}
@@ -0,0 +1,363 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: dependencies.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const addTaskDependency = `-- name: AddTaskDependency :exec
+INSERT INTO task_dependencies (task_id, blocked_by_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddTaskDependencyParams struct {
+	TaskID      int32
+	BlockedByID int32
+}
+
+func (q *Queries) AddTaskDependency(ctx context.Context, arg AddTaskDependencyParams) error {
+	_, err := q.db.Exec(ctx, addTaskDependency, arg.TaskID, arg.BlockedByID)
+	return err
+}
+
+const listBlockers = `-- name: ListBlockers :many
+SELECT t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at,
+       t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at
+FROM task_dependencies d
+JOIN tasks t ON t.id = d.blocked_by_id
+WHERE d.task_id = $1 AND t.owner_id = $2 AND t.deleted_at IS NULL
+ORDER BY t.id
+`
+
+type ListBlockersParams struct {
+	TaskID  int32
+	OwnerID string
+}
+
+type ListBlockersRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// The live tasks blocking task_id, done or not.
+func (q *Queries) ListBlockers(ctx context.Context, arg ListBlockersParams) ([]ListBlockersRow, error) {
+	rows, err := q.db.Query(ctx, listBlockers, arg.TaskID, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListBlockersRow
+	for rows.Next() {
+		var i ListBlockersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenTasks = `-- name: ListOpenTasks :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+FROM tasks
+WHERE owner_id = $1 AND deleted_at IS NULL AND NOT done
+`
+
+type ListOpenTasksRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// owner's live tasks that are not done: the nodes of the plan Next ranks.
+func (q *Queries) ListOpenTasks(ctx context.Context, ownerID string) ([]ListOpenTasksRow, error) {
+	rows, err := q.db.Query(ctx, listOpenTasks, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOpenTasksRow
+	for rows.Next() {
+		var i ListOpenTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSubtasks = `-- name: ListSubtasks :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+FROM tasks
+WHERE parent_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+ORDER BY id
+`
+
+type ListSubtasksParams struct {
+	ParentID pgtype.Int4
+	OwnerID  string
+}
+
+type ListSubtasksRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+func (q *Queries) ListSubtasks(ctx context.Context, arg ListSubtasksParams) ([]ListSubtasksRow, error) {
+	rows, err := q.db.Query(ctx, listSubtasks, arg.ParentID, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListSubtasksRow
+	for rows.Next() {
+		var i ListSubtasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTaskEdges = `-- name: ListTaskEdges :many
+SELECT d.blocked_by_id AS before_id, d.task_id AS after_id
+FROM task_dependencies d
+JOIN tasks t ON t.id = d.task_id
+WHERE t.owner_id = $1
+UNION ALL
+SELECT c.id AS before_id, c.parent_id::int AS after_id
+FROM tasks c
+WHERE c.owner_id = $1 AND c.parent_id IS NOT NULL
+`
+
+type ListTaskEdgesRow struct {
+	BeforeID int32
+	AfterID  int32
+}
+
+// Every "must finish before" edge of owner's tasks, trashed ones included
+// (they can be restored): blocker before blocked, subtask before parent.
+func (q *Queries) ListTaskEdges(ctx context.Context, ownerID string) ([]ListTaskEdgesRow, error) {
+	rows, err := q.db.Query(ctx, listTaskEdges, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTaskEdgesRow
+	for rows.Next() {
+		var i ListTaskEdgesRow
+		if err := rows.Scan(&i.BeforeID, &i.AfterID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockTaskGraph = `-- name: LockTaskGraph :exec
+SELECT pg_advisory_xact_lock(hashtext('task_graph:' || $1::text))
+`
+
+// Serializes changes to one owner's task graph for the rest of the
+// transaction, so two concurrent links cannot each pass the cycle check
+// and together close a cycle.
+func (q *Queries) LockTaskGraph(ctx context.Context, ownerID string) error {
+	_, err := q.db.Exec(ctx, lockTaskGraph, ownerID)
+	return err
+}
+
+const removeTaskDependency = `-- name: RemoveTaskDependency :execrows
+DELETE FROM task_dependencies
+WHERE task_id = $1 AND blocked_by_id = $2
+`
+
+type RemoveTaskDependencyParams struct {
+	TaskID      int32
+	BlockedByID int32
+}
+
+// Both tasks are known to be the caller's.
+func (q *Queries) RemoveTaskDependency(ctx context.Context, arg RemoveTaskDependencyParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeTaskDependency, arg.TaskID, arg.BlockedByID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const setTaskParent = `-- name: SetTaskParent :one
+UPDATE tasks SET parent_id = $1
+WHERE id = $2 AND owner_id = $3 AND deleted_at IS NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type SetTaskParentParams struct {
+	ParentID pgtype.Int4
+	ID       int32
+	OwnerID  string
+}
+
+type SetTaskParentRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Makes a live task a subtask of parent_id, or a top-level task if NULL.
+func (q *Queries) SetTaskParent(ctx context.Context, arg SetTaskParentParams) (SetTaskParentRow, error) {
+	row := q.db.QueryRow(ctx, setTaskParent, arg.ParentID, arg.ID, arg.OwnerID)
+	var i SetTaskParentRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
@@ -0,0 +1,135 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: events.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const appendTaskEvent = `-- name: AppendTaskEvent :exec
+INSERT INTO task_events (task_id, seq, change, owner_id, type, data, undoes, redoes, actor, request_id, occurred_at)
+VALUES ($1, $2, $3, $4, $5, $6,
+        $7, $8, $9, $10,
+        COALESCE($11::timestamptz, now()))
+`
+
+type AppendTaskEventParams struct {
+	TaskID     int32
+	Seq        int32
+	Change     int32
+	OwnerID    string
+	Type       string
+	Data       []byte
+	Undoes     pgtype.Int4
+	Redoes     pgtype.Int4
+	Actor      string
+	RequestID  string
+	OccurredAt pgtype.Timestamptz
+}
+
+// occurred_at defaults to the transaction's time, like updated_at.
+func (q *Queries) AppendTaskEvent(ctx context.Context, arg AppendTaskEventParams) error {
+	_, err := q.db.Exec(ctx, appendTaskEvent,
+		arg.TaskID,
+		arg.Seq,
+		arg.Change,
+		arg.OwnerID,
+		arg.Type,
+		arg.Data,
+		arg.Undoes,
+		arg.Redoes,
+		arg.Actor,
+		arg.RequestID,
+		arg.OccurredAt,
+	)
+	return err
+}
+
+const lastTaskEvent = `-- name: LastTaskEvent :one
+SELECT seq, COALESCE((
+    SELECT (v.data->'after'->>'version')::int FROM task_events v
+    WHERE v.task_id = $1 AND v.data->'after'->'version' IS NOT NULL
+    ORDER BY v.seq DESC
+    LIMIT 1
+  ), 0)::int AS version
+FROM task_events
+WHERE task_id = $1
+ORDER BY seq DESC
+LIMIT 1
+`
+
+type LastTaskEventRow struct {
+	Seq     int32
+	Version int32
+}
+
+// The seq of a task's latest event, and the task version it left behind:
+// that of the latest event to record one (an older event may not), or 0.
+func (q *Queries) LastTaskEvent(ctx context.Context, taskID int32) (LastTaskEventRow, error) {
+	row := q.db.QueryRow(ctx, lastTaskEvent, taskID)
+	var i LastTaskEventRow
+	err := row.Scan(&i.Seq, &i.Version)
+	return i, err
+}
+
+const listTaskEvents = `-- name: ListTaskEvents :many
+SELECT task_id, seq, change, type, data, undoes, redoes, actor, request_id, occurred_at
+FROM task_events
+WHERE task_id = $1 AND owner_id = $2
+ORDER BY seq
+`
+
+type ListTaskEventsParams struct {
+	TaskID  int32
+	OwnerID string
+}
+
+type ListTaskEventsRow struct {
+	TaskID     int32
+	Seq        int32
+	Change     int32
+	Type       string
+	Data       []byte
+	Undoes     pgtype.Int4
+	Redoes     pgtype.Int4
+	Actor      string
+	RequestID  string
+	OccurredAt pgtype.Timestamptz
+}
+
+// One of owner's task streams, in order.
+func (q *Queries) ListTaskEvents(ctx context.Context, arg ListTaskEventsParams) ([]ListTaskEventsRow, error) {
+	rows, err := q.db.Query(ctx, listTaskEvents, arg.TaskID, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTaskEventsRow
+	for rows.Next() {
+		var i ListTaskEventsRow
+		if err := rows.Scan(
+			&i.TaskID,
+			&i.Seq,
+			&i.Change,
+			&i.Type,
+			&i.Data,
+			&i.Undoes,
+			&i.Redoes,
+			&i.Actor,
+			&i.RequestID,
+			&i.OccurredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
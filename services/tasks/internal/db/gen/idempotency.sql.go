@@ -0,0 +1,98 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: idempotency.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const getIdempotencyKey = `-- name: GetIdempotencyKey :one
+SELECT request_hash, status_code, response FROM idempotency_keys
+WHERE owner_id = $1 AND key = $2 AND expires_at > now()
+`
+
+type GetIdempotencyKeyParams struct {
+	OwnerID string
+	Key     string
+}
+
+type GetIdempotencyKeyRow struct {
+	RequestHash string
+	StatusCode  int32
+	Response    []byte
+}
+
+// Expired keys are treated as never used.
+func (q *Queries) GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (GetIdempotencyKeyRow, error) {
+	row := q.db.QueryRow(ctx, getIdempotencyKey, arg.OwnerID, arg.Key)
+	var i GetIdempotencyKeyRow
+	err := row.Scan(&i.RequestHash, &i.StatusCode, &i.Response)
+	return i, err
+}
+
+const lockIdempotencyKey = `-- name: LockIdempotencyKey :exec
+SELECT pg_advisory_xact_lock(hashtextextended($1::text || ':' || $2::text, 0))
+`
+
+type LockIdempotencyKeyParams struct {
+	OwnerID string
+	Key     string
+}
+
+// Serializes concurrent requests carrying the same key until the calling
+// transaction ends, so only the first one creates anything.
+func (q *Queries) LockIdempotencyKey(ctx context.Context, arg LockIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, lockIdempotencyKey, arg.OwnerID, arg.Key)
+	return err
+}
+
+const purgeIdempotencyKeys = `-- name: PurgeIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE expires_at <= now()
+`
+
+func (q *Queries) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeIdempotencyKeys)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const saveIdempotencyKey = `-- name: SaveIdempotencyKey :exec
+INSERT INTO idempotency_keys (owner_id, key, request_hash, status_code, response, expires_at)
+VALUES ($1, $2, $3, $4,
+        $5, $6)
+ON CONFLICT (owner_id, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = EXCLUDED.status_code,
+    response = EXCLUDED.response,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at
+`
+
+type SaveIdempotencyKeyParams struct {
+	OwnerID     string
+	Key         string
+	RequestHash string
+	StatusCode  int32
+	Response    []byte
+	ExpiresAt   pgtype.Timestamptz
+}
+
+// Overwrites an expired row left behind for the same key.
+func (q *Queries) SaveIdempotencyKey(ctx context.Context, arg SaveIdempotencyKeyParams) error {
+	_, err := q.db.Exec(ctx, saveIdempotencyKey,
+		arg.OwnerID,
+		arg.Key,
+		arg.RequestHash,
+		arg.StatusCode,
+		arg.Response,
+		arg.ExpiresAt,
+	)
+	return err
+}
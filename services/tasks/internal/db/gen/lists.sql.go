@@ -0,0 +1,519 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: lists.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const createList = `-- name: CreateList :one
+INSERT INTO lists (owner_id, name) VALUES ($1, $2)
+RETURNING id, owner_id, name, created_at, updated_at
+`
+
+type CreateListParams struct {
+	OwnerID string
+	Name    string
+}
+
+func (q *Queries) CreateList(ctx context.Context, arg CreateListParams) (List, error) {
+	row := q.db.QueryRow(ctx, createList, arg.OwnerID, arg.Name)
+	var i List
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteList = `-- name: DeleteList :execrows
+DELETE FROM lists WHERE id = $1 AND owner_id = $2
+`
+
+type DeleteListParams struct {
+	ID      int32
+	OwnerID string
+}
+
+func (q *Queries) DeleteList(ctx context.Context, arg DeleteListParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteList, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const detachListTasks = `-- name: DetachListTasks :many
+UPDATE tasks SET list_id = NULL, position = NULL
+WHERE list_id = $1
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type DetachListTasksRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Takes every task (trashed ones too) out of a list that is about to be
+// deleted.
+func (q *Queries) DetachListTasks(ctx context.Context, listID pgtype.Int4) ([]DetachListTasksRow, error) {
+	rows, err := q.db.Query(ctx, detachListTasks, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DetachListTasksRow
+	for rows.Next() {
+		var i DetachListTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getList = `-- name: GetList :one
+SELECT id, owner_id, name, created_at, updated_at FROM lists
+WHERE id = $1 AND owner_id = $2
+`
+
+type GetListParams struct {
+	ID      int32
+	OwnerID string
+}
+
+func (q *Queries) GetList(ctx context.Context, arg GetListParams) (List, error) {
+	row := q.db.QueryRow(ctx, getList, arg.ID, arg.OwnerID)
+	var i List
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const lastListPosition = `-- name: LastListPosition :one
+SELECT COALESCE(max(position), 0)::float8 AS position FROM tasks
+WHERE list_id = $1 AND id <> $2
+`
+
+type LastListPositionParams struct {
+	ListID    pgtype.Int4
+	ExcludeID int32
+}
+
+// The position after which a task is appended to a list; 0 if it is empty.
+// Trashed tasks keep their place, so they count too.
+func (q *Queries) LastListPosition(ctx context.Context, arg LastListPositionParams) (float64, error) {
+	row := q.db.QueryRow(ctx, lastListPosition, arg.ListID, arg.ExcludeID)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const listLists = `-- name: ListLists :many
+SELECT id, owner_id, name, created_at, updated_at FROM lists
+WHERE owner_id = $1 ORDER BY id
+`
+
+func (q *Queries) ListLists(ctx context.Context, ownerID string) ([]List, error) {
+	rows, err := q.db.Query(ctx, listLists, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []List
+	for rows.Next() {
+		var i List
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPositionAfter = `-- name: ListPositionAfter :one
+SELECT position::float8 AS position FROM tasks
+WHERE list_id = $1 AND id <> $2
+  AND (position, id) > ($3::float8, $4::int)
+ORDER BY position, id
+LIMIT 1
+`
+
+type ListPositionAfterParams struct {
+	ListID    pgtype.Int4
+	ExcludeID int32
+	Position  float64
+	AnchorID  int32
+}
+
+// The mirror image of ListPositionBefore. No row means the anchor is last.
+func (q *Queries) ListPositionAfter(ctx context.Context, arg ListPositionAfterParams) (float64, error) {
+	row := q.db.QueryRow(ctx, listPositionAfter,
+		arg.ListID,
+		arg.ExcludeID,
+		arg.Position,
+		arg.AnchorID,
+	)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const listPositionBefore = `-- name: ListPositionBefore :one
+SELECT position::float8 AS position FROM tasks
+WHERE list_id = $1 AND id <> $2
+  AND (position, id) < ($3::float8, $4::int)
+ORDER BY position DESC, id DESC
+LIMIT 1
+`
+
+type ListPositionBeforeParams struct {
+	ListID    pgtype.Int4
+	ExcludeID int32
+	Position  float64
+	AnchorID  int32
+}
+
+// The position of the task just before (position, id) in a list, ignoring
+// the task being moved. No row means the anchor is first.
+func (q *Queries) ListPositionBefore(ctx context.Context, arg ListPositionBeforeParams) (float64, error) {
+	row := q.db.QueryRow(ctx, listPositionBefore,
+		arg.ListID,
+		arg.ExcludeID,
+		arg.Position,
+		arg.AnchorID,
+	)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const listTaskPosition = `-- name: ListTaskPosition :one
+SELECT position::float8 AS position FROM tasks
+WHERE id = $1 AND list_id = $2 AND deleted_at IS NULL
+`
+
+type ListTaskPositionParams struct {
+	ID     int32
+	ListID pgtype.Int4
+}
+
+// The position of a live task of the given list, the anchor of a move.
+func (q *Queries) ListTaskPosition(ctx context.Context, arg ListTaskPositionParams) (float64, error) {
+	row := q.db.QueryRow(ctx, listTaskPosition, arg.ID, arg.ListID)
+	var position float64
+	err := row.Scan(&position)
+	return position, err
+}
+
+const listTasksInList = `-- name: ListTasksInList :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+FROM tasks
+WHERE list_id = $1 AND owner_id = $2 AND deleted_at IS NULL
+ORDER BY position, id
+`
+
+type ListTasksInListParams struct {
+	ListID  pgtype.Int4
+	OwnerID string
+}
+
+type ListTasksInListRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// A list's live tasks in list order. Ties (from concurrent moves) are
+// broken by id, so the order is always total.
+func (q *Queries) ListTasksInList(ctx context.Context, arg ListTasksInListParams) ([]ListTasksInListRow, error) {
+	rows, err := q.db.Query(ctx, listTasksInList, arg.ListID, arg.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTasksInListRow
+	for rows.Next() {
+		var i ListTasksInListRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockList = `-- name: LockList :one
+SELECT id FROM lists
+WHERE id = $1 AND owner_id = $2
+FOR UPDATE
+`
+
+type LockListParams struct {
+	ID      int32
+	OwnerID string
+}
+
+// Locks one of owner's lists for the rest of the transaction, so that
+// concurrent moves into it pick positions (and renumber) one at a time.
+func (q *Queries) LockList(ctx context.Context, arg LockListParams) (int32, error) {
+	row := q.db.QueryRow(ctx, lockList, arg.ID, arg.OwnerID)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const moveTask = `-- name: MoveTask :one
+UPDATE tasks SET list_id = $1, position = $2
+WHERE id = $3 AND owner_id = $4 AND deleted_at IS NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type MoveTaskParams struct {
+	ListID   pgtype.Int4
+	Position pgtype.Float8
+	ID       int32
+	OwnerID  string
+}
+
+type MoveTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Puts a live task at position in a list, or, with both NULL, takes it out
+// of its list.
+func (q *Queries) MoveTask(ctx context.Context, arg MoveTaskParams) (MoveTaskRow, error) {
+	row := q.db.QueryRow(ctx, moveTask,
+		arg.ListID,
+		arg.Position,
+		arg.ID,
+		arg.OwnerID,
+	)
+	var i MoveTaskRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
+
+const renumberList = `-- name: RenumberList :many
+UPDATE tasks t SET position = r.n
+FROM (
+  SELECT s.id, row_number() OVER (ORDER BY s.position, s.id)::float8 AS n
+  FROM tasks s WHERE s.list_id = $1
+) r
+WHERE t.id = r.id AND t.position IS DISTINCT FROM r.n
+RETURNING t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at, t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at
+`
+
+type RenumberListRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Spreads a list's positions back out to 1, 2, 3, ... in the same order,
+// for when repeated moves into the same gap have run out of float
+// precision. Returns the tasks (trashed ones too) whose position changed.
+func (q *Queries) RenumberList(ctx context.Context, listID pgtype.Int4) ([]RenumberListRow, error) {
+	rows, err := q.db.Query(ctx, renumberList, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []RenumberListRow
+	for rows.Next() {
+		var i RenumberListRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateList = `-- name: UpdateList :one
+UPDATE lists SET name = $1, updated_at = now()
+WHERE id = $2 AND owner_id = $3
+RETURNING id, owner_id, name, created_at, updated_at
+`
+
+type UpdateListParams struct {
+	Name    string
+	ID      int32
+	OwnerID string
+}
+
+func (q *Queries) UpdateList(ctx context.Context, arg UpdateListParams) (List, error) {
+	row := q.db.QueryRow(ctx, updateList, arg.Name, arg.ID, arg.OwnerID)
+	var i List
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
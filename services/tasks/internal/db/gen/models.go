@@ -5,13 +5,197 @@
 package db
 
 import (
+	"database/sql/driver"
+	"fmt"
+
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type TaskPriority string
+
+const (
+	TaskPriorityLow    TaskPriority = "low"
+	TaskPriorityNormal TaskPriority = "normal"
+	TaskPriorityHigh   TaskPriority = "high"
+	TaskPriorityUrgent TaskPriority = "urgent"
+)
+
+func (e *TaskPriority) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TaskPriority(s)
+	case string:
+		*e = TaskPriority(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TaskPriority: %T", src)
+	}
+	return nil
+}
+
+type NullTaskPriority struct {
+	TaskPriority TaskPriority
+	Valid        bool // Valid is true if TaskPriority is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTaskPriority) Scan(value interface{}) error {
+	if value == nil {
+		ns.TaskPriority, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TaskPriority.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTaskPriority) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.TaskPriority), nil
+}
+
+type IdempotencyKey struct {
+	OwnerID     string
+	Key         string
+	RequestHash string
+	StatusCode  int32
+	Response    []byte
+	CreatedAt   pgtype.Timestamptz
+	ExpiresAt   pgtype.Timestamptz
+}
+
+type List struct {
+	ID        int32
+	OwnerID   string
+	Name      string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type Outbox struct {
+	ID            int64
+	Topic         string
+	Payload       []byte
+	CreatedAt     pgtype.Timestamptz
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	DeliveredAt   pgtype.Timestamptz
+}
+
+type ReminderPref struct {
+	OwnerID     string
+	Channel     string
+	Email       pgtype.Text
+	WebhookUrl  pgtype.Text
+	LeadMinutes int32
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type Tag struct {
+	ID      int32
+	OwnerID string
+	Name    string
+}
+
 type Task struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	Search           interface{}
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	RruleStartAt     pgtype.Timestamptz
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+type TaskAudit struct {
+	ID        int64
+	TaskID    int32
+	OwnerID   string
+	Actor     string
+	Action    string
+	RequestID string
+	Before    []byte
+	After     []byte
+	CreatedAt pgtype.Timestamptz
+}
+
+type TaskDependency struct {
+	TaskID      int32
+	BlockedByID int32
+	CreatedAt   pgtype.Timestamptz
+}
+
+type TaskEvent struct {
+	TaskID     int32
+	Seq        int32
+	Change     int32
+	OwnerID    string
+	Type       string
+	Data       []byte
+	Undoes     pgtype.Int4
+	Redoes     pgtype.Int4
+	Actor      string
+	RequestID  string
+	OccurredAt pgtype.Timestamptz
+}
+
+type TaskReminder struct {
+	ID            int64
+	TaskID        int32
+	OwnerID       string
+	RemindAt      pgtype.Timestamptz
+	Channel       string
+	Address       string
+	Status        string
+	Attempts      int32
+	NextAttemptAt pgtype.Timestamptz
+	LastError     pgtype.Text
+	CreatedAt     pgtype.Timestamptz
+	SentAt        pgtype.Timestamptz
+}
+
+type TaskTag struct {
+	TaskID int32
+	TagID  int32
+}
+
+type Webhook struct {
 	ID        int32
-	Title     string
-	Done      bool
+	OwnerID   string
+	Url       string
+	Secret    string
+	Events    []string
+	Active    bool
 	CreatedAt pgtype.Timestamptz
 	UpdatedAt pgtype.Timestamptz
 }
+
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      int32
+	EventID        int64
+	Topic          string
+	Payload        []byte
+	Status         string
+	Attempts       int32
+	NextAttemptAt  pgtype.Timestamptz
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	RedeliveryOf   pgtype.Int8
+	CreatedAt      pgtype.Timestamptz
+	DeliveredAt    pgtype.Timestamptz
+}
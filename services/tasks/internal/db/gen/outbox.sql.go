@@ -0,0 +1,144 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: outbox.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimOutbox = `-- name: ClaimOutbox :many
+UPDATE outbox
+SET attempts = attempts + 1,
+    next_attempt_at = $1
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE delivered_at IS NULL AND next_attempt_at <= now()
+  ORDER BY id
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, topic, payload, created_at, attempts
+`
+
+type ClaimOutboxParams struct {
+	LeaseUntil pgtype.Timestamptz
+	Lim        int32
+}
+
+type ClaimOutboxRow struct {
+	ID        int64
+	Topic     string
+	Payload   []byte
+	CreatedAt pgtype.Timestamptz
+	Attempts  int32
+}
+
+// Leases up to lim due messages to the caller by pushing next_attempt_at to
+// lease_until. SKIP LOCKED lets several relays poll without double-claiming;
+// a relay that dies holding a lease simply lets it expire.
+func (q *Queries) ClaimOutbox(ctx context.Context, arg ClaimOutboxParams) ([]ClaimOutboxRow, error) {
+	rows, err := q.db.Query(ctx, claimOutbox, arg.LeaseUntil, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimOutboxRow
+	for rows.Next() {
+		var i ClaimOutboxRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.Payload,
+			&i.CreatedAt,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const enqueueOutbox = `-- name: EnqueueOutbox :one
+INSERT INTO outbox (topic, payload) VALUES ($1, $2)
+RETURNING id
+`
+
+type EnqueueOutboxParams struct {
+	Topic   string
+	Payload []byte
+}
+
+// Called inside the transaction of the change the event describes.
+func (q *Queries) EnqueueOutbox(ctx context.Context, arg EnqueueOutboxParams) (int64, error) {
+	row := q.db.QueryRow(ctx, enqueueOutbox, arg.Topic, arg.Payload)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const enqueueOutboxBatch = `-- name: EnqueueOutboxBatch :many
+INSERT INTO outbox (topic, payload)
+SELECT unnest($1::text[]), unnest($2::text[])::jsonb
+RETURNING id
+`
+
+type EnqueueOutboxBatchParams struct {
+	Topics   []string
+	Payloads []string
+}
+
+// The bulk form of EnqueueOutbox: one message per (topic, payload) pair.
+func (q *Queries) EnqueueOutboxBatch(ctx context.Context, arg EnqueueOutboxBatchParams) ([]int64, error) {
+	rows, err := q.db.Query(ctx, enqueueOutboxBatch, arg.Topics, arg.Payloads)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOutboxDelivered = `-- name: MarkOutboxDelivered :exec
+UPDATE outbox SET delivered_at = now(), last_error = NULL WHERE id = $1
+`
+
+func (q *Queries) MarkOutboxDelivered(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, markOutboxDelivered, id)
+	return err
+}
+
+const markOutboxFailed = `-- name: MarkOutboxFailed :exec
+UPDATE outbox
+SET last_error = $1, next_attempt_at = $2
+WHERE id = $3
+`
+
+type MarkOutboxFailedParams struct {
+	LastError pgtype.Text
+	RetryAt   pgtype.Timestamptz
+	ID        int64
+}
+
+func (q *Queries) MarkOutboxFailed(ctx context.Context, arg MarkOutboxFailedParams) error {
+	_, err := q.db.Exec(ctx, markOutboxFailed, arg.LastError, arg.RetryAt, arg.ID)
+	return err
+}
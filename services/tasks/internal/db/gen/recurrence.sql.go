@@ -0,0 +1,237 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: recurrence.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimDueOccurrences = `-- name: ClaimDueOccurrences :many
+SELECT id, owner_id, list_id, rrule::text AS rrule, rrule_start_at, next_occurrence_at
+FROM tasks
+WHERE next_occurrence_at IS NOT NULL AND deleted_at IS NULL
+  AND (next_occurrence_at <= $1::timestamptz OR done)
+ORDER BY next_occurrence_at
+LIMIT $2::int
+FOR UPDATE SKIP LOCKED
+`
+
+type ClaimDueOccurrencesParams struct {
+	Now pgtype.Timestamptz
+	Lim int32
+}
+
+type ClaimDueOccurrencesRow struct {
+	ID               int32
+	OwnerID          string
+	ListID           pgtype.Int4
+	Rrule            string
+	RruleStartAt     pgtype.Timestamptz
+	NextOccurrenceAt pgtype.Timestamptz
+}
+
+// Every owner's live recurring tasks whose next occurrence is due by now,
+// or that are done, so it should be created early. Rows claimed by another
+// transaction are skipped rather than waited for.
+func (q *Queries) ClaimDueOccurrences(ctx context.Context, arg ClaimDueOccurrencesParams) ([]ClaimDueOccurrencesRow, error) {
+	rows, err := q.db.Query(ctx, claimDueOccurrences, arg.Now, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueOccurrencesRow
+	for rows.Next() {
+		var i ClaimDueOccurrencesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.ListID,
+			&i.Rrule,
+			&i.RruleStartAt,
+			&i.NextOccurrenceAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const clearNextOccurrence = `-- name: ClearNextOccurrence :one
+UPDATE tasks SET next_occurrence_at = NULL
+WHERE id = $1
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type ClearNextOccurrenceRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+func (q *Queries) ClearNextOccurrence(ctx context.Context, id int32) (ClearNextOccurrenceRow, error) {
+	row := q.db.QueryRow(ctx, clearNextOccurrence, id)
+	var i ClearNextOccurrenceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
+
+const copyTaskTags = `-- name: CopyTaskTags :exec
+INSERT INTO task_tags (task_id, tag_id)
+SELECT $1::int, tt.tag_id FROM task_tags tt WHERE tt.task_id = $2::int
+`
+
+type CopyTaskTagsParams struct {
+	ToID   int32
+	FromID int32
+}
+
+func (q *Queries) CopyTaskTags(ctx context.Context, arg CopyTaskTagsParams) error {
+	_, err := q.db.Exec(ctx, copyTaskTags, arg.ToID, arg.FromID)
+	return err
+}
+
+const createOccurrence = `-- name: CreateOccurrence :one
+INSERT INTO tasks (title, owner_id, description, due_at, priority, list_id, position,
+                   rrule, rrule_start_at, next_occurrence_at)
+SELECT p.title, p.owner_id, p.description, $1::timestamptz, p.priority,
+       $2, $3,
+       p.rrule, p.rrule_start_at, $4
+FROM tasks p
+WHERE p.id = $5
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type CreateOccurrenceParams struct {
+	DueAt            pgtype.Timestamptz
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	NextOccurrenceAt pgtype.Timestamptz
+	ID               int32
+}
+
+type CreateOccurrenceRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Copies task id as the next occurrence of its series, due at due_at.
+// It is not done, and has no subtasks or blockers of its own.
+func (q *Queries) CreateOccurrence(ctx context.Context, arg CreateOccurrenceParams) (CreateOccurrenceRow, error) {
+	row := q.db.QueryRow(ctx, createOccurrence,
+		arg.DueAt,
+		arg.ListID,
+		arg.Position,
+		arg.NextOccurrenceAt,
+		arg.ID,
+	)
+	var i CreateOccurrenceRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
+
+const getTaskRecurrence = `-- name: GetTaskRecurrence :one
+SELECT due_at, rrule, rrule_start_at, next_occurrence_at
+FROM tasks
+WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+FOR UPDATE
+`
+
+type GetTaskRecurrenceParams struct {
+	ID      int32
+	OwnerID string
+}
+
+type GetTaskRecurrenceRow struct {
+	DueAt            pgtype.Timestamptz
+	Rrule            pgtype.Text
+	RruleStartAt     pgtype.Timestamptz
+	NextOccurrenceAt pgtype.Timestamptz
+}
+
+// Locks a live task ahead of an update that may change its schedule, and
+// returns what the new next_occurrence_at depends on.
+func (q *Queries) GetTaskRecurrence(ctx context.Context, arg GetTaskRecurrenceParams) (GetTaskRecurrenceRow, error) {
+	row := q.db.QueryRow(ctx, getTaskRecurrence, arg.ID, arg.OwnerID)
+	var i GetTaskRecurrenceRow
+	err := row.Scan(
+		&i.DueAt,
+		&i.Rrule,
+		&i.RruleStartAt,
+		&i.NextOccurrenceAt,
+	)
+	return i, err
+}
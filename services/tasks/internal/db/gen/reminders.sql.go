@@ -0,0 +1,219 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: reminders.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const cancelStaleReminders = `-- name: CancelStaleReminders :execrows
+UPDATE task_reminders r
+SET status = 'cancelled'
+FROM tasks t
+WHERE t.id = r.task_id AND r.status = 'pending'
+  AND (t.done OR t.deleted_at IS NOT NULL)
+`
+
+// Drops pending reminders whose task was done or trashed since they were
+// queued.
+func (q *Queries) CancelStaleReminders(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, cancelStaleReminders)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimReminders = `-- name: ClaimReminders :many
+UPDATE task_reminders r
+SET attempts = r.attempts + 1,
+    next_attempt_at = $1
+FROM tasks t
+WHERE t.id = r.task_id
+  AND r.id IN (
+    SELECT q.id FROM task_reminders q
+    WHERE q.status = 'pending' AND q.next_attempt_at <= now()
+    ORDER BY q.id
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING r.id, r.task_id, r.owner_id, t.title, t.due_at, r.remind_at, r.channel, r.address, r.attempts
+`
+
+type ClaimRemindersParams struct {
+	LeaseUntil pgtype.Timestamptz
+	Lim        int32
+}
+
+type ClaimRemindersRow struct {
+	ID       int64
+	TaskID   int32
+	OwnerID  string
+	Title    string
+	DueAt    pgtype.Timestamptz
+	RemindAt pgtype.Timestamptz
+	Channel  string
+	Address  string
+	Attempts int32
+}
+
+// Same leasing scheme as ClaimWebhookDeliveries.
+func (q *Queries) ClaimReminders(ctx context.Context, arg ClaimRemindersParams) ([]ClaimRemindersRow, error) {
+	rows, err := q.db.Query(ctx, claimReminders, arg.LeaseUntil, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimRemindersRow
+	for rows.Next() {
+		var i ClaimRemindersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TaskID,
+			&i.OwnerID,
+			&i.Title,
+			&i.DueAt,
+			&i.RemindAt,
+			&i.Channel,
+			&i.Address,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const enqueueDueReminders = `-- name: EnqueueDueReminders :execrows
+INSERT INTO task_reminders (task_id, owner_id, remind_at, channel, address)
+SELECT t.id, t.owner_id, d.at, d.channel,
+       CASE d.channel WHEN 'email' THEN p.email WHEN 'webhook' THEN p.webhook_url ELSE '' END
+FROM tasks t
+LEFT JOIN reminder_prefs p ON p.owner_id = t.owner_id
+CROSS JOIN LATERAL (
+  SELECT COALESCE(t.remind_at, t.due_at - make_interval(mins => COALESCE(p.lead_minutes, 0))) AS at,
+         COALESCE(p.channel, 'log') AS channel
+) d
+WHERE NOT t.done AND t.deleted_at IS NULL
+  AND ((t.remind_at > $1::timestamptz AND t.remind_at <= $2::timestamptz)
+    OR (t.remind_at IS NULL AND t.due_at > $1::timestamptz
+        AND t.due_at <= $2::timestamptz + make_interval(mins => 10080)))
+  AND d.at > $1::timestamptz AND d.at <= $2::timestamptz
+  AND d.channel <> 'none'
+ON CONFLICT (task_id, remind_at) DO NOTHING
+`
+
+type EnqueueDueRemindersParams struct {
+	Since pgtype.Timestamptz
+	Now   pgtype.Timestamptz
+}
+
+// Queues a reminder for every open, live task whose reminder time (see
+// 015_reminders.up.sql) is in (since, now], unless its owner opted out.
+// One already queued for the same time is left alone, so each fires once.
+//
+// The reminder time is computed per row, so the window is first applied to
+// remind_at and due_at themselves, where tasks_remind_at_idx and
+// tasks_open_due_at_idx can serve it: a reminder from due_at fires at most
+// 10080 minutes (the lead_minutes cap) before it.
+func (q *Queries) EnqueueDueReminders(ctx context.Context, arg EnqueueDueRemindersParams) (int64, error) {
+	result, err := q.db.Exec(ctx, enqueueDueReminders, arg.Since, arg.Now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getReminderPrefs = `-- name: GetReminderPrefs :one
+SELECT owner_id, channel, email, webhook_url, lead_minutes, updated_at FROM reminder_prefs
+WHERE owner_id = $1
+`
+
+func (q *Queries) GetReminderPrefs(ctx context.Context, ownerID string) (ReminderPref, error) {
+	row := q.db.QueryRow(ctx, getReminderPrefs, ownerID)
+	var i ReminderPref
+	err := row.Scan(
+		&i.OwnerID,
+		&i.Channel,
+		&i.Email,
+		&i.WebhookUrl,
+		&i.LeadMinutes,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const recordReminderAttempt = `-- name: RecordReminderAttempt :exec
+UPDATE task_reminders
+SET status          = $1,
+    last_error      = $2,
+    next_attempt_at = $3,
+    sent_at         = CASE WHEN $1::text = 'sent' THEN now() END
+WHERE id = $4
+`
+
+type RecordReminderAttemptParams struct {
+	Status        string
+	LastError     pgtype.Text
+	NextAttemptAt pgtype.Timestamptz
+	ID            int64
+}
+
+func (q *Queries) RecordReminderAttempt(ctx context.Context, arg RecordReminderAttemptParams) error {
+	_, err := q.db.Exec(ctx, recordReminderAttempt,
+		arg.Status,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ID,
+	)
+	return err
+}
+
+const upsertReminderPrefs = `-- name: UpsertReminderPrefs :one
+INSERT INTO reminder_prefs (owner_id, channel, email, webhook_url, lead_minutes)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (owner_id) DO UPDATE
+SET channel      = EXCLUDED.channel,
+    email        = EXCLUDED.email,
+    webhook_url  = EXCLUDED.webhook_url,
+    lead_minutes = EXCLUDED.lead_minutes,
+    updated_at   = now()
+RETURNING owner_id, channel, email, webhook_url, lead_minutes, updated_at
+`
+
+type UpsertReminderPrefsParams struct {
+	OwnerID     string
+	Channel     string
+	Email       pgtype.Text
+	WebhookUrl  pgtype.Text
+	LeadMinutes int32
+}
+
+func (q *Queries) UpsertReminderPrefs(ctx context.Context, arg UpsertReminderPrefsParams) (ReminderPref, error) {
+	row := q.db.QueryRow(ctx, upsertReminderPrefs,
+		arg.OwnerID,
+		arg.Channel,
+		arg.Email,
+		arg.WebhookUrl,
+		arg.LeadMinutes,
+	)
+	var i ReminderPref
+	err := row.Scan(
+		&i.OwnerID,
+		&i.Channel,
+		&i.Email,
+		&i.WebhookUrl,
+		&i.LeadMinutes,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,77 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: tags.sql
+
+package db
+
+import (
+	"context"
+)
+
+const addTaskTags = `-- name: AddTaskTags :exec
+WITH tag AS (
+  INSERT INTO tags (owner_id, name)
+  SELECT $2, unnest($3::text[])
+  ON CONFLICT (owner_id, name) DO UPDATE SET name = EXCLUDED.name
+  RETURNING id
+)
+INSERT INTO task_tags (task_id, tag_id)
+SELECT $1, id FROM tag
+ON CONFLICT DO NOTHING
+`
+
+type AddTaskTagsParams struct {
+	TaskID  int32
+	OwnerID string
+	Names   []string
+}
+
+// Tags the task with names (already normalized), creating the owner's tags
+// that don't exist yet. The no-op DO UPDATE makes RETURNING include tags
+// that already existed.
+func (q *Queries) AddTaskTags(ctx context.Context, arg AddTaskTagsParams) error {
+	_, err := q.db.Exec(ctx, addTaskTags, arg.TaskID, arg.OwnerID, arg.Names)
+	return err
+}
+
+const clearTaskTags = `-- name: ClearTaskTags :exec
+DELETE FROM task_tags WHERE task_id = $1
+`
+
+func (q *Queries) ClearTaskTags(ctx context.Context, taskID int32) error {
+	_, err := q.db.Exec(ctx, clearTaskTags, taskID)
+	return err
+}
+
+const listTaskTags = `-- name: ListTaskTags :many
+SELECT tt.task_id, g.name FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+WHERE tt.task_id = ANY($1::int[])
+ORDER BY tt.task_id, g.name COLLATE "C"
+`
+
+type ListTaskTagsRow struct {
+	TaskID int32
+	Name   string
+}
+
+// The tags of each of the given tasks, in byte order (as Go sorts them).
+func (q *Queries) ListTaskTags(ctx context.Context, taskIds []int32) ([]ListTaskTagsRow, error) {
+	rows, err := q.db.Query(ctx, listTaskTags, taskIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListTaskTagsRow
+	for rows.Next() {
+		var i ListTaskTagsRow
+		if err := rows.Scan(&i.TaskID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -7,62 +7,395 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type CopyTasksParams struct {
+	ID               int32
+	Title            string
+	OwnerID          string
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	Rrule            pgtype.Text
+	RruleStartAt     pgtype.Timestamptz
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+}
+
 const createTask = `-- name: CreateTask :one
-INSERT INTO tasks (title) VALUES ($1)
-RETURNING id, title, done, created_at, updated_at
+INSERT INTO tasks (title, owner_id, description, due_at, priority, list_id, position,
+                   rrule, rrule_start_at, next_occurrence_at, remind_at)
+VALUES ($1, $2, $3, $4, $5,
+        $6, $7,
+        $8, $9, $10, $11)
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
 `
 
-func (q *Queries) CreateTask(ctx context.Context, title string) (Task, error) {
-	row := q.db.QueryRow(ctx, createTask, title)
-	var i Task
+type CreateTaskParams struct {
+	Title            string
+	OwnerID          string
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	Rrule            pgtype.Text
+	RruleStartAt     pgtype.Timestamptz
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+type CreateTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (CreateTaskRow, error) {
+	row := q.db.QueryRow(ctx, createTask,
+		arg.Title,
+		arg.OwnerID,
+		arg.Description,
+		arg.DueAt,
+		arg.Priority,
+		arg.ListID,
+		arg.Position,
+		arg.Rrule,
+		arg.RruleStartAt,
+		arg.NextOccurrenceAt,
+		arg.RemindAt,
+	)
+	var i CreateTaskRow
 	err := row.Scan(
 		&i.ID,
 		&i.Title,
 		&i.Done,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
+
+const deleteTask = `-- name: DeleteTask :one
+UPDATE tasks SET deleted_at = now()
+WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type DeleteTaskParams struct {
+	ID      int32
+	OwnerID string
+}
+
+type DeleteTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Moves the task to the trash; PurgeTrashedTasks removes it for good later.
+func (q *Queries) DeleteTask(ctx context.Context, arg DeleteTaskParams) (DeleteTaskRow, error) {
+	row := q.db.QueryRow(ctx, deleteTask, arg.ID, arg.OwnerID)
+	var i DeleteTaskRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
 	)
 	return i, err
 }
 
 const getTask = `-- name: GetTask :one
-SELECT id, title, done, created_at, updated_at FROM tasks WHERE id = $1
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE id = $1 AND owner_id = $2 AND deleted_at IS NULL
 `
 
-func (q *Queries) GetTask(ctx context.Context, id int32) (Task, error) {
-	row := q.db.QueryRow(ctx, getTask, id)
-	var i Task
+type GetTaskParams struct {
+	ID      int32
+	OwnerID string
+}
+
+type GetTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Trashed tasks are not found (restore them first).
+func (q *Queries) GetTask(ctx context.Context, arg GetTaskParams) (GetTaskRow, error) {
+	row := q.db.QueryRow(ctx, getTask, arg.ID, arg.OwnerID)
+	var i GetTaskRow
 	err := row.Scan(
 		&i.ID,
 		&i.Title,
 		&i.Done,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
 	)
 	return i, err
 }
 
+const getTasksByIDs = `-- name: GetTasksByIDs :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE owner_id = $1 AND id = ANY($2::int[])
+ORDER BY id
+`
+
+type GetTasksByIDsParams struct {
+	OwnerID string
+	Ids     []int32
+}
+
+type GetTasksByIDsRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+func (q *Queries) GetTasksByIDs(ctx context.Context, arg GetTasksByIDsParams) ([]GetTasksByIDsRow, error) {
+	rows, err := q.db.Query(ctx, getTasksByIDs, arg.OwnerID, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTasksByIDsRow
+	for rows.Next() {
+		var i GetTasksByIDsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listTasks = `-- name: ListTasks :many
-SELECT id, title, done, created_at, updated_at FROM tasks ORDER BY id
+SELECT t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at,
+       t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at
+FROM tasks t
+WHERE t.owner_id = $1
+  AND (t.deleted_at IS NOT NULL) = $2::boolean
+  AND ($3::boolean IS NULL OR t.done = $3)
+  AND ($4::text IS NULL OR t.title ILIKE '%' || $4 || '%')
+  AND ($5::text IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.task_id = t.id AND g.name = $5
+  ))
+  AND ($6::timestamptz IS NULL OR t.due_at < $6)
+  AND (
+    $7::int IS NULL
+    OR ($8::text = 'id' AND t.id > $7)
+    OR ($8::text = '-id' AND t.id < $7)
+    OR ($8::text = 'created_at'
+        AND (t.created_at, t.id) > ($9::timestamptz, $7))
+    OR ($8::text = '-created_at'
+        AND (t.created_at, t.id) < ($9::timestamptz, $7))
+  )
+ORDER BY
+  CASE WHEN $8::text = 'created_at' THEN t.created_at END ASC,
+  CASE WHEN $8::text = '-created_at' THEN t.created_at END DESC,
+  CASE WHEN $8::text IN ('id', 'created_at') THEN t.id END ASC,
+  CASE WHEN $8::text IN ('-id', '-created_at') THEN t.id END DESC
+LIMIT $10::int
 `
 
-func (q *Queries) ListTasks(ctx context.Context) ([]Task, error) {
-	rows, err := q.db.Query(ctx, listTasks)
+type ListTasksParams struct {
+	OwnerID        string
+	Trashed        bool
+	Done           pgtype.Bool
+	Q              pgtype.Text
+	Tag            pgtype.Text
+	DueBefore      pgtype.Timestamptz
+	AfterID        pgtype.Int4
+	Sort           string
+	AfterCreatedAt pgtype.Timestamptz
+	Lim            pgtype.Int4
+}
+
+type ListTasksRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// Keyset-paginated listing. after_id/after_created_at come from the decoded
+// cursor (the last row of the previous page); sort selects both the ORDER BY
+// and the matching keyset comparison. A NULL lim means "no limit".
+// Every query is scoped to owner_id: callers only ever see their own tasks.
+// trashed selects the trash (soft-deleted rows) instead of live tasks.
+// tag (a normalized tag name) and due_before narrow it further; tasks
+// without a due date never match due_before.
+func (q *Queries) ListTasks(ctx context.Context, arg ListTasksParams) ([]ListTasksRow, error) {
+	rows, err := q.db.Query(ctx, listTasks,
+		arg.OwnerID,
+		arg.Trashed,
+		arg.Done,
+		arg.Q,
+		arg.Tag,
+		arg.DueBefore,
+		arg.AfterID,
+		arg.Sort,
+		arg.AfterCreatedAt,
+		arg.Lim,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Task
+	var items []ListTasksRow
 	for rows.Next() {
-		var i Task
+		var i ListTasksRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Title,
 			&i.Done,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
 		); err != nil {
 			return nil, err
 		}
@@ -73,3 +406,301 @@ func (q *Queries) ListTasks(ctx context.Context) ([]Task, error) {
 	}
 	return items, nil
 }
+
+const nextTaskIDs = `-- name: NextTaskIDs :many
+SELECT nextval(pg_get_serial_sequence('tasks', 'id'))::int AS id
+FROM generate_series(1, $1::int)
+`
+
+// Reserves n ids from the tasks sequence, so a bulk COPY (which returns
+// nothing) knows the ids of the rows it inserts.
+func (q *Queries) NextTaskIDs(ctx context.Context, n int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, nextTaskIDs, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeTrashedTasks = `-- name: PurgeTrashedTasks :execrows
+DELETE FROM tasks
+WHERE id IN (
+  SELECT t.id FROM tasks t
+  WHERE t.deleted_at < $1::timestamptz
+  ORDER BY t.deleted_at
+  LIMIT $2::int
+)
+`
+
+type PurgeTrashedTasksParams struct {
+	Cutoff pgtype.Timestamptz
+	Lim    int32
+}
+
+// Hard-deletes up to lim tasks trashed before cutoff. The purger calls it
+// until it returns fewer than lim, keeping each transaction short.
+func (q *Queries) PurgeTrashedTasks(ctx context.Context, arg PurgeTrashedTasksParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeTrashedTasks, arg.Cutoff, arg.Lim)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const restoreTask = `-- name: RestoreTask :one
+UPDATE tasks SET deleted_at = NULL
+WHERE id = $1 AND owner_id = $2 AND deleted_at IS NOT NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type RestoreTaskParams struct {
+	ID      int32
+	OwnerID string
+}
+
+type RestoreTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+func (q *Queries) RestoreTask(ctx context.Context, arg RestoreTaskParams) (RestoreTaskRow, error) {
+	row := q.db.QueryRow(ctx, restoreTask, arg.ID, arg.OwnerID)
+	var i RestoreTaskRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
+
+const searchTasks = `-- name: SearchTasks :many
+SELECT t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at,
+       t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at,
+       ts_rank_cd(t.search, query)::real AS rank,
+       ts_headline('english', concat_ws(E'\n', t.title, NULLIF(t.description, '')), query,
+                   'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MinWords=15, MaxWords=35')::text AS snippet
+FROM tasks t, websearch_to_tsquery('english', $1::text) AS query
+WHERE t.owner_id = $2 AND t.deleted_at IS NULL AND t.search @@ query
+ORDER BY rank DESC, t.id DESC
+LIMIT $3::int
+`
+
+type SearchTasksParams struct {
+	Q       string
+	OwnerID string
+	Lim     int32
+}
+
+type SearchTasksRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+	Rank             float32
+	Snippet          string
+}
+
+// Ranked full-text search over the caller's live tasks. q is parsed with
+// websearch_to_tsquery, so any input is valid ("quoted phrases", or, -not).
+// The snippet is the best-matching stretch of the title and description,
+// with matches marked \x02...\x03, which cannot occur in either (see the
+// *_no_control constraints), for the caller to turn into markup.
+func (q *Queries) SearchTasks(ctx context.Context, arg SearchTasksParams) ([]SearchTasksRow, error) {
+	rows, err := q.db.Query(ctx, searchTasks, arg.Q, arg.OwnerID, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchTasksRow
+	for rows.Next() {
+		var i SearchTasksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Done,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Version,
+			&i.OwnerID,
+			&i.DeletedAt,
+			&i.Description,
+			&i.DueAt,
+			&i.Priority,
+			&i.ListID,
+			&i.Position,
+			&i.ParentID,
+			&i.Rrule,
+			&i.NextOccurrenceAt,
+			&i.RemindAt,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateTask = `-- name: UpdateTask :one
+UPDATE tasks
+SET title       = COALESCE($1, title),
+    done        = COALESCE($2, done),
+    description = COALESCE($3, description),
+    priority    = COALESCE($4, priority),
+    due_at      = CASE WHEN $5::boolean THEN $6 ELSE due_at END,
+    remind_at   = CASE WHEN $7::boolean THEN $8 ELSE remind_at END,
+    rrule              = CASE WHEN $9::boolean THEN $10 ELSE rrule END,
+    rrule_start_at     = CASE WHEN $9::boolean THEN $11 ELSE rrule_start_at END,
+    next_occurrence_at = CASE WHEN $9::boolean THEN $12 ELSE next_occurrence_at END,
+    version            = CASE WHEN $13::boolean THEN version + 1 ELSE version END
+WHERE id = $14 AND owner_id = $15 AND deleted_at IS NULL
+  AND ($16::int IS NULL OR version = $16)
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+`
+
+type UpdateTaskParams struct {
+	Title            pgtype.Text
+	Done             pgtype.Bool
+	Description      pgtype.Text
+	Priority         NullTaskPriority
+	SetDueAt         bool
+	DueAt            pgtype.Timestamptz
+	SetRemindAt      bool
+	RemindAt         pgtype.Timestamptz
+	SetRecurrence    bool
+	Rrule            pgtype.Text
+	RruleStartAt     pgtype.Timestamptz
+	NextOccurrenceAt pgtype.Timestamptz
+	SetTags          bool
+	ID               int32
+	OwnerID          string
+	IfVersion        pgtype.Int4
+}
+
+type UpdateTaskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// updated_at and version are bumped by the tasks_touch trigger. A non-NULL
+// if_version makes the update conditional (If-Match): no row is returned when
+// the task has moved on since the client read it. due_at can be cleared, so
+// set_due_at says whether to write it (NULL included) at all, and likewise
+// set_remind_at, and set_recurrence for the three recurrence columns (see
+// GetTaskRecurrence). Tags live in task_tags, so set_tags bumps version
+// itself to make the row change (and the trigger fire) on a tags-only update.
+func (q *Queries) UpdateTask(ctx context.Context, arg UpdateTaskParams) (UpdateTaskRow, error) {
+	row := q.db.QueryRow(ctx, updateTask,
+		arg.Title,
+		arg.Done,
+		arg.Description,
+		arg.Priority,
+		arg.SetDueAt,
+		arg.DueAt,
+		arg.SetRemindAt,
+		arg.RemindAt,
+		arg.SetRecurrence,
+		arg.Rrule,
+		arg.RruleStartAt,
+		arg.NextOccurrenceAt,
+		arg.SetTags,
+		arg.ID,
+		arg.OwnerID,
+		arg.IfVersion,
+	)
+	var i UpdateTaskRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Done,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Version,
+		&i.OwnerID,
+		&i.DeletedAt,
+		&i.Description,
+		&i.DueAt,
+		&i.Priority,
+		&i.ListID,
+		&i.Position,
+		&i.ParentID,
+		&i.Rrule,
+		&i.NextOccurrenceAt,
+		&i.RemindAt,
+	)
+	return i, err
+}
@@ -0,0 +1,389 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.29.0
+// source: webhooks.sql
+
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const claimWebhookDeliveries = `-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET attempts = d.attempts + 1,
+    next_attempt_at = $1
+FROM webhooks w
+WHERE w.id = d.webhook_id
+  AND d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= now()
+    ORDER BY id
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING d.id, d.webhook_id, w.url, w.secret, d.event_id, d.topic, d.payload, d.attempts
+`
+
+type ClaimWebhookDeliveriesParams struct {
+	LeaseUntil pgtype.Timestamptz
+	Lim        int32
+}
+
+type ClaimWebhookDeliveriesRow struct {
+	ID        int64
+	WebhookID int32
+	Url       string
+	Secret    string
+	EventID   int64
+	Topic     string
+	Payload   []byte
+	Attempts  int32
+}
+
+// Same leasing scheme as ClaimOutbox.
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]ClaimWebhookDeliveriesRow, error) {
+	rows, err := q.db.Query(ctx, claimWebhookDeliveries, arg.LeaseUntil, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimWebhookDeliveriesRow
+	for rows.Next() {
+		var i ClaimWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Url,
+			&i.Secret,
+			&i.EventID,
+			&i.Topic,
+			&i.Payload,
+			&i.Attempts,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (owner_id, url, secret, events)
+VALUES ($1, $2, $3, $4)
+RETURNING id, owner_id, url, secret, events, active, created_at, updated_at
+`
+
+type CreateWebhookParams struct {
+	OwnerID string
+	Url     string
+	Secret  string
+	Events  []string
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, createWebhook,
+		arg.OwnerID,
+		arg.Url,
+		arg.Secret,
+		arg.Events,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE id = $1 AND owner_id = $2
+`
+
+type DeleteWebhookParams struct {
+	ID      int32
+	OwnerID string
+}
+
+func (q *Queries) DeleteWebhook(ctx context.Context, arg DeleteWebhookParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteWebhook, arg.ID, arg.OwnerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :exec
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload)
+SELECT w.id, $1::bigint, $2::text, $3::jsonb
+FROM webhooks w
+WHERE w.owner_id = $4 AND w.active
+  AND (cardinality(w.events) = 0 OR $2::text = ANY (w.events))
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	EventID int64
+	Topic   string
+	Payload []byte
+	OwnerID string
+}
+
+// Fans one outbox event out to the owner's matching webhooks. Called in the
+// same transaction as EnqueueOutbox.
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) error {
+	_, err := q.db.Exec(ctx, enqueueWebhookDeliveries,
+		arg.EventID,
+		arg.Topic,
+		arg.Payload,
+		arg.OwnerID,
+	)
+	return err
+}
+
+const enqueueWebhookDeliveriesBatch = `-- name: EnqueueWebhookDeliveriesBatch :exec
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload)
+SELECT w.id, o.id, o.topic, o.payload
+FROM outbox o
+JOIN webhooks w ON w.owner_id = $1 AND w.active
+  AND (cardinality(w.events) = 0 OR o.topic = ANY (w.events))
+WHERE o.id = ANY ($2::bigint[])
+`
+
+type EnqueueWebhookDeliveriesBatchParams struct {
+	OwnerID  string
+	EventIds []int64
+}
+
+// The bulk form of EnqueueWebhookDeliveries, for outbox rows just written
+// by EnqueueOutboxBatch.
+func (q *Queries) EnqueueWebhookDeliveriesBatch(ctx context.Context, arg EnqueueWebhookDeliveriesBatchParams) error {
+	_, err := q.db.Exec(ctx, enqueueWebhookDeliveriesBatch, arg.OwnerID, arg.EventIds)
+	return err
+}
+
+const getWebhook = `-- name: GetWebhook :one
+SELECT id, owner_id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE id = $1 AND owner_id = $2
+`
+
+type GetWebhookParams struct {
+	ID      int32
+	OwnerID string
+}
+
+func (q *Queries) GetWebhook(ctx context.Context, arg GetWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, getWebhook, arg.ID, arg.OwnerID)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event_id, topic, payload, status, attempts, next_attempt_at,
+       last_status_code, last_error, redelivery_of, created_at, delivered_at
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID int32
+	Lim       int32
+}
+
+// Newest first. The webhook's ownership is checked by the caller.
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveries, arg.WebhookID, arg.Lim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.EventID,
+			&i.Topic,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.RedeliveryOf,
+			&i.CreatedAt,
+			&i.DeliveredAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, owner_id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE owner_id = $1 ORDER BY id
+`
+
+func (q *Queries) ListWebhooks(ctx context.Context, ownerID string) ([]Webhook, error) {
+	rows, err := q.db.Query(ctx, listWebhooks, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Webhook
+	for rows.Next() {
+		var i Webhook
+		if err := rows.Scan(
+			&i.ID,
+			&i.OwnerID,
+			&i.Url,
+			&i.Secret,
+			&i.Events,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordWebhookAttempt = `-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET status           = $1,
+    last_status_code = $2,
+    last_error       = $3,
+    next_attempt_at  = $4,
+    delivered_at     = CASE WHEN $1::text = 'delivered' THEN now() END
+WHERE id = $5
+`
+
+type RecordWebhookAttemptParams struct {
+	Status         string
+	LastStatusCode pgtype.Int4
+	LastError      pgtype.Text
+	NextAttemptAt  pgtype.Timestamptz
+	ID             int64
+}
+
+func (q *Queries) RecordWebhookAttempt(ctx context.Context, arg RecordWebhookAttemptParams) error {
+	_, err := q.db.Exec(ctx, recordWebhookAttempt,
+		arg.Status,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.NextAttemptAt,
+		arg.ID,
+	)
+	return err
+}
+
+const redeliverWebhookDelivery = `-- name: RedeliverWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload, redelivery_of)
+SELECT d.webhook_id, d.event_id, d.topic, d.payload, d.id
+FROM webhook_deliveries d
+JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.id = $1 AND d.webhook_id = $2 AND w.owner_id = $3
+RETURNING id, webhook_id, event_id, topic, payload, status, attempts, next_attempt_at,
+          last_status_code, last_error, redelivery_of, created_at, delivered_at
+`
+
+type RedeliverWebhookDeliveryParams struct {
+	ID        int64
+	WebhookID int32
+	OwnerID   string
+}
+
+// Queues a fresh copy of an earlier delivery of one of owner's webhooks.
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, arg RedeliverWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, redeliverWebhookDelivery, arg.ID, arg.WebhookID, arg.OwnerID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.EventID,
+		&i.Topic,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.RedeliveryOf,
+		&i.CreatedAt,
+		&i.DeliveredAt,
+	)
+	return i, err
+}
+
+const updateWebhook = `-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url        = COALESCE($1, url),
+    events     = COALESCE($2::text[], events),
+    active     = COALESCE($3, active),
+    updated_at = now()
+WHERE id = $4 AND owner_id = $5
+RETURNING id, owner_id, url, secret, events, active, created_at, updated_at
+`
+
+type UpdateWebhookParams struct {
+	Url     pgtype.Text
+	Events  []string
+	Active  pgtype.Bool
+	ID      int32
+	OwnerID string
+}
+
+func (q *Queries) UpdateWebhook(ctx context.Context, arg UpdateWebhookParams) (Webhook, error) {
+	row := q.db.QueryRow(ctx, updateWebhook,
+		arg.Url,
+		arg.Events,
+		arg.Active,
+		arg.ID,
+		arg.OwnerID,
+	)
+	var i Webhook
+	err := row.Scan(
+		&i.ID,
+		&i.OwnerID,
+		&i.Url,
+		&i.Secret,
+		&i.Events,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -6,5 +6,7 @@ CREATE TABLE IF NOT EXISTS tasks (
   updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
--- optional seed
-INSERT INTO tasks (title, done) VALUES ('First task', false) ON CONFLICT DO NOTHING;
+-- optional seed (only into an empty table, so re-running is harmless)
+INSERT INTO tasks (title, done)
+SELECT 'First task', false
+WHERE NOT EXISTS (SELECT 1 FROM tasks);
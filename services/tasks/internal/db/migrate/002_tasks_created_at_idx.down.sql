@@ -0,0 +1 @@
+DROP INDEX IF EXISTS tasks_created_at_id_idx;
@@ -0,0 +1,2 @@
+-- Supports keyset pagination when listing tasks sorted by created_at.
+CREATE INDEX IF NOT EXISTS tasks_created_at_id_idx ON tasks (created_at, id);
@@ -0,0 +1,5 @@
+ALTER TABLE tasks
+  DROP CONSTRAINT IF EXISTS tasks_title_nfc,
+  DROP CONSTRAINT IF EXISTS tasks_title_no_control,
+  DROP CONSTRAINT IF EXISTS tasks_title_length,
+  DROP CONSTRAINT IF EXISTS tasks_title_not_blank;
@@ -0,0 +1,13 @@
+-- Mirror the title rules from internal/tasks/validate.go in the schema, so
+-- rows written outside the API (psql, scripts, other services) obey them too.
+-- NOT VALID skips checking rows that already exist; new and updated rows are
+-- checked. Run `ALTER TABLE tasks VALIDATE CONSTRAINT ...` once old data is clean.
+ALTER TABLE tasks
+  ADD CONSTRAINT tasks_title_not_blank
+    CHECK (btrim(title) <> '') NOT VALID,
+  ADD CONSTRAINT tasks_title_length
+    CHECK (char_length(title) <= 200) NOT VALID,
+  ADD CONSTRAINT tasks_title_no_control
+    CHECK (title !~ '[[:cntrl:]]') NOT VALID,
+  ADD CONSTRAINT tasks_title_nfc
+    CHECK (title IS NFC NORMALIZED) NOT VALID;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS tasks_touch ON tasks;
+DROP FUNCTION IF EXISTS tasks_touch();
+ALTER TABLE tasks DROP COLUMN IF EXISTS version;
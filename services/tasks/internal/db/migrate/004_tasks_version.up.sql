@@ -0,0 +1,20 @@
+-- Optimistic concurrency: every change to a row bumps its version, which the
+-- API exposes as the ETag and checks against If-Match on PATCH.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Maintain updated_at and version in the database rather than in each query,
+-- so every writer (including ones outside this service) keeps them right.
+-- No-op updates (nothing actually changed) leave both alone.
+CREATE OR REPLACE FUNCTION tasks_touch() RETURNS trigger AS $$
+BEGIN
+  IF NEW IS DISTINCT FROM OLD THEN
+    NEW.updated_at := now();
+    NEW.version := OLD.version + 1;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tasks_touch
+  BEFORE UPDATE ON tasks
+  FOR EACH ROW EXECUTE FUNCTION tasks_touch();
@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS tasks_created_at_id_idx ON tasks (created_at, id);
+DROP INDEX IF EXISTS tasks_owner_created_at_id_idx;
+DROP INDEX IF EXISTS tasks_owner_id_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,10 @@
+-- Every task belongs to the caller that created it (internal/auth resolves
+-- the caller). Rows from before multi-user support go to the shared
+-- "anonymous" owner, which is who dev callers are by default.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS owner_id TEXT NOT NULL DEFAULT 'anonymous';
+ALTER TABLE tasks ALTER COLUMN owner_id DROP DEFAULT;
+
+-- Every query is scoped to one owner, so lead the keyset indexes with it.
+CREATE INDEX IF NOT EXISTS tasks_owner_id_idx ON tasks (owner_id, id);
+CREATE INDEX IF NOT EXISTS tasks_owner_created_at_id_idx ON tasks (owner_id, created_at, id);
+DROP INDEX IF EXISTS tasks_created_at_id_idx;
@@ -0,0 +1,19 @@
+-- Transactional outbox: task mutations insert their domain event here in the
+-- same transaction, so an event exists if and only if the change committed.
+-- The relay (internal/outbox) delivers pending rows to a sink at least once.
+CREATE TABLE IF NOT EXISTS outbox (
+  id BIGSERIAL PRIMARY KEY,
+  topic TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  -- Retry bookkeeping. A claimed row's next_attempt_at is pushed out by the
+  -- relay's lease, so a relay that dies mid-delivery is retried later.
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_error TEXT,
+  delivered_at TIMESTAMPTZ
+);
+
+-- Only undelivered rows are ever polled.
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (next_attempt_at, id)
+  WHERE delivered_at IS NULL;
@@ -0,0 +1,39 @@
+-- Outgoing webhooks: each owner registers URLs to be POSTed their task
+-- events. Deliveries are fanned out in the transaction that records the
+-- event (see enqueueEvent) and sent by the delivery worker (internal/webhook).
+CREATE TABLE IF NOT EXISTS webhooks (
+  id SERIAL PRIMARY KEY,
+  owner_id TEXT NOT NULL,
+  url TEXT NOT NULL,
+  -- HMAC-SHA256 key for the X-Webhook-Signature header. Kept in clear: we
+  -- need it to sign, and it is only ever shown to the owner once.
+  secret TEXT NOT NULL,
+  -- Topics to deliver (e.g. task.created); empty means all of them.
+  events TEXT[] NOT NULL DEFAULT '{}',
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS webhooks_owner_id_idx ON webhooks (owner_id, id);
+
+-- One row per (webhook, event) delivery, kept as the delivery log. A manual
+-- redelivery adds a new row pointing at the one it repeats.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id BIGSERIAL PRIMARY KEY,
+  webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+  event_id BIGINT NOT NULL,
+  topic TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending'
+    CONSTRAINT webhook_deliveries_status CHECK (status IN ('pending', 'delivered', 'failed')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_status_code INTEGER,
+  last_error TEXT,
+  redelivery_of BIGINT REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  delivered_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending_idx ON webhook_deliveries (next_attempt_at, id)
+  WHERE status = 'pending';
@@ -0,0 +1,17 @@
+-- Idempotency-Key support for POST /api/tasks: the first request with a key
+-- stores its response here, in the same transaction as the task it created,
+-- and retries with the same key get that response back until expires_at.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  owner_id TEXT NOT NULL,
+  key TEXT NOT NULL,
+  -- SHA-256 of the (normalized) request, to reject reuse with another payload.
+  request_hash TEXT NOT NULL,
+  status_code INTEGER NOT NULL,
+  response JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  -- Keys are per caller: two users may pick the same key.
+  PRIMARY KEY (owner_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,4 @@
+-- Trashed rows would reappear as live tasks, so drop them first.
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS tasks_deleted_at_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
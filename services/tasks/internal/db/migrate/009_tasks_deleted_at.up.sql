@@ -0,0 +1,9 @@
+-- Soft delete: DELETE /api/tasks/{id} sets deleted_at instead of removing
+-- the row, so the task can be restored from the trash. The purger in the
+-- server hard-deletes rows that have been in the trash for longer than
+-- TASKS_TRASH_RETENTION.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Only the purger looks trashed rows up by age.
+CREATE INDEX IF NOT EXISTS tasks_deleted_at_idx ON tasks (deleted_at)
+  WHERE deleted_at IS NOT NULL;
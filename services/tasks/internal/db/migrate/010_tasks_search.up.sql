@@ -0,0 +1,7 @@
+-- Full-text search (GET /api/tasks/search). The vector is a generated
+-- column so every writer keeps it current; title is weighted A so that
+-- fields added later (e.g. a description, weight B) rank below it.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS search tsvector
+  GENERATED ALWAYS AS (setweight(to_tsvector('english', title), 'A')) STORED;
+
+CREATE INDEX IF NOT EXISTS tasks_search_idx ON tasks USING GIN (search);
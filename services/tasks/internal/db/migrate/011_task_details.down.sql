@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS tasks_search_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search;
+ALTER TABLE tasks ADD COLUMN search tsvector
+  GENERATED ALWAYS AS (setweight(to_tsvector('english', title), 'A')) STORED;
+CREATE INDEX IF NOT EXISTS tasks_search_idx ON tasks USING GIN (search);
+
+DROP TABLE IF EXISTS task_tags;
+DROP TABLE IF EXISTS tags;
+DROP INDEX IF EXISTS tasks_owner_due_at_idx;
+ALTER TABLE tasks
+  DROP COLUMN IF EXISTS priority,
+  DROP COLUMN IF EXISTS due_at,
+  DROP COLUMN IF EXISTS description;
+DROP TYPE IF EXISTS task_priority;
@@ -0,0 +1,46 @@
+-- Richer tasks: a markdown description, an optional due date, a priority
+-- and tags. The rules mirror internal/tasks/validate.go: descriptions may
+-- hold newlines and tabs but no other control characters, and tag names
+-- are stored lower-cased.
+CREATE TYPE task_priority AS ENUM ('low', 'normal', 'high', 'urgent');
+
+ALTER TABLE tasks
+  ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT ''
+    CONSTRAINT tasks_description_length CHECK (char_length(description) <= 10000)
+    CONSTRAINT tasks_description_no_control CHECK (description !~ '[\x01-\x08\x0b-\x1f\x7f]'),
+  ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS priority task_priority NOT NULL DEFAULT 'normal';
+
+-- ?due_before= lists live tasks by due date.
+CREATE INDEX IF NOT EXISTS tasks_owner_due_at_idx ON tasks (owner_id, due_at)
+  WHERE due_at IS NOT NULL AND deleted_at IS NULL;
+
+-- Tags are per owner; a task has any number of them and a tag any number
+-- of tasks. Unused tags are kept, so they can be reused by name.
+CREATE TABLE IF NOT EXISTS tags (
+  id SERIAL PRIMARY KEY,
+  owner_id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  CONSTRAINT tags_owner_name UNIQUE (owner_id, name),
+  CONSTRAINT tags_name_lower CHECK (name = lower(name) AND btrim(name) <> '' AND char_length(name) <= 32)
+);
+
+CREATE TABLE IF NOT EXISTS task_tags (
+  task_id INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  tag_id INT NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+  PRIMARY KEY (task_id, tag_id)
+);
+
+-- ?tag= goes from the tag to its tasks.
+CREATE INDEX IF NOT EXISTS task_tags_tag_id_idx ON task_tags (tag_id);
+
+-- Search the description too, ranked below the title. A generated column's
+-- expression cannot be changed in place, so it is re-created.
+DROP INDEX IF EXISTS tasks_search_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search;
+ALTER TABLE tasks ADD COLUMN search tsvector
+  GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', description), 'B')
+  ) STORED;
+CREATE INDEX IF NOT EXISTS tasks_search_idx ON tasks USING GIN (search);
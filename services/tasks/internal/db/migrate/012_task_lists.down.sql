@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS tasks_list_position_idx;
+ALTER TABLE tasks
+  DROP CONSTRAINT IF EXISTS tasks_list_position,
+  DROP COLUMN IF EXISTS position,
+  DROP COLUMN IF EXISTS list_id;
+DROP TABLE IF EXISTS lists;
@@ -0,0 +1,26 @@
+-- Lists (projects) group an owner's tasks. A task is in at most one list,
+-- ordered within it by position: a float, so a task can be moved between
+-- two neighbours by taking the midpoint of their positions without
+-- renumbering the rest (see Repo.Move).
+CREATE TABLE IF NOT EXISTS lists (
+  id SERIAL PRIMARY KEY,
+  owner_id TEXT NOT NULL,
+  name TEXT NOT NULL
+    CONSTRAINT lists_name_not_blank CHECK (btrim(name) <> '')
+    CONSTRAINT lists_name_length CHECK (char_length(name) <= 100)
+    CONSTRAINT lists_name_no_control CHECK (name !~ '[\x01-\x1f\x7f]'),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS lists_owner_id_idx ON lists (owner_id, id);
+
+-- Deleting a list takes its tasks out of it rather than deleting them.
+-- Repo.DeleteList does that itself, so it can record the change; the FK
+-- action is the backstop.
+ALTER TABLE tasks
+  ADD COLUMN IF NOT EXISTS list_id INT REFERENCES lists (id) ON DELETE SET NULL (list_id, position),
+  ADD COLUMN IF NOT EXISTS position DOUBLE PRECISION,
+  ADD CONSTRAINT tasks_list_position CHECK ((list_id IS NULL) = (position IS NULL));
+
+CREATE INDEX IF NOT EXISTS tasks_list_position_idx ON tasks (list_id, position, id)
+  WHERE list_id IS NOT NULL;
@@ -0,0 +1,18 @@
+-- Task relationships. A task may be a subtask of one parent, and may be
+-- blocked by any number of other tasks. Together they form a "must finish
+-- before" graph (a subtask before its parent, a blocker before what it
+-- blocks), which the service keeps acyclic; see internal/tasks/deps.go.
+ALTER TABLE tasks
+  ADD COLUMN IF NOT EXISTS parent_id INT REFERENCES tasks (id) ON DELETE SET NULL
+    CONSTRAINT tasks_parent_not_self CHECK (parent_id <> id);
+CREATE INDEX IF NOT EXISTS tasks_parent_id_idx ON tasks (parent_id) WHERE parent_id IS NOT NULL;
+
+-- task_id cannot start before blocked_by_id is done.
+CREATE TABLE IF NOT EXISTS task_dependencies (
+  task_id INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  blocked_by_id INT NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (task_id, blocked_by_id),
+  CONSTRAINT task_dependencies_not_self CHECK (task_id <> blocked_by_id)
+);
+CREATE INDEX IF NOT EXISTS task_dependencies_blocked_by_id_idx ON task_dependencies (blocked_by_id);
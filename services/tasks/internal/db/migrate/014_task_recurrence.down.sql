@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS tasks_next_occurrence_at_idx;
+ALTER TABLE tasks
+  DROP CONSTRAINT IF EXISTS tasks_next_occurrence_rrule,
+  DROP CONSTRAINT IF EXISTS tasks_rrule_start,
+  DROP COLUMN IF EXISTS next_occurrence_at,
+  DROP COLUMN IF EXISTS rrule_start_at,
+  DROP COLUMN IF EXISTS rrule;
@@ -0,0 +1,17 @@
+-- Recurring tasks. A task with an rrule (an RFC 5545 RRULE value such as
+-- FREQ=WEEKLY;BYDAY=MO) is one occurrence of a series that started at
+-- rrule_start_at (the rule's DTSTART). next_occurrence_at is when the
+-- following occurrence is due: the scheduler creates it as a new task then,
+-- or as soon as this one is done, and clears next_occurrence_at so it is
+-- created only once. It is NULL once that has happened, or when the series
+-- has no further occurrences. See internal/tasks/recurrence.go.
+ALTER TABLE tasks
+  ADD COLUMN IF NOT EXISTS rrule TEXT
+    CONSTRAINT tasks_rrule_length CHECK (char_length(rrule) <= 500),
+  ADD COLUMN IF NOT EXISTS rrule_start_at TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS next_occurrence_at TIMESTAMPTZ,
+  ADD CONSTRAINT tasks_rrule_start CHECK ((rrule IS NULL) = (rrule_start_at IS NULL)),
+  ADD CONSTRAINT tasks_next_occurrence_rrule CHECK (next_occurrence_at IS NULL OR rrule IS NOT NULL);
+
+CREATE INDEX IF NOT EXISTS tasks_next_occurrence_at_idx ON tasks (next_occurrence_at)
+  WHERE next_occurrence_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS task_reminders;
+DROP TABLE IF EXISTS reminder_prefs;
+DROP INDEX IF EXISTS tasks_remind_at_idx;
+ALTER TABLE tasks DROP COLUMN IF EXISTS remind_at;
@@ -0,0 +1,44 @@
+-- Due-date reminders. A task is reminded of at remind_at if set, otherwise
+-- lead_minutes (from its owner's preferences) before due_at. Each reminder
+-- is recorded in task_reminders, once per (task, time): that row is both
+-- the de-duplication key and the delivery queue for the reminder worker
+-- (internal/notify). Moving remind_at or due_at makes a new reminder.
+ALTER TABLE tasks ADD COLUMN IF NOT EXISTS remind_at TIMESTAMPTZ;
+
+-- How each owner wants to be reminded. Owners without a row get the
+-- defaults: channel 'log', no lead time.
+CREATE TABLE IF NOT EXISTS reminder_prefs (
+  owner_id TEXT PRIMARY KEY,
+  channel TEXT NOT NULL DEFAULT 'log'
+    CONSTRAINT reminder_prefs_channel CHECK (channel IN ('none', 'log', 'email', 'webhook')),
+  email TEXT,
+  webhook_url TEXT,
+  lead_minutes INTEGER NOT NULL DEFAULT 0
+    CONSTRAINT reminder_prefs_lead_minutes CHECK (lead_minutes BETWEEN 0 AND 10080),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  CONSTRAINT reminder_prefs_email CHECK (channel <> 'email' OR email IS NOT NULL),
+  CONSTRAINT reminder_prefs_webhook_url CHECK (channel <> 'webhook' OR webhook_url IS NOT NULL)
+);
+
+CREATE TABLE IF NOT EXISTS task_reminders (
+  id BIGSERIAL PRIMARY KEY,
+  task_id INTEGER NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+  owner_id TEXT NOT NULL,
+  remind_at TIMESTAMPTZ NOT NULL,
+  -- Where to send it, fixed when the reminder is queued.
+  channel TEXT NOT NULL,
+  address TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'pending'
+    CONSTRAINT task_reminders_status CHECK (status IN ('pending', 'sent', 'failed', 'cancelled')),
+  attempts INTEGER NOT NULL DEFAULT 0,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_error TEXT,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  sent_at TIMESTAMPTZ,
+  CONSTRAINT task_reminders_once UNIQUE (task_id, remind_at)
+);
+CREATE INDEX IF NOT EXISTS task_reminders_pending_idx ON task_reminders (next_attempt_at, id)
+  WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS tasks_remind_at_idx ON tasks (remind_at)
+  WHERE remind_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS task_audit_append_only ON task_audit;
+DROP FUNCTION IF EXISTS task_audit_append_only();
+DROP TABLE IF EXISTS task_audit;
@@ -0,0 +1,32 @@
+-- Audit log: one row per change to a task (created, updated or deleted, as
+-- in the outbox topics), written in the same transaction as the change. It
+-- records who made it (actor: the caller's subject, or 'system' for the
+-- scheduler), the request it came from, and before/after: the fields that
+-- changed, with their old and new values (before is NULL on creation).
+--
+-- There is no foreign key to tasks: the history outlives purged tasks. The
+-- log is append-only; the trigger below rejects updates and deletes.
+CREATE TABLE IF NOT EXISTS task_audit (
+  id BIGSERIAL PRIMARY KEY,
+  task_id INTEGER NOT NULL,
+  owner_id TEXT NOT NULL,
+  actor TEXT NOT NULL,
+  action TEXT NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+  request_id TEXT NOT NULL DEFAULT '',
+  before JSONB,
+  after JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_audit_owner_idx ON task_audit (owner_id, id);
+CREATE INDEX IF NOT EXISTS task_audit_task_idx ON task_audit (task_id, id);
+
+CREATE OR REPLACE FUNCTION task_audit_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'task_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER task_audit_append_only
+  BEFORE UPDATE OR DELETE ON task_audit
+  FOR EACH ROW EXECUTE FUNCTION task_audit_append_only();
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS task_events_append_only ON task_events;
+DROP FUNCTION IF EXISTS task_events_append_only();
+DROP TABLE IF EXISTS task_events;
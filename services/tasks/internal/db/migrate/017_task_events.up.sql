@@ -0,0 +1,41 @@
+-- Event store for the event-sourced mode (TASKS_EVENT_SOURCING; see
+-- internal/tasks/eventstore.go). Each task has a stream of events, numbered
+-- by seq from 1, that replays to the task: the tasks row is its projection,
+-- updated in the same transaction as the events are appended.
+--
+-- One change appends one or more events (a PATCH may rename and complete a
+-- task); change is the seq of the first of them. A change made by an undo
+-- or redo names the change it reverts or re-applies. data holds the task
+-- fields the event changed, {"before": {...}, "after": {...}}, in the
+-- task's JSON form. A stream starts with TaskCreated, or with TaskImported
+-- (the whole task as it was) for a task changed while the mode was off.
+--
+-- Like task_audit, the store outlives purged tasks and is append-only.
+CREATE TABLE IF NOT EXISTS task_events (
+  task_id INTEGER NOT NULL,
+  seq INTEGER NOT NULL CHECK (seq > 0),
+  change INTEGER NOT NULL,
+  owner_id TEXT NOT NULL,
+  type TEXT NOT NULL CHECK (type IN (
+    'TaskImported', 'TaskCreated', 'TaskRenamed', 'TaskCompleted', 'TaskReopened',
+    'TaskDetailsChanged', 'TaskMoved', 'TaskDeleted', 'TaskRestored'
+  )),
+  data JSONB NOT NULL,
+  undoes INTEGER,
+  redoes INTEGER,
+  actor TEXT NOT NULL,
+  request_id TEXT NOT NULL DEFAULT '',
+  occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (task_id, seq),
+  CONSTRAINT task_events_one_revert CHECK (undoes IS NULL OR redoes IS NULL)
+);
+
+CREATE OR REPLACE FUNCTION task_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'task_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER task_events_append_only
+  BEFORE UPDATE OR DELETE ON task_events
+  FOR EACH ROW EXECUTE FUNCTION task_events_append_only();
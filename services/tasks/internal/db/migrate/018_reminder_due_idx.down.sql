@@ -0,0 +1 @@
+DROP INDEX IF EXISTS tasks_open_due_at_idx;
@@ -0,0 +1,4 @@
+-- Lets EnqueueDueReminders find the open tasks due soon without scanning
+-- them all; tasks_remind_at_idx covers the ones with an explicit remind_at.
+CREATE INDEX IF NOT EXISTS tasks_open_due_at_idx ON tasks (due_at)
+  WHERE NOT done AND deleted_at IS NULL;
@@ -0,0 +1,65 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad_EmbeddedMigrationsArePairedAndOrdered(t *testing.T) {
+	migs, err := load(embedded)
+	if err != nil {
+		t.Fatalf("load embedded: %v", err)
+	}
+	if len(migs) == 0 {
+		t.Fatalf("expected embedded migrations")
+	}
+	if migs[0].Version != 1 || migs[0].Name != "init" {
+		t.Fatalf("expected 001_init first, got %03d_%s", migs[0].Version, migs[0].Name)
+	}
+	for i := 1; i < len(migs); i++ {
+		if migs[i].Version <= migs[i-1].Version {
+			t.Fatalf("migrations out of order: %d after %d", migs[i].Version, migs[i-1].Version)
+		}
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"name must match": {
+			"init.sql": {Data: []byte("SELECT 1")},
+		},
+		"needs both": {
+			"001_init.up.sql": {Data: []byte("SELECT 1")},
+		},
+		"conflicting names": {
+			"001_init.up.sql":    {Data: []byte("SELECT 1")},
+			"001_other.down.sql": {Data: []byte("SELECT 1")},
+		},
+	}
+	for want, fsys := range cases {
+		_, err := load(fsys)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLoad_SortsNumerically(t *testing.T) {
+	fsys := fstest.MapFS{
+		"10_later.up.sql":    {Data: []byte("SELECT 10")},
+		"10_later.down.sql":  {Data: []byte("SELECT -10")},
+		"9_earlier.up.sql":   {Data: []byte("SELECT 9")},
+		"9_earlier.down.sql": {Data: []byte("SELECT -9")},
+	}
+	migs, err := load(fsys)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(migs) != 2 || migs[0].Version != 9 || migs[1].Version != 10 {
+		t.Fatalf("unexpected order: %+v", migs)
+	}
+	if migs[1].Up != "SELECT 10" || migs[1].Down != "SELECT -10" {
+		t.Fatalf("up/down not paired: %+v", migs[1])
+	}
+}
@@ -0,0 +1,40 @@
+-- name: SnapshotTask :one
+-- A task as it is before a change, trashed or not, locked until the end of
+-- the transaction so the change applies to exactly this version.
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id)
+FOR UPDATE;
+
+-- name: AppendTaskAudit :exec
+INSERT INTO task_audit (task_id, owner_id, actor, action, request_id, before, after)
+VALUES (sqlc.arg(task_id), sqlc.arg(owner_id), sqlc.arg(actor), sqlc.arg(action), sqlc.arg(request_id),
+        sqlc.narg(before), sqlc.arg(after));
+
+-- name: AppendTaskAuditBatch :exec
+-- The bulk form of AppendTaskAudit for created tasks (no before).
+INSERT INTO task_audit (task_id, owner_id, actor, action, request_id, after)
+SELECT unnest(sqlc.arg(task_ids)::int[]), sqlc.arg(owner_id), sqlc.arg(actor), 'created', sqlc.arg(request_id),
+       unnest(sqlc.arg(afters)::text[])::jsonb;
+
+-- name: ListTaskAudit :many
+-- owner_id's audit entries, newest first, narrowed by any of the optional
+-- filters. before_id is the keyset cursor: the last id of the previous page.
+SELECT a.id, a.task_id, a.actor, a.action, a.request_id, a.before, a.after, a.created_at
+FROM task_audit a
+WHERE a.owner_id = sqlc.arg(owner_id)
+  AND (sqlc.narg(task_id)::int IS NULL OR a.task_id = sqlc.narg(task_id))
+  AND (sqlc.narg(actor)::text IS NULL OR a.actor = sqlc.narg(actor))
+  AND (sqlc.narg(action)::text IS NULL OR a.action = sqlc.narg(action))
+  AND (sqlc.narg(request_id)::text IS NULL OR a.request_id = sqlc.narg(request_id))
+  AND (sqlc.narg(since)::timestamptz IS NULL OR a.created_at >= sqlc.narg(since))
+  AND (sqlc.narg(until)::timestamptz IS NULL OR a.created_at < sqlc.narg(until))
+  AND (sqlc.narg(before_id)::bigint IS NULL OR a.id < sqlc.narg(before_id))
+ORDER BY a.id DESC
+LIMIT sqlc.arg(lim)::int;
+
+-- name: SnapshotListTasks :many
+-- SnapshotTask for every task in a list, trashed ones included.
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE list_id = sqlc.arg(list_id) AND owner_id = sqlc.arg(owner_id)
+ORDER BY id
+FOR UPDATE;
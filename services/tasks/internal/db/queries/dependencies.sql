@@ -0,0 +1,54 @@
+-- name: LockTaskGraph :exec
+-- Serializes changes to one owner's task graph for the rest of the
+-- transaction, so two concurrent links cannot each pass the cycle check
+-- and together close a cycle.
+SELECT pg_advisory_xact_lock(hashtext('task_graph:' || sqlc.arg(owner_id)::text));
+
+-- name: ListTaskEdges :many
+-- Every "must finish before" edge of owner's tasks, trashed ones included
+-- (they can be restored): blocker before blocked, subtask before parent.
+SELECT d.blocked_by_id AS before_id, d.task_id AS after_id
+FROM task_dependencies d
+JOIN tasks t ON t.id = d.task_id
+WHERE t.owner_id = sqlc.arg(owner_id)
+UNION ALL
+SELECT c.id AS before_id, c.parent_id::int AS after_id
+FROM tasks c
+WHERE c.owner_id = sqlc.arg(owner_id) AND c.parent_id IS NOT NULL;
+
+-- name: ListOpenTasks :many
+-- owner's live tasks that are not done: the nodes of the plan Next ranks.
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+FROM tasks
+WHERE owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL AND NOT done;
+
+-- name: SetTaskParent :one
+-- Makes a live task a subtask of parent_id, or a top-level task if NULL.
+UPDATE tasks SET parent_id = sqlc.narg(parent_id)
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: ListSubtasks :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+FROM tasks
+WHERE parent_id = sqlc.arg(parent_id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+ORDER BY id;
+
+-- name: AddTaskDependency :exec
+INSERT INTO task_dependencies (task_id, blocked_by_id)
+VALUES (sqlc.arg(task_id), sqlc.arg(blocked_by_id))
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveTaskDependency :execrows
+-- Both tasks are known to be the caller's.
+DELETE FROM task_dependencies
+WHERE task_id = sqlc.arg(task_id) AND blocked_by_id = sqlc.arg(blocked_by_id);
+
+-- name: ListBlockers :many
+-- The live tasks blocking task_id, done or not.
+SELECT t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at,
+       t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at
+FROM task_dependencies d
+JOIN tasks t ON t.id = d.blocked_by_id
+WHERE d.task_id = sqlc.arg(task_id) AND t.owner_id = sqlc.arg(owner_id) AND t.deleted_at IS NULL
+ORDER BY t.id;
@@ -0,0 +1,27 @@
+-- name: LastTaskEvent :one
+-- The seq of a task's latest event, and the task version it left behind:
+-- that of the latest event to record one (an older event may not), or 0.
+SELECT seq, COALESCE((
+    SELECT (v.data->'after'->>'version')::int FROM task_events v
+    WHERE v.task_id = sqlc.arg(task_id) AND v.data->'after'->'version' IS NOT NULL
+    ORDER BY v.seq DESC
+    LIMIT 1
+  ), 0)::int AS version
+FROM task_events
+WHERE task_id = sqlc.arg(task_id)
+ORDER BY seq DESC
+LIMIT 1;
+
+-- name: AppendTaskEvent :exec
+-- occurred_at defaults to the transaction's time, like updated_at.
+INSERT INTO task_events (task_id, seq, change, owner_id, type, data, undoes, redoes, actor, request_id, occurred_at)
+VALUES (sqlc.arg(task_id), sqlc.arg(seq), sqlc.arg(change), sqlc.arg(owner_id), sqlc.arg(type), sqlc.arg(data),
+        sqlc.narg(undoes), sqlc.narg(redoes), sqlc.arg(actor), sqlc.arg(request_id),
+        COALESCE(sqlc.narg(occurred_at)::timestamptz, now()));
+
+-- name: ListTaskEvents :many
+-- One of owner's task streams, in order.
+SELECT task_id, seq, change, type, data, undoes, redoes, actor, request_id, occurred_at
+FROM task_events
+WHERE task_id = sqlc.arg(task_id) AND owner_id = sqlc.arg(owner_id)
+ORDER BY seq;
@@ -0,0 +1,24 @@
+-- name: LockIdempotencyKey :exec
+-- Serializes concurrent requests carrying the same key until the calling
+-- transaction ends, so only the first one creates anything.
+SELECT pg_advisory_xact_lock(hashtextextended(sqlc.arg(owner_id)::text || ':' || sqlc.arg(key)::text, 0));
+
+-- name: GetIdempotencyKey :one
+-- Expired keys are treated as never used.
+SELECT request_hash, status_code, response FROM idempotency_keys
+WHERE owner_id = sqlc.arg(owner_id) AND key = sqlc.arg(key) AND expires_at > now();
+
+-- name: SaveIdempotencyKey :exec
+-- Overwrites an expired row left behind for the same key.
+INSERT INTO idempotency_keys (owner_id, key, request_hash, status_code, response, expires_at)
+VALUES (sqlc.arg(owner_id), sqlc.arg(key), sqlc.arg(request_hash), sqlc.arg(status_code),
+        sqlc.arg(response), sqlc.arg(expires_at))
+ON CONFLICT (owner_id, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+    status_code = EXCLUDED.status_code,
+    response = EXCLUDED.response,
+    created_at = now(),
+    expires_at = EXCLUDED.expires_at;
+
+-- name: PurgeIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE expires_at <= now();
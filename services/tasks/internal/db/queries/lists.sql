@@ -0,0 +1,88 @@
+-- name: ListLists :many
+SELECT id, owner_id, name, created_at, updated_at FROM lists
+WHERE owner_id = sqlc.arg(owner_id) ORDER BY id;
+
+-- name: GetList :one
+SELECT id, owner_id, name, created_at, updated_at FROM lists
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id);
+
+-- name: LockList :one
+-- Locks one of owner's lists for the rest of the transaction, so that
+-- concurrent moves into it pick positions (and renumber) one at a time.
+SELECT id FROM lists
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id)
+FOR UPDATE;
+
+-- name: CreateList :one
+INSERT INTO lists (owner_id, name) VALUES (sqlc.arg(owner_id), sqlc.arg(name))
+RETURNING id, owner_id, name, created_at, updated_at;
+
+-- name: UpdateList :one
+UPDATE lists SET name = sqlc.arg(name), updated_at = now()
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id)
+RETURNING id, owner_id, name, created_at, updated_at;
+
+-- name: DeleteList :execrows
+DELETE FROM lists WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id);
+
+-- name: ListTasksInList :many
+-- A list's live tasks in list order. Ties (from concurrent moves) are
+-- broken by id, so the order is always total.
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at
+FROM tasks
+WHERE list_id = sqlc.arg(list_id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+ORDER BY position, id;
+
+-- name: DetachListTasks :many
+-- Takes every task (trashed ones too) out of a list that is about to be
+-- deleted.
+UPDATE tasks SET list_id = NULL, position = NULL
+WHERE list_id = sqlc.arg(list_id)
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: LastListPosition :one
+-- The position after which a task is appended to a list; 0 if it is empty.
+-- Trashed tasks keep their place, so they count too.
+SELECT COALESCE(max(position), 0)::float8 AS position FROM tasks
+WHERE list_id = sqlc.arg(list_id) AND id <> sqlc.arg(exclude_id);
+
+-- name: ListTaskPosition :one
+-- The position of a live task of the given list, the anchor of a move.
+SELECT position::float8 AS position FROM tasks
+WHERE id = sqlc.arg(id) AND list_id = sqlc.arg(list_id) AND deleted_at IS NULL;
+
+-- name: ListPositionBefore :one
+-- The position of the task just before (position, id) in a list, ignoring
+-- the task being moved. No row means the anchor is first.
+SELECT position::float8 AS position FROM tasks
+WHERE list_id = sqlc.arg(list_id) AND id <> sqlc.arg(exclude_id)
+  AND (position, id) < (sqlc.arg(position)::float8, sqlc.arg(anchor_id)::int)
+ORDER BY position DESC, id DESC
+LIMIT 1;
+
+-- name: ListPositionAfter :one
+-- The mirror image of ListPositionBefore. No row means the anchor is last.
+SELECT position::float8 AS position FROM tasks
+WHERE list_id = sqlc.arg(list_id) AND id <> sqlc.arg(exclude_id)
+  AND (position, id) > (sqlc.arg(position)::float8, sqlc.arg(anchor_id)::int)
+ORDER BY position, id
+LIMIT 1;
+
+-- name: RenumberList :many
+-- Spreads a list's positions back out to 1, 2, 3, ... in the same order,
+-- for when repeated moves into the same gap have run out of float
+-- precision. Returns the tasks (trashed ones too) whose position changed.
+UPDATE tasks t SET position = r.n
+FROM (
+  SELECT s.id, row_number() OVER (ORDER BY s.position, s.id)::float8 AS n
+  FROM tasks s WHERE s.list_id = sqlc.arg(list_id)
+) r
+WHERE t.id = r.id AND t.position IS DISTINCT FROM r.n
+RETURNING t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at, t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at;
+
+-- name: MoveTask :one
+-- Puts a live task at position in a list, or, with both NULL, takes it out
+-- of its list.
+UPDATE tasks SET list_id = sqlc.narg(list_id), position = sqlc.narg(position)
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
@@ -0,0 +1,34 @@
+-- name: EnqueueOutbox :one
+-- Called inside the transaction of the change the event describes.
+INSERT INTO outbox (topic, payload) VALUES (sqlc.arg(topic), sqlc.arg(payload))
+RETURNING id;
+
+-- name: ClaimOutbox :many
+-- Leases up to lim due messages to the caller by pushing next_attempt_at to
+-- lease_until. SKIP LOCKED lets several relays poll without double-claiming;
+-- a relay that dies holding a lease simply lets it expire.
+UPDATE outbox
+SET attempts = attempts + 1,
+    next_attempt_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE delivered_at IS NULL AND next_attempt_at <= now()
+  ORDER BY id
+  LIMIT sqlc.arg(lim)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, topic, payload, created_at, attempts;
+
+-- name: MarkOutboxDelivered :exec
+UPDATE outbox SET delivered_at = now(), last_error = NULL WHERE id = sqlc.arg(id);
+
+-- name: MarkOutboxFailed :exec
+UPDATE outbox
+SET last_error = sqlc.arg(last_error), next_attempt_at = sqlc.arg(retry_at)
+WHERE id = sqlc.arg(id);
+
+-- name: EnqueueOutboxBatch :many
+-- The bulk form of EnqueueOutbox: one message per (topic, payload) pair.
+INSERT INTO outbox (topic, payload)
+SELECT unnest(sqlc.arg(topics)::text[]), unnest(sqlc.arg(payloads)::text[])::jsonb
+RETURNING id;
@@ -0,0 +1,40 @@
+-- name: GetTaskRecurrence :one
+-- Locks a live task ahead of an update that may change its schedule, and
+-- returns what the new next_occurrence_at depends on.
+SELECT due_at, rrule, rrule_start_at, next_occurrence_at
+FROM tasks
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+FOR UPDATE;
+
+-- name: ClaimDueOccurrences :many
+-- Every owner's live recurring tasks whose next occurrence is due by now,
+-- or that are done, so it should be created early. Rows claimed by another
+-- transaction are skipped rather than waited for.
+SELECT id, owner_id, list_id, rrule::text AS rrule, rrule_start_at, next_occurrence_at
+FROM tasks
+WHERE next_occurrence_at IS NOT NULL AND deleted_at IS NULL
+  AND (next_occurrence_at <= sqlc.arg(now)::timestamptz OR done)
+ORDER BY next_occurrence_at
+LIMIT sqlc.arg(lim)::int
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateOccurrence :one
+-- Copies task id as the next occurrence of its series, due at due_at.
+-- It is not done, and has no subtasks or blockers of its own.
+INSERT INTO tasks (title, owner_id, description, due_at, priority, list_id, position,
+                   rrule, rrule_start_at, next_occurrence_at)
+SELECT p.title, p.owner_id, p.description, sqlc.arg(due_at)::timestamptz, p.priority,
+       sqlc.narg(list_id), sqlc.narg(position),
+       p.rrule, p.rrule_start_at, sqlc.narg(next_occurrence_at)
+FROM tasks p
+WHERE p.id = sqlc.arg(id)
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: CopyTaskTags :exec
+INSERT INTO task_tags (task_id, tag_id)
+SELECT sqlc.arg(to_id)::int, tt.tag_id FROM task_tags tt WHERE tt.task_id = sqlc.arg(from_id)::int;
+
+-- name: ClearNextOccurrence :one
+UPDATE tasks SET next_occurrence_at = NULL
+WHERE id = sqlc.arg(id)
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
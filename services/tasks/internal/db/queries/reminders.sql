@@ -0,0 +1,73 @@
+-- name: GetReminderPrefs :one
+SELECT owner_id, channel, email, webhook_url, lead_minutes, updated_at FROM reminder_prefs
+WHERE owner_id = sqlc.arg(owner_id);
+
+-- name: UpsertReminderPrefs :one
+INSERT INTO reminder_prefs (owner_id, channel, email, webhook_url, lead_minutes)
+VALUES (sqlc.arg(owner_id), sqlc.arg(channel), sqlc.narg(email), sqlc.narg(webhook_url), sqlc.arg(lead_minutes))
+ON CONFLICT (owner_id) DO UPDATE
+SET channel      = EXCLUDED.channel,
+    email        = EXCLUDED.email,
+    webhook_url  = EXCLUDED.webhook_url,
+    lead_minutes = EXCLUDED.lead_minutes,
+    updated_at   = now()
+RETURNING owner_id, channel, email, webhook_url, lead_minutes, updated_at;
+
+-- name: EnqueueDueReminders :execrows
+-- Queues a reminder for every open, live task whose reminder time (see
+-- 015_reminders.up.sql) is in (since, now], unless its owner opted out.
+-- One already queued for the same time is left alone, so each fires once.
+--
+-- The reminder time is computed per row, so the window is first applied to
+-- remind_at and due_at themselves, where tasks_remind_at_idx and
+-- tasks_open_due_at_idx can serve it: a reminder from due_at fires at most
+-- 10080 minutes (the lead_minutes cap) before it.
+INSERT INTO task_reminders (task_id, owner_id, remind_at, channel, address)
+SELECT t.id, t.owner_id, d.at, d.channel,
+       CASE d.channel WHEN 'email' THEN p.email WHEN 'webhook' THEN p.webhook_url ELSE '' END
+FROM tasks t
+LEFT JOIN reminder_prefs p ON p.owner_id = t.owner_id
+CROSS JOIN LATERAL (
+  SELECT COALESCE(t.remind_at, t.due_at - make_interval(mins => COALESCE(p.lead_minutes, 0))) AS at,
+         COALESCE(p.channel, 'log') AS channel
+) d
+WHERE NOT t.done AND t.deleted_at IS NULL
+  AND ((t.remind_at > sqlc.arg(since)::timestamptz AND t.remind_at <= sqlc.arg(now)::timestamptz)
+    OR (t.remind_at IS NULL AND t.due_at > sqlc.arg(since)::timestamptz
+        AND t.due_at <= sqlc.arg(now)::timestamptz + make_interval(mins => 10080)))
+  AND d.at > sqlc.arg(since)::timestamptz AND d.at <= sqlc.arg(now)::timestamptz
+  AND d.channel <> 'none'
+ON CONFLICT (task_id, remind_at) DO NOTHING;
+
+-- name: CancelStaleReminders :execrows
+-- Drops pending reminders whose task was done or trashed since they were
+-- queued.
+UPDATE task_reminders r
+SET status = 'cancelled'
+FROM tasks t
+WHERE t.id = r.task_id AND r.status = 'pending'
+  AND (t.done OR t.deleted_at IS NOT NULL);
+
+-- name: ClaimReminders :many
+-- Same leasing scheme as ClaimWebhookDeliveries.
+UPDATE task_reminders r
+SET attempts = r.attempts + 1,
+    next_attempt_at = sqlc.arg(lease_until)
+FROM tasks t
+WHERE t.id = r.task_id
+  AND r.id IN (
+    SELECT q.id FROM task_reminders q
+    WHERE q.status = 'pending' AND q.next_attempt_at <= now()
+    ORDER BY q.id
+    LIMIT sqlc.arg(lim)
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING r.id, r.task_id, r.owner_id, t.title, t.due_at, r.remind_at, r.channel, r.address, r.attempts;
+
+-- name: RecordReminderAttempt :exec
+UPDATE task_reminders
+SET status          = sqlc.arg(status),
+    last_error      = sqlc.narg(last_error),
+    next_attempt_at = sqlc.arg(next_attempt_at),
+    sent_at         = CASE WHEN sqlc.arg(status)::text = 'sent' THEN now() END
+WHERE id = sqlc.arg(id);
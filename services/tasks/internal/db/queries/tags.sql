@@ -0,0 +1,22 @@
+-- name: ClearTaskTags :exec
+DELETE FROM task_tags WHERE task_id = sqlc.arg(task_id);
+
+-- name: AddTaskTags :exec
+-- Tags the task with names (already normalized), creating the owner's tags
+-- that don't exist yet. The no-op DO UPDATE makes RETURNING include tags
+-- that already existed.
+WITH tag AS (
+  INSERT INTO tags (owner_id, name)
+  SELECT sqlc.arg(owner_id), unnest(sqlc.arg(names)::text[])
+  ON CONFLICT (owner_id, name) DO UPDATE SET name = EXCLUDED.name
+  RETURNING id
+)
+INSERT INTO task_tags (task_id, tag_id)
+SELECT sqlc.arg(task_id), id FROM tag
+ON CONFLICT DO NOTHING;
+
+-- name: ListTaskTags :many
+-- The tags of each of the given tasks, in byte order (as Go sorts them).
+SELECT tt.task_id, g.name FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+WHERE tt.task_id = ANY(sqlc.arg(task_ids)::int[])
+ORDER BY tt.task_id, g.name COLLATE "C";
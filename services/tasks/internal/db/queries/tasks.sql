@@ -1,9 +1,125 @@
 -- name: ListTasks :many
-SELECT id, title, done, created_at, updated_at FROM tasks ORDER BY id;
+-- Keyset-paginated listing. after_id/after_created_at come from the decoded
+-- cursor (the last row of the previous page); sort selects both the ORDER BY
+-- and the matching keyset comparison. A NULL lim means "no limit".
+-- Every query is scoped to owner_id: callers only ever see their own tasks.
+-- trashed selects the trash (soft-deleted rows) instead of live tasks.
+-- tag (a normalized tag name) and due_before narrow it further; tasks
+-- without a due date never match due_before.
+SELECT t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at,
+       t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at
+FROM tasks t
+WHERE t.owner_id = sqlc.arg(owner_id)
+  AND (t.deleted_at IS NOT NULL) = sqlc.arg(trashed)::boolean
+  AND (sqlc.narg(done)::boolean IS NULL OR t.done = sqlc.narg(done))
+  AND (sqlc.narg(q)::text IS NULL OR t.title ILIKE '%' || sqlc.narg(q) || '%')
+  AND (sqlc.narg(tag)::text IS NULL OR EXISTS (
+    SELECT 1 FROM task_tags tt JOIN tags g ON g.id = tt.tag_id
+    WHERE tt.task_id = t.id AND g.name = sqlc.narg(tag)
+  ))
+  AND (sqlc.narg(due_before)::timestamptz IS NULL OR t.due_at < sqlc.narg(due_before))
+  AND (
+    sqlc.narg(after_id)::int IS NULL
+    OR (sqlc.arg(sort)::text = 'id' AND t.id > sqlc.narg(after_id))
+    OR (sqlc.arg(sort)::text = '-id' AND t.id < sqlc.narg(after_id))
+    OR (sqlc.arg(sort)::text = 'created_at'
+        AND (t.created_at, t.id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)))
+    OR (sqlc.arg(sort)::text = '-created_at'
+        AND (t.created_at, t.id) < (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)))
+  )
+ORDER BY
+  CASE WHEN sqlc.arg(sort)::text = 'created_at' THEN t.created_at END ASC,
+  CASE WHEN sqlc.arg(sort)::text = '-created_at' THEN t.created_at END DESC,
+  CASE WHEN sqlc.arg(sort)::text IN ('id', 'created_at') THEN t.id END ASC,
+  CASE WHEN sqlc.arg(sort)::text IN ('-id', '-created_at') THEN t.id END DESC
+LIMIT sqlc.narg(lim)::int;
 
 -- name: GetTask :one
-SELECT id, title, done, created_at, updated_at FROM tasks WHERE id = $1;
+-- Trashed tasks are not found (restore them first).
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL;
 
 -- name: CreateTask :one
-INSERT INTO tasks (title) VALUES ($1)
-RETURNING id, title, done, created_at, updated_at;
+INSERT INTO tasks (title, owner_id, description, due_at, priority, list_id, position,
+                   rrule, rrule_start_at, next_occurrence_at, remind_at)
+VALUES (sqlc.arg(title), sqlc.arg(owner_id), sqlc.arg(description), sqlc.narg(due_at), sqlc.arg(priority),
+        sqlc.narg(list_id), sqlc.narg(position),
+        sqlc.narg(rrule), sqlc.narg(rrule_start_at), sqlc.narg(next_occurrence_at), sqlc.narg(remind_at))
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: UpdateTask :one
+-- updated_at and version are bumped by the tasks_touch trigger. A non-NULL
+-- if_version makes the update conditional (If-Match): no row is returned when
+-- the task has moved on since the client read it. due_at can be cleared, so
+-- set_due_at says whether to write it (NULL included) at all, and likewise
+-- set_remind_at, and set_recurrence for the three recurrence columns (see
+-- GetTaskRecurrence). Tags live in task_tags, so set_tags bumps version
+-- itself to make the row change (and the trigger fire) on a tags-only update.
+UPDATE tasks
+SET title       = COALESCE(sqlc.narg(title), title),
+    done        = COALESCE(sqlc.narg(done), done),
+    description = COALESCE(sqlc.narg(description), description),
+    priority    = COALESCE(sqlc.narg(priority), priority),
+    due_at      = CASE WHEN sqlc.arg(set_due_at)::boolean THEN sqlc.narg(due_at) ELSE due_at END,
+    remind_at   = CASE WHEN sqlc.arg(set_remind_at)::boolean THEN sqlc.narg(remind_at) ELSE remind_at END,
+    rrule              = CASE WHEN sqlc.arg(set_recurrence)::boolean THEN sqlc.narg(rrule) ELSE rrule END,
+    rrule_start_at     = CASE WHEN sqlc.arg(set_recurrence)::boolean THEN sqlc.narg(rrule_start_at) ELSE rrule_start_at END,
+    next_occurrence_at = CASE WHEN sqlc.arg(set_recurrence)::boolean THEN sqlc.narg(next_occurrence_at) ELSE next_occurrence_at END,
+    version            = CASE WHEN sqlc.arg(set_tags)::boolean THEN version + 1 ELSE version END
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+  AND (sqlc.narg(if_version)::int IS NULL OR version = sqlc.narg(if_version))
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: DeleteTask :one
+-- Moves the task to the trash; PurgeTrashedTasks removes it for good later.
+UPDATE tasks SET deleted_at = now()
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: RestoreTask :one
+UPDATE tasks SET deleted_at = NULL
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id) AND deleted_at IS NOT NULL
+RETURNING id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at;
+
+-- name: PurgeTrashedTasks :execrows
+-- Hard-deletes up to lim tasks trashed before cutoff. The purger calls it
+-- until it returns fewer than lim, keeping each transaction short.
+DELETE FROM tasks
+WHERE id IN (
+  SELECT t.id FROM tasks t
+  WHERE t.deleted_at < sqlc.arg(cutoff)::timestamptz
+  ORDER BY t.deleted_at
+  LIMIT sqlc.arg(lim)::int
+);
+
+-- name: NextTaskIDs :many
+-- Reserves n ids from the tasks sequence, so a bulk COPY (which returns
+-- nothing) knows the ids of the rows it inserts.
+SELECT nextval(pg_get_serial_sequence('tasks', 'id'))::int AS id
+FROM generate_series(1, sqlc.arg(n)::int);
+
+-- name: CopyTasks :copyfrom
+INSERT INTO tasks (id, title, owner_id, description, due_at, priority, rrule, rrule_start_at, next_occurrence_at, remind_at,
+                   list_id, position)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
+
+-- name: GetTasksByIDs :many
+SELECT id, title, done, created_at, updated_at, version, owner_id, deleted_at, description, due_at, priority, list_id, position, parent_id, rrule, next_occurrence_at, remind_at FROM tasks
+WHERE owner_id = sqlc.arg(owner_id) AND id = ANY(sqlc.arg(ids)::int[])
+ORDER BY id;
+
+-- name: SearchTasks :many
+-- Ranked full-text search over the caller's live tasks. q is parsed with
+-- websearch_to_tsquery, so any input is valid ("quoted phrases", or, -not).
+-- The snippet is the best-matching stretch of the title and description,
+-- with matches marked \x02...\x03, which cannot occur in either (see the
+-- *_no_control constraints), for the caller to turn into markup.
+SELECT t.id, t.title, t.done, t.created_at, t.updated_at, t.version, t.owner_id, t.deleted_at,
+       t.description, t.due_at, t.priority, t.list_id, t.position, t.parent_id, t.rrule, t.next_occurrence_at, t.remind_at,
+       ts_rank_cd(t.search, query)::real AS rank,
+       ts_headline('english', concat_ws(E'\n', t.title, NULLIF(t.description, '')), query,
+                   'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MinWords=15, MaxWords=35')::text AS snippet
+FROM tasks t, websearch_to_tsquery('english', sqlc.arg(q)::text) AS query
+WHERE t.owner_id = sqlc.arg(owner_id) AND t.deleted_at IS NULL AND t.search @@ query
+ORDER BY rank DESC, t.id DESC
+LIMIT sqlc.arg(lim)::int;
@@ -0,0 +1,87 @@
+-- name: ListWebhooks :many
+SELECT id, owner_id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE owner_id = sqlc.arg(owner_id) ORDER BY id;
+
+-- name: GetWebhook :one
+SELECT id, owner_id, url, secret, events, active, created_at, updated_at FROM webhooks
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id);
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (owner_id, url, secret, events)
+VALUES (sqlc.arg(owner_id), sqlc.arg(url), sqlc.arg(secret), sqlc.arg(events))
+RETURNING id, owner_id, url, secret, events, active, created_at, updated_at;
+
+-- name: UpdateWebhook :one
+UPDATE webhooks
+SET url        = COALESCE(sqlc.narg(url), url),
+    events     = COALESCE(sqlc.narg(events)::text[], events),
+    active     = COALESCE(sqlc.narg(active), active),
+    updated_at = now()
+WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id)
+RETURNING id, owner_id, url, secret, events, active, created_at, updated_at;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks WHERE id = sqlc.arg(id) AND owner_id = sqlc.arg(owner_id);
+
+-- name: EnqueueWebhookDeliveries :exec
+-- Fans one outbox event out to the owner's matching webhooks. Called in the
+-- same transaction as EnqueueOutbox.
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload)
+SELECT w.id, sqlc.arg(event_id)::bigint, sqlc.arg(topic)::text, sqlc.arg(payload)::jsonb
+FROM webhooks w
+WHERE w.owner_id = sqlc.arg(owner_id) AND w.active
+  AND (cardinality(w.events) = 0 OR sqlc.arg(topic)::text = ANY (w.events));
+
+-- name: EnqueueWebhookDeliveriesBatch :exec
+-- The bulk form of EnqueueWebhookDeliveries, for outbox rows just written
+-- by EnqueueOutboxBatch.
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload)
+SELECT w.id, o.id, o.topic, o.payload
+FROM outbox o
+JOIN webhooks w ON w.owner_id = sqlc.arg(owner_id) AND w.active
+  AND (cardinality(w.events) = 0 OR o.topic = ANY (w.events))
+WHERE o.id = ANY (sqlc.arg(event_ids)::bigint[]);
+
+-- name: ListWebhookDeliveries :many
+-- Newest first. The webhook's ownership is checked by the caller.
+SELECT id, webhook_id, event_id, topic, payload, status, attempts, next_attempt_at,
+       last_status_code, last_error, redelivery_of, created_at, delivered_at
+FROM webhook_deliveries
+WHERE webhook_id = sqlc.arg(webhook_id)
+ORDER BY id DESC
+LIMIT sqlc.arg(lim);
+
+-- name: RedeliverWebhookDelivery :one
+-- Queues a fresh copy of an earlier delivery of one of owner's webhooks.
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload, redelivery_of)
+SELECT d.webhook_id, d.event_id, d.topic, d.payload, d.id
+FROM webhook_deliveries d
+JOIN webhooks w ON w.id = d.webhook_id
+WHERE d.id = sqlc.arg(id) AND d.webhook_id = sqlc.arg(webhook_id) AND w.owner_id = sqlc.arg(owner_id)
+RETURNING id, webhook_id, event_id, topic, payload, status, attempts, next_attempt_at,
+          last_status_code, last_error, redelivery_of, created_at, delivered_at;
+
+-- name: ClaimWebhookDeliveries :many
+-- Same leasing scheme as ClaimOutbox.
+UPDATE webhook_deliveries d
+SET attempts = d.attempts + 1,
+    next_attempt_at = sqlc.arg(lease_until)
+FROM webhooks w
+WHERE w.id = d.webhook_id
+  AND d.id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE status = 'pending' AND next_attempt_at <= now()
+    ORDER BY id
+    LIMIT sqlc.arg(lim)
+    FOR UPDATE SKIP LOCKED
+  )
+RETURNING d.id, d.webhook_id, w.url, w.secret, d.event_id, d.topic, d.payload, d.attempts;
+
+-- name: RecordWebhookAttempt :exec
+UPDATE webhook_deliveries
+SET status           = sqlc.arg(status),
+    last_status_code = sqlc.narg(last_status_code),
+    last_error       = sqlc.narg(last_error),
+    next_attempt_at  = sqlc.arg(next_attempt_at),
+    delivered_at     = CASE WHEN sqlc.arg(status)::text = 'delivered' THEN now() END
+WHERE id = sqlc.arg(id);
@@ -0,0 +1,142 @@
+// Package health serves the liveness and readiness probes.
+//
+//   - /livez (and the older /healthz) answers "is the process up?". It never
+//     touches dependencies, so a database outage doesn't get us restarted.
+//   - /readyz answers "should we receive traffic?". It runs every registered
+//     Check (e.g. a Postgres ping) with a short timeout, and also reports
+//     not-ready once graceful shutdown has begun, so load balancers stop
+//     routing to an instance that is draining.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Check probes one dependency. details (may be nil) is included in the
+// /readyz response, e.g. connection pool stats.
+type Check func(ctx context.Context) (details any, err error)
+
+// CheckResult is the per-dependency entry in the /readyz response.
+type CheckResult struct {
+	Status    string `json:"status"` // "up" or "down"
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// Report is the /readyz response body.
+type Report struct {
+	Status   string                 `json:"status"` // "ready" or "not_ready"
+	Draining bool                   `json:"draining"`
+	Checks   map[string]CheckResult `json:"checks"`
+}
+
+// Handler holds the registered checks and the draining flag.
+type Handler struct {
+	timeout  time.Duration
+	draining atomic.Bool
+
+	mu     sync.RWMutex
+	checks map[string]Check
+}
+
+// New returns a Handler whose checks each get at most timeout to answer.
+func New(timeout time.Duration) *Handler {
+	return &Handler{timeout: timeout, checks: map[string]Check{}}
+}
+
+// Add registers a named dependency check for /readyz.
+func (h *Handler) Add(name string, check Check) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks[name] = check
+}
+
+// SetDraining marks the instance as shutting down; /readyz reports
+// not-ready from then on regardless of dependency health.
+func (h *Handler) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Register mounts /livez, /healthz and /readyz on r.
+func (h *Handler) Register(r gin.IRoutes) {
+	live := func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) }
+	r.GET("/livez", live)
+	r.GET("/healthz", live) // kept for existing probes and `make health`
+	r.GET("/readyz", h.ready)
+}
+
+func (h *Handler) ready(c *gin.Context) {
+	rep := h.Run(c.Request.Context())
+	status := http.StatusOK
+	if rep.Status != "ready" {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, rep)
+}
+
+// Run executes all checks concurrently and summarizes the result.
+func (h *Handler) Run(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := make(map[string]Check, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	rep := Report{
+		Status:   "ready",
+		Draining: h.draining.Load(),
+		Checks:   make(map[string]CheckResult, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := h.runOne(ctx, check)
+			mu.Lock()
+			rep.Checks[name] = res
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range rep.Checks {
+		if res.Status != "up" {
+			rep.Status = "not_ready"
+		}
+	}
+	if rep.Draining {
+		rep.Status = "not_ready"
+	}
+	return rep
+}
+
+func (h *Handler) runOne(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check(ctx)
+	res := CheckResult{
+		Status:    "up",
+		LatencyMS: time.Since(start).Milliseconds(),
+		Details:   details,
+	}
+	if err != nil {
+		res.Status = "down"
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newRouter(h *Handler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h.Register(r)
+	return r
+}
+
+func get(t *testing.T, r http.Handler, path string) (int, Report) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var rep Report
+	if path == "/readyz" {
+		if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+			t.Fatalf("json: %v; body=%s", err, w.Body.String())
+		}
+	}
+	return w.Code, rep
+}
+
+func TestReadyz_AllChecksUp(t *testing.T) {
+	h := New(time.Second)
+	h.Add("postgres", func(ctx context.Context) (any, error) {
+		return map[string]int{"total_conns": 1}, nil
+	})
+
+	code, rep := get(t, newRouter(h), "/readyz")
+	if code != http.StatusOK || rep.Status != "ready" {
+		t.Fatalf("expected 200 ready, got %d %+v", code, rep)
+	}
+	if rep.Checks["postgres"].Status != "up" || rep.Checks["postgres"].Details == nil {
+		t.Fatalf("unexpected check result: %+v", rep.Checks["postgres"])
+	}
+}
+
+func TestReadyz_FailingCheckIsNotReadyButLivezIsOK(t *testing.T) {
+	h := New(time.Second)
+	h.Add("postgres", func(ctx context.Context) (any, error) { return nil, errors.New("connection refused") })
+	r := newRouter(h)
+
+	code, rep := get(t, r, "/readyz")
+	if code != http.StatusServiceUnavailable || rep.Status != "not_ready" {
+		t.Fatalf("expected 503 not_ready, got %d %+v", code, rep)
+	}
+	if rep.Checks["postgres"].Error != "connection refused" {
+		t.Fatalf("expected error to be reported, got %+v", rep.Checks["postgres"])
+	}
+
+	for _, path := range []string{"/livez", "/healthz"} {
+		if code, _ := get(t, r, path); code != http.StatusOK {
+			t.Fatalf("%s: expected 200 regardless of dependencies, got %d", path, code)
+		}
+	}
+}
+
+func TestReadyz_CheckTimesOut(t *testing.T) {
+	h := New(20 * time.Millisecond)
+	h.Add("slow", func(ctx context.Context) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	start := time.Now()
+	code, rep := get(t, newRouter(h), "/readyz")
+	if code != http.StatusServiceUnavailable || rep.Checks["slow"].Status != "down" {
+		t.Fatalf("expected timed-out check to be down, got %d %+v", code, rep)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("readiness did not honour the check timeout")
+	}
+}
+
+func TestReadyz_NotReadyWhileDraining(t *testing.T) {
+	h := New(time.Second)
+	h.Add("postgres", func(ctx context.Context) (any, error) { return nil, nil })
+	h.SetDraining()
+
+	code, rep := get(t, newRouter(h), "/readyz")
+	if code != http.StatusServiceUnavailable || !rep.Draining || rep.Status != "not_ready" {
+		t.Fatalf("expected 503 draining, got %d %+v", code, rep)
+	}
+}
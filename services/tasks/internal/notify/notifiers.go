@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/safehttp"
+)
+
+// LogNotifier writes each reminder to Logger as one line. It is the
+// "log" channel, and the default one: handy in dev, and a trail of
+// reminders anywhere else.
+type LogNotifier struct {
+	Logger *log.Logger // nil means log.Default()
+}
+
+// Notify logs r.
+func (n *LogNotifier) Notify(_ context.Context, r Reminder) error {
+	l := n.Logger
+	if l == nil {
+		l = log.Default()
+	}
+	l.Printf("reminder: task %d %q for %s%s", r.TaskID, r.Title, r.OwnerID, dueSuffix(r))
+	return nil
+}
+
+// WebhookNotifier POSTs each reminder as JSON to its address (the owner's
+// webhook_url). Any 2xx response counts as delivered; anything else (or no
+// response) is retried.
+//
+// The reminder ID travels in the X-Reminder-Id header, so receivers can
+// deduplicate redeliveries. The address is user-supplied, so the default
+// client is a safehttp one: no private or local addresses, no redirects.
+type WebhookNotifier struct {
+	Client *http.Client // nil means safehttp.NewClient()
+}
+
+// Notify POSTs r to r.Address.
+func (n *WebhookNotifier) Notify(ctx context.Context, r Reminder) error {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.Address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tasks-reminders/1")
+	req.Header.Set("X-Reminder-Id", strconv.FormatInt(r.ID, 10))
+
+	client := n.Client
+	if client == nil {
+		client = safehttp.NewClient()
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10)) // allow connection reuse
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected response %s", res.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier emails each reminder to its address (the owner's email)
+// through the SMTP server at Addr. It upgrades to TLS whenever the server
+// offers STARTTLS, and authenticates with Auth if set.
+type SMTPNotifier struct {
+	Addr string    // host:port
+	From string    // envelope and header sender
+	Auth smtp.Auth // e.g. smtp.PlainAuth; nil for none
+}
+
+// Notify sends r as a plain-text email.
+func (n *SMTPNotifier) Notify(ctx context.Context, r Reminder) error {
+	host, _, err := net.SplitHostPort(n.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.Addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if n.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp: server does not support AUTH")
+		}
+		if err := c.Auth(n.Auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(n.From); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := c.Rcpt(r.Address); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(n.message(r)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return c.Quit()
+}
+
+// message renders r as an RFC 5322 message. The title is user input, so
+// it only reaches the headers Q-encoded (no line breaks can get through).
+func (n *SMTPNotifier) message(r Reminder) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.From)
+	fmt.Fprintf(&b, "To: %s\r\n", r.Address)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "Reminder: "+r.Title))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "X-Task-Id: %d\r\n", r.TaskID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&b)
+	fmt.Fprintf(qp, "Reminder: %s%s\r\n", r.Title, dueSuffix(r))
+	_ = qp.Close()
+	return b.Bytes()
+}
+
+// dueSuffix is ", due <time>" if r's task has a due date.
+func dueSuffix(r Reminder) string {
+	if r.DueAt == nil {
+		return ""
+	}
+	return ", due " + r.DueAt.UTC().Format(time.RFC1123)
+}
@@ -0,0 +1,223 @@
+// Package notify sends due-date reminders for tasks through pluggable
+// Notifiers: email over SMTP, a webhook, or the service log.
+//
+// Reminders are queued in Postgres by the Worker itself (see
+// Store.EnqueueReminders): once per task and reminder time, so however many
+// replicas run a Worker, and however often, each reminder is queued once.
+// The Worker then claims due reminders, hands each to the Notifier for its
+// channel, and records the outcome: sent, or retried with exponential
+// backoff until MaxAttempts, after which it is marked failed.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Channel is how an owner wants to be reminded (see tasks.ReminderPrefs).
+type Channel string
+
+const (
+	ChannelNone    Channel = "none" // not reminded at all
+	ChannelLog     Channel = "log"
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+)
+
+// Status is the state of a queued reminder.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSent      Status = "sent"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled" // the task was done or trashed first
+)
+
+// Reminder is one claimed reminder, with what is needed to send it.
+type Reminder struct {
+	ID       int64      `json:"id"`
+	TaskID   int32      `json:"task_id"`
+	OwnerID  string     `json:"owner_id"`
+	Title    string     `json:"title"`
+	DueAt    *time.Time `json:"due_at,omitempty"`
+	RemindAt time.Time  `json:"remind_at"`
+	Channel  Channel    `json:"channel"`
+	// Address is where Channel delivers: an email address or a webhook
+	// URL, empty for ChannelLog.
+	Address string `json:"-"`
+	// Attempts counts delivery attempts, including the current one.
+	Attempts int32 `json:"-"`
+}
+
+// Notifier delivers a reminder. A nil error means it was delivered and
+// will not be offered again.
+type Notifier interface {
+	Notify(ctx context.Context, r Reminder) error
+}
+
+// NotifierFunc adapts a plain function to the Notifier interface.
+type NotifierFunc func(ctx context.Context, r Reminder) error
+
+// Notify calls f(ctx, r).
+func (f NotifierFunc) Notify(ctx context.Context, r Reminder) error { return f(ctx, r) }
+
+// Result is the outcome of one attempt.
+type Result struct {
+	Status        Status
+	Error         string // empty once sent
+	NextAttemptAt time.Time
+}
+
+// Store is the persistence the Worker needs; tasks.Repo implements it.
+type Store interface {
+	// EnqueueReminders queues the reminders that fell due in (since, now]
+	// and were not queued yet, and reports how many it queued.
+	EnqueueReminders(ctx context.Context, since, now time.Time) (int64, error)
+	// ClaimReminders leases up to limit due pending reminders until
+	// leaseUntil and counts the attempt. Reminders for tasks that have been
+	// done or trashed since they were queued are cancelled instead.
+	ClaimReminders(ctx context.Context, limit int, leaseUntil time.Time) ([]Reminder, error)
+	RecordReminderAttempt(ctx context.Context, id int64, r Result) error
+}
+
+// Options tune the Worker. Zero values select the defaults noted below.
+type Options struct {
+	BatchSize    int           // reminders claimed per poll (50)
+	PollInterval time.Duration // wait between polls when idle (30s)
+	// Window bounds how late a reminder may be queued: one that fell due
+	// longer ago than this (say, while no replica was running) is skipped
+	// rather than sent stale (24h).
+	Window      time.Duration
+	Lease       time.Duration // how long a claimed batch is ours (5m)
+	Timeout     time.Duration // per-reminder deadline for the Notifier (10s)
+	MinBackoff  time.Duration // first retry delay, doubled per attempt (1m)
+	MaxBackoff  time.Duration // cap on the retry delay (1h)
+	MaxAttempts int32         // attempts before a reminder is marked failed (5)
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 50
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 30 * time.Second
+	}
+	if o.Window <= 0 {
+		o.Window = 24 * time.Hour
+	}
+	if o.Lease <= 0 {
+		o.Lease = 5 * time.Minute
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Minute
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	return o
+}
+
+// Worker queues and sends reminders from a Store.
+type Worker struct {
+	store     Store
+	notifiers map[Channel]Notifier
+	opts      Options
+	now       func() time.Time
+}
+
+// NewWorker returns a Worker sending reminders from store through the
+// Notifier for each one's channel. A reminder on a channel without one
+// fails, e.g. email when no SMTP server is configured.
+func NewWorker(store Store, notifiers map[Channel]Notifier, opts Options) *Worker {
+	return &Worker{store: store, notifiers: notifiers, opts: opts.withDefaults(), now: time.Now}
+}
+
+// Run sends reminders until ctx is cancelled. Store errors are logged and
+// retried on the next poll.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		n, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("notify: %v", err)
+		}
+		if err == nil && n == w.opts.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// RunOnce queues the reminders that have fallen due, then claims one batch
+// of due reminders and attempts each one, recording the results. It
+// returns how many reminders it claimed.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	now := w.now()
+	if _, err := w.store.EnqueueReminders(ctx, now.Add(-w.opts.Window), now); err != nil {
+		return 0, err
+	}
+	rs, err := w.store.ClaimReminders(ctx, w.opts.BatchSize, now.Add(w.opts.Lease))
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range rs {
+		res := w.attempt(ctx, r)
+		if err := w.store.RecordReminderAttempt(ctx, r.ID, res); err != nil {
+			return len(rs), err
+		}
+	}
+	return len(rs), nil
+}
+
+// attempt sends r once and decides what happens next.
+func (w *Worker) attempt(ctx context.Context, r Reminder) Result {
+	err := w.notify(ctx, r)
+	now := w.now()
+	if err == nil {
+		return Result{Status: StatusSent, NextAttemptAt: now}
+	}
+	res := Result{Status: StatusPending, Error: err.Error()}
+	if r.Attempts >= w.opts.MaxAttempts {
+		res.Status = StatusFailed
+		res.NextAttemptAt = now
+		log.Printf("notify: reminder %d for task %d failed after %d attempts: %v", r.ID, r.TaskID, r.Attempts, err)
+		return res
+	}
+	res.NextAttemptAt = now.Add(w.backoff(r.Attempts))
+	return res
+}
+
+func (w *Worker) notify(ctx context.Context, r Reminder) error {
+	n, ok := w.notifiers[r.Channel]
+	if !ok {
+		return fmt.Errorf("no notifier for channel %q", r.Channel)
+	}
+	ctx, cancel := context.WithTimeout(ctx, w.opts.Timeout)
+	defer cancel()
+	return n.Notify(ctx, r)
+}
+
+// backoff is the delay before retrying after the given attempt:
+// MinBackoff doubled for each earlier attempt, capped at MaxBackoff.
+func (w *Worker) backoff(attempt int32) time.Duration {
+	d := w.opts.MinBackoff
+	for i := int32(1); i < attempt && d < w.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.opts.MaxBackoff)
+}
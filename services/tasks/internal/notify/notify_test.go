@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/safehttp"
+)
+
+// fakeSMTP is a minimal SMTP server on a local port that accepts every
+// message and keeps it, for testing SMTPNotifier end to end.
+type fakeSMTP struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	msgs []smtpMessage
+}
+
+type smtpMessage struct {
+	from, to string
+	data     []byte
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeSMTP) messages() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.msgs...)
+}
+
+// serve speaks just enough of RFC 5321 for net/smtp: no extensions, so no
+// STARTTLS or AUTH.
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	reply := func(line string) {
+		rw.WriteString(line + "\r\n")
+		rw.Flush()
+	}
+	reply("220 fake ESMTP")
+	var m smtpMessage
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.Fields(cmd + " x")[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 fake")
+		case "MAIL":
+			m = smtpMessage{from: cmd}
+			reply("250 ok")
+		case "RCPT":
+			m.to = cmd
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data bytes.Buffer
+			for {
+				l, err := rw.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			m.data = data.Bytes()
+			s.mu.Lock()
+			s.msgs = append(s.msgs, m)
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier_SendsEmail(t *testing.T) {
+	srv := newFakeSMTP(t)
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	n := &SMTPNotifier{Addr: srv.addr(), From: "tasks@example.com"}
+
+	r := Reminder{ID: 1, TaskID: 7, Title: "Pay rent\r\nBcc: x@evil.test — ünïcode", DueAt: &due, Address: "ann@example.com"}
+	if err := n.Notify(context.Background(), r); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	msgs := srv.messages()
+	if len(msgs) != 1 {
+		t.Fatalf("expected one message, got %d", len(msgs))
+	}
+	if got := msgs[0]; got.from != "MAIL FROM:<tasks@example.com>" || got.to != "RCPT TO:<ann@example.com>" {
+		t.Fatalf("envelope: %q %q", got.from, got.to)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(msgs[0].data))
+	if err != nil {
+		t.Fatalf("parse message: %v", err)
+	}
+	if msg.Header.Get("Bcc") != "" || msg.Header.Get("X-Task-Id") != "7" {
+		t.Fatalf("headers: %v", msg.Header)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "Reminder: "+r.Title {
+		t.Fatalf("subject: %q %v", subject, err)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if !strings.Contains(string(body), "due Fri, 01 May 2026 09:00:00 UTC") {
+		t.Fatalf("body: %q", body)
+	}
+}
+
+func TestSMTPNotifier_ConnectionRefused(t *testing.T) {
+	srv := newFakeSMTP(t)
+	addr := srv.addr()
+	srv.ln.Close()
+
+	n := &SMTPNotifier{Addr: addr, From: "tasks@example.com"}
+	if err := n.Notify(context.Background(), Reminder{Address: "ann@example.com"}); err == nil {
+		t.Fatalf("expected an error with no server listening")
+	}
+}
+
+func TestWebhookNotifier_PostsJSON(t *testing.T) {
+	var got Reminder
+	var id string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = r.Header.Get("X-Reminder-Id")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	n := &WebhookNotifier{Client: srv.Client()}
+	r := Reminder{ID: 9, TaskID: 3, OwnerID: "ann", Title: "Call", RemindAt: time.Unix(1_700_000_000, 0).UTC(), Channel: ChannelWebhook, Address: srv.URL}
+	if err := n.Notify(context.Background(), r); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if id != "9" || got.TaskID != 3 || got.Title != "Call" || !got.RemindAt.Equal(r.RemindAt) || got.Address != "" {
+		t.Fatalf("unexpected request: id=%q %+v", id, got)
+	}
+
+	status = http.StatusBadGateway
+	if err := n.Notify(context.Background(), r); err == nil {
+		t.Fatalf("expected an error for a 502")
+	}
+}
+
+func TestWebhookNotifier_RefusesPrivateAddresses(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+
+	n := &WebhookNotifier{} // the default client; srv is on loopback
+	err := n.Notify(context.Background(), Reminder{ID: 1, Channel: ChannelWebhook, Address: srv.URL})
+	if !errors.Is(err, safehttp.ErrBlocked) || hits != 0 {
+		t.Fatalf("expected safehttp.ErrBlocked without a request, got %v after %d requests", err, hits)
+	}
+}
+
+func TestLogNotifier(t *testing.T) {
+	var buf bytes.Buffer
+	n := &LogNotifier{Logger: log.New(&buf, "", 0)}
+	if err := n.Notify(context.Background(), Reminder{TaskID: 4, Title: "Water plants", OwnerID: "ann"}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got := buf.String(); got != "reminder: task 4 \"Water plants\" for ann\n" {
+		t.Fatalf("logged %q", got)
+	}
+}
+
+// memStore queues the reminders it is given once each and records the
+// results.
+type memStore struct {
+	mu       sync.Mutex
+	due      []Reminder
+	pending  []Reminder
+	results  map[int64][]Result
+	enqueued [][2]time.Time
+}
+
+func (s *memStore) EnqueueReminders(_ context.Context, since, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enqueued = append(s.enqueued, [2]time.Time{since, now})
+	n := len(s.due)
+	s.pending, s.due = append(s.pending, s.due...), nil
+	return int64(n), nil
+}
+
+func (s *memStore) ClaimReminders(_ context.Context, limit int, _ time.Time) ([]Reminder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.pending))
+	out := s.pending[:n]
+	s.pending = s.pending[n:]
+	return out, nil
+}
+
+func (s *memStore) RecordReminderAttempt(_ context.Context, id int64, r Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.results == nil {
+		s.results = map[int64][]Result{}
+	}
+	s.results[id] = append(s.results[id], r)
+	return nil
+}
+
+func TestWorker_DispatchesByChannel(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	var sent []string
+	record := func(name string) Notifier {
+		return NotifierFunc(func(_ context.Context, r Reminder) error {
+			sent = append(sent, name+":"+r.Address)
+			return nil
+		})
+	}
+	store := &memStore{due: []Reminder{
+		{ID: 1, Channel: ChannelEmail, Address: "ann@example.com", Attempts: 1},
+		{ID: 2, Channel: ChannelLog, Attempts: 1},
+		{ID: 3, Channel: ChannelWebhook, Address: "https://example.com/hook", Attempts: 1},
+	}}
+	w := NewWorker(store, map[Channel]Notifier{ChannelEmail: record("email"), ChannelLog: record("log")}, Options{})
+	w.now = func() time.Time { return now }
+
+	if n, err := w.RunOnce(context.Background()); err != nil || n != 3 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if want := []string{"email:ann@example.com", "log:"}; strings.Join(sent, ",") != strings.Join(want, ",") {
+		t.Fatalf("sent %v, want %v", sent, want)
+	}
+	if got := store.enqueued; len(got) != 1 || !got[0][0].Equal(now.Add(-24*time.Hour)) || !got[0][1].Equal(now) {
+		t.Fatalf("enqueue window: %v", got)
+	}
+	for id, want := range map[int64]Status{1: StatusSent, 2: StatusSent, 3: StatusPending} {
+		if got := store.results[id]; len(got) != 1 || got[0].Status != want {
+			t.Fatalf("reminder %d: got %+v, want %s", id, got, want)
+		}
+	}
+	// No webhook notifier: retried after MinBackoff.
+	if got := store.results[3][0]; !strings.Contains(got.Error, "no notifier") || !got.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("reminder 3: %+v", got)
+	}
+}
+
+func TestWorker_BacksOffThenFails(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	failing := NotifierFunc(func(context.Context, Reminder) error { return errors.New("mailbox full") })
+	store := &memStore{due: []Reminder{
+		{ID: 1, Channel: ChannelEmail, Attempts: 3},
+		{ID: 2, Channel: ChannelEmail, Attempts: 5},
+	}}
+	w := NewWorker(store, map[Channel]Notifier{ChannelEmail: failing}, Options{MinBackoff: time.Minute, MaxBackoff: 3 * time.Minute})
+	w.now = func() time.Time { return now }
+
+	if _, err := w.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	// Attempt 3 waits 1m doubled twice, capped at 3m.
+	if got := store.results[1][0]; got.Status != StatusPending || got.Error != "mailbox full" || !got.NextAttemptAt.Equal(now.Add(3*time.Minute)) {
+		t.Fatalf("reminder 1: %+v", got)
+	}
+	if got := store.results[2][0]; got.Status != StatusFailed {
+		t.Fatalf("reminder 2: expected failed after MaxAttempts, got %+v", got)
+	}
+}
@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSSink publishes each message's payload on the subject Prefix+Topic,
+// e.g. "tasks." + "task.created".
+//
+// The message ID is sent in the Nats-Msg-Id header, which JetStream uses to
+// drop redeliveries within its duplicate window.
+type NATSSink struct {
+	Conn   *nats.Conn
+	Prefix string
+}
+
+// NewNATSSink connects to the NATS server at url.
+func NewNATSSink(url, prefix string) (*NATSSink, error) {
+	nc, err := nats.Connect(url, nats.Name("tasks-outbox"))
+	if err != nil {
+		return nil, err
+	}
+	return &NATSSink{Conn: nc, Prefix: prefix}, nil
+}
+
+// Publish sends m and waits for the server to acknowledge receipt (a
+// flush round-trip), so a dropped connection is reported and retried.
+func (s *NATSSink) Publish(ctx context.Context, m Message) error {
+	msg := nats.NewMsg(s.Prefix + m.Topic)
+	msg.Data = m.Payload
+	msg.Header.Set(nats.MsgIdHdr, strconv.FormatInt(m.ID, 10))
+	if err := s.Conn.PublishMsg(msg); err != nil {
+		return err
+	}
+	return s.Conn.FlushWithContext(ctx)
+}
+
+// Close drains and closes the NATS connection.
+func (s *NATSSink) Close() {
+	_ = s.Conn.Drain()
+}
@@ -0,0 +1,157 @@
+// Package outbox relays domain events from the Postgres outbox table to an
+// external Sink (a webhook, NATS, stdout, ...).
+//
+// Writers insert an event into the outbox in the same transaction as the
+// change it describes (see tasks.Repo), so an event is recorded if and only
+// if the change commits. The Relay then polls for pending messages and
+// publishes them. Delivery is at-least-once: a message is marked delivered
+// only after the sink accepts it, and a relay that crashes in between will
+// publish it again. Consumers should therefore deduplicate on Message.ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// Message is one outbox row as handed to a Sink.
+type Message struct {
+	ID        int64           `json:"id"`
+	Topic     string          `json:"topic"` // e.g. "task.created"
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// Attempts counts delivery attempts, including the current one.
+	Attempts int32 `json:"-"`
+}
+
+// Sink publishes messages somewhere outside this service. A nil error means
+// the message was accepted and will not be offered again.
+type Sink interface {
+	Publish(ctx context.Context, m Message) error
+}
+
+// SinkFunc adapts a plain function to the Sink interface.
+type SinkFunc func(ctx context.Context, m Message) error
+
+// Publish calls f(ctx, m).
+func (f SinkFunc) Publish(ctx context.Context, m Message) error { return f(ctx, m) }
+
+// Store is the persistence the Relay needs; tasks.Repo implements it.
+type Store interface {
+	// ClaimOutbox leases up to limit due messages until leaseUntil, so other
+	// relays skip them, and counts the attempt.
+	ClaimOutbox(ctx context.Context, limit int, leaseUntil time.Time) ([]Message, error)
+	MarkOutboxDelivered(ctx context.Context, id int64) error
+	// MarkOutboxFailed records why delivery failed and when to retry.
+	MarkOutboxFailed(ctx context.Context, id int64, reason string, retryAt time.Time) error
+}
+
+// Options tune the Relay. Zero values select the defaults noted below.
+type Options struct {
+	BatchSize      int           // messages claimed per poll (100)
+	PollInterval   time.Duration // wait between polls when idle (1s)
+	Lease          time.Duration // how long a claimed batch is ours (1m)
+	PublishTimeout time.Duration // per-message deadline for the sink (10s)
+	MinBackoff     time.Duration // first retry delay, doubled per attempt (1s)
+	MaxBackoff     time.Duration // cap on the retry delay (5m)
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = time.Minute
+	}
+	if o.PublishTimeout <= 0 {
+		o.PublishTimeout = 10 * time.Second
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = 5 * time.Minute
+	}
+	return o
+}
+
+// Relay moves messages from a Store to a Sink.
+type Relay struct {
+	store Store
+	sink  Sink
+	opts  Options
+	now   func() time.Time
+}
+
+// NewRelay returns a Relay delivering from store to sink.
+func NewRelay(store Store, sink Sink, opts Options) *Relay {
+	return &Relay{store: store, sink: sink, opts: opts.withDefaults(), now: time.Now}
+}
+
+// Run delivers messages until ctx is cancelled. Store errors (e.g. the
+// database being down) are logged and retried on the next poll.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		n, err := r.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("outbox: %v", err)
+		}
+		// A full batch suggests a backlog: go straight back for more.
+		if err == nil && n == r.opts.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(r.opts.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims one batch of due messages and offers each to the sink,
+// recording the outcome. It returns how many messages it claimed.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	msgs, err := r.store.ClaimOutbox(ctx, r.opts.BatchSize, r.now().Add(r.opts.Lease))
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range msgs {
+		if err := r.deliver(ctx, m); err != nil {
+			return len(msgs), err
+		}
+	}
+	return len(msgs), nil
+}
+
+// deliver publishes m and records the result. Only a failure to record is
+// returned; a sink failure is bookkeeping, not an error of the relay.
+func (r *Relay) deliver(ctx context.Context, m Message) error {
+	pctx, cancel := context.WithTimeout(ctx, r.opts.PublishTimeout)
+	err := r.sink.Publish(pctx, m)
+	cancel()
+	if err == nil {
+		return r.store.MarkOutboxDelivered(ctx, m.ID)
+	}
+	retryAt := r.now().Add(r.backoff(m.Attempts))
+	log.Printf("outbox: deliver %s message %d (attempt %d): %v; retrying at %s",
+		m.Topic, m.ID, m.Attempts, err, retryAt.Format(time.RFC3339))
+	return r.store.MarkOutboxFailed(ctx, m.ID, err.Error(), retryAt)
+}
+
+// backoff is the delay before retrying after the given attempt:
+// MinBackoff doubled for each earlier attempt, capped at MaxBackoff.
+func (r *Relay) backoff(attempt int32) time.Duration {
+	d := r.opts.MinBackoff
+	for i := int32(1); i < attempt && d < r.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.opts.MaxBackoff)
+}
@@ -0,0 +1,201 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStore is an in-memory Store with the same claim/lease semantics as
+// the outbox table.
+type memStore struct {
+	mu   sync.Mutex
+	now  time.Time
+	rows []*memRow
+}
+
+type memRow struct {
+	msg       Message
+	nextAt    time.Time
+	lastError string
+	delivered bool
+}
+
+func (s *memStore) add(topic, payload string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := int64(len(s.rows) + 1)
+	s.rows = append(s.rows, &memRow{
+		msg:    Message{ID: id, Topic: topic, Payload: json.RawMessage(payload), CreatedAt: s.now},
+		nextAt: s.now,
+	})
+}
+
+func (s *memStore) ClaimOutbox(_ context.Context, limit int, leaseUntil time.Time) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Message
+	for _, r := range s.rows {
+		if len(out) == limit {
+			break
+		}
+		if r.delivered || r.nextAt.After(s.now) {
+			continue
+		}
+		r.msg.Attempts++
+		r.nextAt = leaseUntil
+		out = append(out, r.msg)
+	}
+	return out, nil
+}
+
+func (s *memStore) MarkOutboxDelivered(_ context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows[id-1].delivered = true
+	s.rows[id-1].lastError = ""
+	return nil
+}
+
+func (s *memStore) MarkOutboxFailed(_ context.Context, id int64, reason string, retryAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rows[id-1].lastError = reason
+	s.rows[id-1].nextAt = retryAt
+	return nil
+}
+
+func newTestRelay(store *memStore, sink Sink) *Relay {
+	r := NewRelay(store, sink, Options{BatchSize: 10, MinBackoff: time.Second, MaxBackoff: 4 * time.Second})
+	r.now = func() time.Time { return store.now }
+	return r
+}
+
+func TestRelay_DeliversInOrderOnce(t *testing.T) {
+	store := &memStore{now: time.Unix(1_700_000_000, 0)}
+	store.add("task.created", `{"n":1}`)
+	store.add("task.updated", `{"n":2}`)
+	sink := &MemorySink{}
+	relay := newTestRelay(store, sink)
+
+	if n, err := relay.RunOnce(context.Background()); err != nil || n != 2 {
+		t.Fatalf("RunOnce = %d, %v; want 2, nil", n, err)
+	}
+	got := sink.Messages()
+	if len(got) != 2 || got[0].Topic != "task.created" || string(got[1].Payload) != `{"n":2}` {
+		t.Fatalf("unexpected messages: %+v", got)
+	}
+
+	// Delivered messages are not offered again, even after their lease.
+	store.now = store.now.Add(time.Hour)
+	if n, _ := relay.RunOnce(context.Background()); n != 0 || len(sink.Messages()) != 2 {
+		t.Fatalf("expected no redelivery, claimed %d, sink has %d", n, len(sink.Messages()))
+	}
+}
+
+func TestRelay_RetriesWithBackoff(t *testing.T) {
+	store := &memStore{now: time.Unix(1_700_000_000, 0)}
+	store.add("task.created", `{}`)
+
+	var calls int
+	sink := SinkFunc(func(context.Context, Message) error {
+		calls++
+		if calls < 4 {
+			return errors.New("sink down")
+		}
+		return nil
+	})
+	relay := newTestRelay(store, sink)
+	ctx := context.Background()
+
+	// 1s, 2s, 4s, then capped: the message is only due again after each delay.
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if _, err := relay.RunOnce(ctx); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+		row := store.rows[0]
+		if row.delivered || row.lastError != "sink down" || !row.nextAt.Equal(store.now.Add(wait)) {
+			t.Fatalf("after attempt %d: %+v, want retry in %s", row.msg.Attempts, row, wait)
+		}
+		if n, _ := relay.RunOnce(ctx); n != 0 {
+			t.Fatalf("message offered again before its retry time")
+		}
+		store.now = store.now.Add(wait)
+	}
+	if _, err := relay.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if row := store.rows[0]; !row.delivered || row.msg.Attempts != 4 || row.lastError != "" {
+		t.Fatalf("expected delivery on the 4th attempt, got %+v", row)
+	}
+}
+
+func TestRelay_RunStopsWithContext(t *testing.T) {
+	store := &memStore{now: time.Now()}
+	store.add("task.created", `{}`)
+	sink := &MemorySink{}
+	relay := NewRelay(store, sink, Options{PollInterval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	deadline := time.After(2 * time.Second)
+	for len(sink.Messages()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatalf("message not relayed")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var gotBody []byte
+	var gotID, gotTopic string
+	status := http.StatusNoContent
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(r.Body)
+		gotBody, gotID, gotTopic = buf.Bytes(), r.Header.Get("X-Event-Id"), r.Header.Get("X-Event-Topic")
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := &WebhookSink{URL: srv.URL}
+	m := Message{ID: 42, Topic: "task.created", Payload: json.RawMessage(`{"type":"created"}`)}
+	if err := sink.Publish(context.Background(), m); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if string(gotBody) != `{"type":"created"}` || gotID != "42" || gotTopic != "task.created" {
+		t.Fatalf("unexpected request: body=%s id=%s topic=%s", gotBody, gotID, gotTopic)
+	}
+
+	status = http.StatusBadGateway
+	if err := sink.Publish(context.Background(), m); err == nil {
+		t.Fatalf("expected an error for a non-2xx response")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+	m := Message{ID: 7, Topic: "task.deleted", Payload: json.RawMessage(`{"type":"deleted"}`), CreatedAt: time.Unix(0, 0).UTC()}
+	if err := sink.Publish(context.Background(), m); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	want := `{"id":7,"topic":"task.deleted","payload":{"type":"deleted"},"created_at":"1970-01-01T00:00:00Z"}` + "\n"
+	if buf.String() != want {
+		t.Fatalf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,94 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// WriterSink writes each message to W as one line of JSON. With os.Stdout it
+// is the "stdout" sink: handy in dev, or to feed a log shipper.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a Sink that writes JSON lines to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Publish writes m as a JSON line.
+func (s *WriterSink) Publish(_ context.Context, m Message) error {
+	line, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// WebhookSink POSTs each message's payload to URL. Any 2xx response counts
+// as delivered; anything else (or no response) is retried.
+//
+// The message ID and topic travel in the X-Event-Id and X-Event-Topic
+// headers, so receivers can deduplicate redeliveries.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client // nil means http.DefaultClient
+}
+
+// Publish POSTs m.Payload to the webhook URL.
+func (s *WebhookSink) Publish(ctx context.Context, m Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(m.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", strconv.FormatInt(m.ID, 10))
+	req.Header.Set("X-Event-Topic", m.Topic)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10)) // allow connection reuse
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook %s: %s", s.URL, res.Status)
+	}
+	return nil
+}
+
+// MemorySink keeps published messages in memory. It is meant for tests and
+// for running the relay in-process without external infrastructure.
+type MemorySink struct {
+	mu   sync.Mutex
+	msgs []Message
+}
+
+// Publish records m.
+func (s *MemorySink) Publish(_ context.Context, m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.msgs = append(s.msgs, m)
+	return nil
+}
+
+// Messages returns a copy of everything published so far, in order.
+func (s *MemorySink) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.msgs...)
+}
@@ -0,0 +1,71 @@
+// Package requestid tags every request with an ID, so that what a request
+// did (log lines, audit entries; see tasks.AuditEntry) can be traced back to
+// it, and to the client or gateway log that has the same ID.
+//
+// The ID is the request's X-Request-Id header if it has a sane one (Kong's
+// correlation-id plugin can be set to forward one), or a random one
+// otherwise. Either way it is echoed back in the response's X-Request-Id.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header carries the request ID, both ways.
+const Header = "X-Request-Id"
+
+// maxLen bounds a client-supplied ID; longer ones are replaced.
+const maxLen = 128
+
+type ctxKey struct{}
+
+// WithID returns a copy of ctx carrying id.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the ID stored by the middleware, or "" if none (e.g.
+// background jobs).
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// New returns a random ID: 16 bytes, hex-encoded.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // never fails (see crypto/rand)
+	return hex.EncodeToString(b[:])
+}
+
+// Middleware stores the request's ID in its context (FromContext) and sets
+// it on the response.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = New()
+		}
+		c.Header(Header, id)
+		c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// valid reports whether a client-supplied ID is safe to store and log: not
+// empty, not too long, and only printable ASCII without spaces.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
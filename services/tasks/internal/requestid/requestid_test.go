@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Middleware())
+	r.GET("/id", func(c *gin.Context) {
+		c.String(http.StatusOK, FromContext(c.Request.Context()))
+	})
+
+	get := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/id", nil)
+		if header != "" {
+			req.Header.Set(Header, header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := get("abc-123"); w.Body.String() != "abc-123" || w.Header().Get(Header) != "abc-123" {
+		t.Fatalf("expected the client's ID, got %q / %q", w.Body.String(), w.Header().Get(Header))
+	}
+	for _, bad := range []string{"", "has space", "tab\there", strings.Repeat("x", maxLen+1), "ünï"} {
+		w := get(bad)
+		if id := w.Body.String(); len(id) != 32 || id == bad || w.Header().Get(Header) != id {
+			t.Fatalf("%q: expected a generated ID, got %q / %q", bad, id, w.Header().Get(Header))
+		}
+	}
+	if a, b := get("").Body.String(), get("").Body.String(); a == b {
+		t.Fatalf("generated IDs must differ, got %q twice", a)
+	}
+}
+
+func TestFromContext_Empty(t *testing.T) {
+	if id := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context()); id != "" {
+		t.Fatalf("expected no ID, got %q", id)
+	}
+}
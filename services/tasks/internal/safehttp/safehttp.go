@@ -0,0 +1,106 @@
+// Package safehttp makes requests to URLs users supply (webhooks, reminder
+// webhooks) without letting those URLs reach into our own network.
+//
+// NewClient's client refuses to connect to loopback, link-local, private or
+// unspecified addresses, whatever the URL's host resolves to at the time, and
+// doesn't follow redirects. CheckURL catches the obvious cases (an IP literal,
+// localhost) early, when the URL is registered.
+package safehttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrBlocked is returned for a request to, or a URL naming, an address
+// Blocked refuses.
+var ErrBlocked = errors.New("destination address not allowed")
+
+// Blocked reports whether requests to ip are refused.
+func Blocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return !ip.IsValid() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsPrivate() || ip.IsUnspecified()
+}
+
+// CheckURL returns ErrBlocked if u's host is localhost or a blocked IP
+// literal. Other host names are checked when the client dials them.
+func CheckURL(u *url.URL) error {
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlocked
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && Blocked(ip) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// NewClient returns a client that won't connect to blocked addresses, uses
+// no proxy (which would connect on its behalf), and returns 3xx responses
+// as they are rather than following them.
+func NewClient() *http.Client {
+	d := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: control}
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext:           d.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// control vets the address a connection is about to be made to, after name
+// resolution, so a host name can't smuggle a blocked address past CheckURL.
+func control(_, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if Blocked(ap.Addr()) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// ErrorClass names the kind of failure err is, for records the URL's owner
+// can see: unlike err itself, it reveals nothing about our network (which
+// addresses exist, what answers on them).
+func ErrorClass(err error) string {
+	var (
+		dnsErr  *net.DNSError
+		netErr  net.Error
+		certErr *tls.CertificateVerificationError
+		authErr x509.UnknownAuthorityError
+		hostErr x509.HostnameError
+	)
+	switch {
+	case errors.Is(err, ErrBlocked):
+		return "blocked_address"
+	case errors.As(err, &dnsErr):
+		return "dns_error"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &certErr), errors.As(err, &authErr), errors.As(err, &hostErr):
+		return "tls_error"
+	case errors.As(err, &netErr):
+		return "connection_failed"
+	default:
+		return "request_failed"
+	}
+}
@@ -0,0 +1,108 @@
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBlocked(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"127.0.0.1":          true,
+		"::1":                true,
+		"10.1.2.3":           true,
+		"172.16.0.1":         true,
+		"192.168.1.1":        true,
+		"169.254.169.254":    true,
+		"fe80::1":            true,
+		"fd00::1":            true,
+		"0.0.0.0":            true,
+		"::":                 true,
+		"::ffff:127.0.0.1":   true,
+		"93.184.216.34":      false,
+		"2606:4700::6810:85": false,
+	} {
+		if got := Blocked(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("Blocked(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for raw, want := range map[string]error{
+		"https://example.com/hook":      nil,
+		"https://93.184.216.34/hook":    nil,
+		"http://localhost:8080/":        ErrBlocked,
+		"http://LocalHost./":            ErrBlocked,
+		"http://api.localhost/":         ErrBlocked,
+		"http://127.0.0.1/":             ErrBlocked,
+		"http://[::1]:9000/":            ErrBlocked,
+		"http://169.254.169.254/latest": ErrBlocked,
+		"http://10.0.0.5/":              ErrBlocked,
+	} {
+		u, err := url.Parse(raw)
+		if err != nil {
+			t.Fatalf("url.Parse(%q): %v", raw, err)
+		}
+		if err := CheckURL(u); !errors.Is(err, want) {
+			t.Errorf("CheckURL(%q) = %v, want %v", raw, err, want)
+		}
+	}
+}
+
+func TestClient_RefusesBlockedAddresses(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+
+	_, err := NewClient().Get(srv.URL) // 127.0.0.1
+	if !errors.Is(err, ErrBlocked) || hits != 0 {
+		t.Fatalf("expected ErrBlocked without a request, got %v after %d requests", err, hits)
+	}
+	if c := ErrorClass(err); c != "blocked_address" {
+		t.Fatalf("ErrorClass = %q", c)
+	}
+}
+
+func TestClient_DoesNotFollowRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/start" {
+			http.Redirect(w, r, "/internal", http.StatusFound)
+			return
+		}
+		t.Errorf("redirect followed to %s", r.URL)
+	}))
+	defer srv.Close()
+
+	// The test server is on loopback, so keep the redirect policy but
+	// swap in a transport that may reach it.
+	c := NewClient()
+	c.Transport = srv.Client().Transport
+	res, err := c.Get(srv.URL + "/start")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusFound {
+		t.Fatalf("expected the 302 itself, got %d", res.StatusCode)
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com", nil)
+	_, err := NewClient().Do(req)
+	if c := ErrorClass(err); c != "timeout" {
+		t.Fatalf("deadline exceeded: ErrorClass = %q (%v)", c, err)
+	}
+	if c := ErrorClass(errors.New("secret internal detail")); c != "request_failed" {
+		t.Fatalf("other error: ErrorClass = %q", c)
+	}
+}
@@ -0,0 +1,305 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/auth"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/requestid"
+)
+
+// SystemActor is the actor of changes no caller made, e.g. occurrences
+// created by the scheduler (see RunScheduler).
+const SystemActor = "system"
+
+// AuditEntry is one change to a task in the audit log. Every mutation
+// writes one per task it changes, in the same transaction (see
+// recordChange), so the log holds exactly the changes that were committed.
+type AuditEntry struct {
+	ID     int64     `json:"id"`
+	TaskID int32     `json:"task_id"`
+	Action EventType `json:"action"`
+	// Actor is the subject of the caller who made the change, or
+	// SystemActor.
+	Actor string `json:"actor"`
+	// RequestID is the X-Request-Id of the request that made the change
+	// (see internal/requestid), empty for changes made outside a request.
+	RequestID string `json:"request_id,omitempty"`
+	// Before and After hold the task fields that changed (in Task's JSON
+	// form), with their old and new values. Before is null on creation,
+	// when After is the whole task.
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+	At     time.Time       `json:"at"`
+}
+
+// AuditFilter narrows and pages an audit log query. Entries come newest
+// first.
+type AuditFilter struct {
+	TaskID    *int32
+	Actor     string
+	Action    EventType // EventCreated, EventUpdated or EventDeleted
+	RequestID string
+	Since     *time.Time // at or after
+	Until     *time.Time // before
+	Limit     int        // max entries per page; 0 means DefaultPageSize
+	Cursor    string     // opaque next_cursor from a previous page
+}
+
+// AuditPage is one page of the audit log. NextCursor is nil on the last
+// page.
+type AuditPage struct {
+	Items      []AuditEntry `json:"items"`
+	NextCursor *string      `json:"next_cursor"`
+}
+
+func (f AuditFilter) normalize() (AuditFilter, error) {
+	switch f.Action {
+	case "", EventCreated, EventUpdated, EventDeleted:
+	default:
+		return f, invalidParam("action")
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit < 1 || f.Limit > MaxPageSize {
+		return f, invalidParam("limit")
+	}
+	return f, nil
+}
+
+// Audit returns a page of the audit log of the caller's tasks, purged ones
+// included.
+func (s *Service) Audit(ctx context.Context, f AuditFilter) (AuditPage, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return AuditPage{}, err
+	}
+	if f, err = f.normalize(); err != nil {
+		return AuditPage{}, err
+	}
+	return s.repo.Audit(ctx, owner, f)
+}
+
+// Audit returns a page of owner's audit log matching f.
+func (r *Repo) Audit(ctx context.Context, owner string, f AuditFilter) (AuditPage, error) {
+	params := gen.ListTaskAuditParams{OwnerID: owner, Lim: int32(f.Limit) + 1}
+	if f.TaskID != nil {
+		params.TaskID = pgtype.Int4{Int32: *f.TaskID, Valid: true}
+	}
+	params.Actor = pgtype.Text{String: f.Actor, Valid: f.Actor != ""}
+	params.Action = pgtype.Text{String: string(f.Action), Valid: f.Action != ""}
+	params.RequestID = pgtype.Text{String: f.RequestID, Valid: f.RequestID != ""}
+	params.Since, params.Until = timestamptz(f.Since), timestamptz(f.Until)
+	if f.Cursor != "" {
+		id, err := decodeAuditCursor(f.Cursor)
+		if err != nil {
+			return AuditPage{}, err
+		}
+		params.BeforeID = pgtype.Int8{Int64: id, Valid: true}
+	}
+
+	rows, err := r.qry.ListTaskAudit(ctx, params)
+	if err != nil {
+		return AuditPage{}, dbError(err, "audit")
+	}
+	page := AuditPage{}
+	if len(rows) > f.Limit {
+		rows = rows[:f.Limit]
+		next := encodeAuditCursor(rows[len(rows)-1].ID)
+		page.NextCursor = &next
+	}
+	page.Items = make([]AuditEntry, 0, len(rows))
+	for _, row := range rows {
+		e := AuditEntry{
+			ID:        row.ID,
+			TaskID:    row.TaskID,
+			Action:    EventType(row.Action),
+			Actor:     row.Actor,
+			RequestID: row.RequestID,
+			Before:    row.Before,
+			After:     row.After,
+			At:        row.CreatedAt.Time,
+		}
+		if e.Before == nil {
+			e.Before = json.RawMessage("null")
+		}
+		page.Items = append(page.Items, e)
+	}
+	return page, nil
+}
+
+// snapshot locks one of owner's tasks (trashed or not) for the rest of the
+// transaction and returns it as it is before a change, for recordChange.
+// It returns nil if there is no such task, leaving the change itself to
+// report that.
+func snapshot(ctx context.Context, q *gen.Queries, owner string, id int32) (*Task, error) {
+	row, err := q.SnapshotTask(ctx, gen.SnapshotTaskParams{ID: id, OwnerID: owner})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t, err := taskWithTags(ctx, q, taskFromRow(taskRow(row)))
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// snapshotList is snapshot for every task in one of owner's lists, by id.
+func snapshotList(ctx context.Context, q *gen.Queries, owner string, listID int32) (map[int32]*Task, error) {
+	rows, err := q.SnapshotListTasks(ctx, gen.SnapshotListTasksParams{
+		ListID: pgtype.Int4{Int32: listID, Valid: true}, OwnerID: owner,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ts := make([]Task, 0, len(rows))
+	for _, row := range rows {
+		ts = append(ts, taskFromRow(taskRow(row)))
+	}
+	if err := withTags(ctx, q, ts); err != nil {
+		return nil, err
+	}
+	byID := make(map[int32]*Task, len(ts))
+	for i := range ts {
+		byID[ts[i].ID] = &ts[i]
+	}
+	return byID, nil
+}
+
+// recordChange writes ev to the outbox (see enqueueEvent), to the audit log
+// and, in event-sourced mode, to the task's stream (see appendEvents), with
+// what changed since before: the task as snapshot returned it, or nil for a
+// created task. Like enqueueEvent, q must be bound to the transaction making
+// the change, and ctx must be that transaction's (see Repo.withTx).
+func recordChange(ctx context.Context, q *gen.Queries, before *Task, ev Event) error {
+	old, changed, err := diffTasks(before, ev.Task)
+	if err != nil {
+		return err
+	}
+	err = q.AppendTaskAudit(ctx, gen.AppendTaskAuditParams{
+		TaskID:    ev.Task.ID,
+		OwnerID:   ev.Task.OwnerID,
+		Actor:     actor(ctx),
+		Action:    string(ev.Type),
+		RequestID: requestid.FromContext(ctx),
+		Before:    old,
+		After:     changed,
+	})
+	if err != nil {
+		return err
+	}
+	if err := appendEvents(ctx, q, before, ev); err != nil {
+		return err
+	}
+	return enqueueEvent(ctx, q, ev)
+}
+
+// recordCreations is recordChange for several tasks of one owner just
+// created, in bulk (see enqueueEvents).
+func recordCreations(ctx context.Context, q *gen.Queries, owner string, evs []Event) error {
+	params := gen.AppendTaskAuditBatchParams{
+		TaskIds:   make([]int32, 0, len(evs)),
+		OwnerID:   owner,
+		Actor:     actor(ctx),
+		RequestID: requestid.FromContext(ctx),
+		Afters:    make([]string, 0, len(evs)),
+	}
+	for _, ev := range evs {
+		after, err := json.Marshal(ev.Task)
+		if err != nil {
+			return err
+		}
+		params.TaskIds = append(params.TaskIds, ev.Task.ID)
+		params.Afters = append(params.Afters, string(after))
+	}
+	if err := q.AppendTaskAuditBatch(ctx, params); err != nil {
+		return err
+	}
+	for _, ev := range evs {
+		if err := appendEvents(ctx, q, nil, ev); err != nil {
+			return err
+		}
+	}
+	return enqueueEvents(ctx, q, owner, evs)
+}
+
+// actor is who is making a change: the authenticated caller, or
+// SystemActor outside a request.
+func actor(ctx context.Context) string {
+	if id, ok := auth.FromContext(ctx); ok && id.Subject != "" {
+		return id.Subject
+	}
+	return SystemActor
+}
+
+// diffTasks compares the JSON forms of before and after, returning the
+// fields that differ as two objects, one with their old values and one
+// with their new ones. A field one side omits (e.g. an unset due_at) counts
+// as null. With no before, old is nil and changed is all of after.
+func diffTasks(before *Task, after Task) (old, changed []byte, err error) {
+	if before == nil {
+		changed, err = json.Marshal(after)
+		return nil, changed, err
+	}
+	oldFields, newFields, err := diffFields(*before, after)
+	if err != nil {
+		return nil, nil, err
+	}
+	if old, err = json.Marshal(oldFields); err != nil {
+		return nil, nil, err
+	}
+	changed, err = json.Marshal(newFields)
+	return old, changed, err
+}
+
+// taskFields is a task (or part of one) in its JSON form, by field.
+type taskFields map[string]json.RawMessage
+
+// fieldsOf returns t's fields.
+func fieldsOf(t Task) (taskFields, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var f taskFields
+	return f, json.Unmarshal(b, &f)
+}
+
+// diffFields is diffTasks before encoding: the changed fields of before
+// and after, with null for a field one of them omits.
+func diffFields(before, after Task) (old, changed taskFields, err error) {
+	bm, err := fieldsOf(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	am, err := fieldsOf(after)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, changed = taskFields{}, taskFields{}
+	null := json.RawMessage("null")
+	for k, bv := range bm {
+		if av, ok := am[k]; !ok {
+			old[k], changed[k] = bv, null
+		} else if !bytes.Equal(av, bv) {
+			old[k], changed[k] = bv, av
+		}
+	}
+	for k, av := range am {
+		if _, ok := bm[k]; !ok {
+			old[k], changed[k] = null, av
+		}
+	}
+	return old, changed, nil
+}
@@ -0,0 +1,117 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// TaskAuditor is the optional capability behind GET /api/audit and
+// GET /api/tasks/{id}/history; without it both return 501.
+type TaskAuditor interface {
+	Audit(ctx context.Context, f AuditFilter) (AuditPage, error)
+}
+
+// registerAuditRoutes wires up the audit log routes under r (see
+// RegisterRoutes).
+func registerAuditRoutes(r *gin.RouterGroup, svc TaskLister) {
+	// audit discovers the capability, writing a 501 if it is missing.
+	audit := func(c *gin.Context) (TaskAuditor, bool) {
+		a, ok := svc.(TaskAuditor)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("audit_not_supported", "audit log not supported"))
+		}
+		return a, ok
+	}
+	serve := func(c *gin.Context, a TaskAuditor, f AuditFilter) {
+		page, err := a.Audit(c.Request.Context(), f)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, page)
+	}
+
+	// GET /api/audit?task_id=&actor=&action=&request_id=&since=&until=&limit=&cursor=
+	r.GET("/audit", func(c *gin.Context) {
+		a, ok := audit(c)
+		if !ok {
+			return
+		}
+		f, ok := parseAuditFilter(c)
+		if !ok {
+			return
+		}
+		if v := c.Query("task_id"); v != "" {
+			id, err := strconv.ParseInt(v, 10, 32)
+			if err != nil || id < 1 {
+				apperr.Write(c, invalidParam("task_id"))
+				return
+			}
+			taskID := int32(id)
+			f.TaskID = &taskID
+		}
+		serve(c, a, f)
+	})
+
+	// GET /api/tasks/{id}/history (the same filters, bar task_id). It is
+	// kept after the task is purged, so an unknown id is an empty history
+	// rather than a 404.
+	r.GET("/tasks/:id/history", func(c *gin.Context) {
+		a, ok := audit(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		f, ok := parseAuditFilter(c)
+		if !ok {
+			return
+		}
+		f.TaskID = &id
+		serve(c, a, f)
+	})
+}
+
+// parseAuditFilter reads the audit query string, bar task_id.
+// On invalid input it writes a 400 problem response and returns ok=false.
+func parseAuditFilter(c *gin.Context) (f AuditFilter, ok bool) {
+	f.Actor = c.Query("actor")
+	f.Action = EventType(c.Query("action"))
+	f.RequestID = c.Query("request_id")
+	f.Cursor = c.Query("cursor")
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"since", &f.Since}, {"until", &f.Until}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				apperr.Write(c, invalidParam(p.name))
+				return f, false
+			}
+			*p.dst = &t
+		}
+	}
+	if v, ok := c.GetQuery("limit"); ok {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			apperr.Write(c, invalidParam("limit"))
+			return f, false
+		}
+		f.Limit = limit
+	}
+	f, err := f.normalize()
+	if err != nil {
+		apperr.Write(c, err)
+		return f, false
+	}
+	return f, true
+}
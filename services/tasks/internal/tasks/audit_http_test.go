@@ -0,0 +1,101 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeAuditSvc records the filter it is asked for and returns one entry.
+type fakeAuditSvc struct {
+	listOnlySvc
+	got AuditFilter
+}
+
+func (f *fakeAuditSvc) Audit(ctx context.Context, filter AuditFilter) (AuditPage, error) {
+	f.got = filter
+	next := encodeAuditCursor(41)
+	return AuditPage{
+		Items: []AuditEntry{{
+			ID: 42, TaskID: 7, Action: EventUpdated, Actor: "ann", RequestID: "req-1",
+			Before: json.RawMessage(`{"done":false}`), After: json.RawMessage(`{"done":true}`),
+			At: time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC),
+		}},
+		NextCursor: &next,
+	}, nil
+}
+
+func TestAudit_ParsesFilters(t *testing.T) {
+	svc := &fakeAuditSvc{}
+	r := newTestRouter(svc)
+
+	w := doJSON(t, r, http.MethodGet,
+		"/api/audit?task_id=7&actor=ann&action=updated&request_id=req-1&since=2026-05-01T00:00:00Z&until=2026-05-02T00:00:00Z&limit=10&cursor=abc", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	f := svc.got
+	if f.TaskID == nil || *f.TaskID != 7 || f.Actor != "ann" || f.Action != EventUpdated || f.RequestID != "req-1" ||
+		f.Since == nil || f.Since.Day() != 1 || f.Until == nil || f.Until.Day() != 2 || f.Limit != 10 || f.Cursor != "abc" {
+		t.Fatalf("unexpected filter: %+v", f)
+	}
+	var page AuditPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || len(page.Items) != 1 || page.Items[0].Actor != "ann" || page.NextCursor == nil {
+		t.Fatalf("unexpected page: %s", w.Body.String())
+	}
+
+	// The history of one task: its id comes from the path; the limit defaults.
+	if w := doJSON(t, r, http.MethodGet, "/api/tasks/9/history?action=deleted", ""); w.Code != http.StatusOK {
+		t.Fatalf("history: expected 200, got %d", w.Code)
+	}
+	if f := svc.got; f.TaskID == nil || *f.TaskID != 9 || f.Action != EventDeleted || f.Limit != DefaultPageSize {
+		t.Fatalf("history: unexpected filter: %+v", f)
+	}
+}
+
+func TestAudit_InvalidParams(t *testing.T) {
+	r := newTestRouter(&fakeAuditSvc{})
+	for path, field := range map[string]string{
+		"/api/audit?action=moved":         "action",
+		"/api/audit?since=yesterday":      "since",
+		"/api/audit?until=2026-05-01":     "until",
+		"/api/audit?limit=0":              "limit",
+		"/api/audit?limit=201":            "limit",
+		"/api/audit?task_id=-1":           "task_id",
+		"/api/audit?task_id=x":            "task_id",
+		"/api/tasks/abc/history":          "id",
+		"/api/tasks/1/history?limit=many": "limit",
+	} {
+		w := doJSON(t, r, http.MethodGet, path, "")
+		p := decodeProblem(t, w)
+		if w.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != field {
+			t.Fatalf("%s: expected 400 on %s, got %d %+v", path, field, w.Code, p)
+		}
+	}
+}
+
+func TestAudit_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+	for _, path := range []string{"/api/audit", "/api/tasks/1/history"} {
+		if w := doJSON(t, r, http.MethodGet, path, ""); w.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: expected 501, got %d", path, w.Code)
+		}
+	}
+}
+
+func Test_Server_Audit_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := &fakeAuditSvc{}
+	for _, path := range []string{
+		"/api/audit",
+		"/api/audit?task_id=7&action=updated&since=2026-05-01T00:00:00Z&limit=5",
+		"/api/tasks/7/history",
+		"/api/audit?action=moved",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		serveAndValidateWith(t, doc, svc, req)
+	}
+}
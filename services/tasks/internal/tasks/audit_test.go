@@ -0,0 +1,49 @@
+package tasks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDiffTasks(t *testing.T) {
+	due := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	before := Task{ID: 3, Title: "Pay rent", Priority: PriorityNormal, Tags: []string{"home"}, Version: 1}
+	after := before
+	after.Title, after.DueAt, after.Tags, after.Version = "Pay rent!", &due, []string{"home", "money"}, 2
+
+	old, changed, err := diffTasks(&before, after)
+	if err != nil {
+		t.Fatalf("diffTasks: %v", err)
+	}
+	var o, c map[string]any
+	if err := json.Unmarshal(old, &o); err != nil {
+		t.Fatalf("old: %v", err)
+	}
+	if err := json.Unmarshal(changed, &c); err != nil {
+		t.Fatalf("changed: %v", err)
+	}
+	if len(o) != 4 || o["title"] != "Pay rent" || o["due_at"] != nil || o["version"] != 1.0 {
+		t.Fatalf("old: %v", o)
+	}
+	if _, ok := o["due_at"]; !ok {
+		t.Fatalf("an unset field must be recorded as null: %v", o)
+	}
+	if len(c) != 4 || c["title"] != "Pay rent!" || c["due_at"] != "2026-05-01T09:00:00Z" || len(c["tags"].([]any)) != 2 {
+		t.Fatalf("changed: %v", c)
+	}
+
+	// A creation has no before; after is the whole task.
+	old, changed, err = diffTasks(nil, after)
+	if err != nil || old != nil {
+		t.Fatalf("creation: %s, %v", old, err)
+	}
+	if whole, _ := json.Marshal(after); string(changed) != string(whole) {
+		t.Fatalf("creation: got %s, want %s", changed, whole)
+	}
+
+	// Nothing changed: two empty objects.
+	if old, changed, _ = diffTasks(&after, after); string(old) != "{}" || string(changed) != "{}" {
+		t.Fatalf("no-op: %s %s", old, changed)
+	}
+}
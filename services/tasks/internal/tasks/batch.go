@@ -0,0 +1,462 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// BatchMode says what a batch does when one of its operations fails.
+type BatchMode string
+
+const (
+	// BatchAtomic (the default) applies every operation or none: the first
+	// failure rolls the whole batch back and is returned as the error.
+	BatchAtomic BatchMode = "atomic"
+	// BatchPerItem applies each operation that succeeds and reports a
+	// result, success or failure, for every one of them.
+	BatchPerItem BatchMode = "per_item"
+)
+
+// BatchOpKind is the operation a BatchOp performs.
+type BatchOpKind string
+
+const (
+	OpCreate BatchOpKind = "create"
+	OpUpdate BatchOpKind = "update"
+	OpDelete BatchOpKind = "delete"
+)
+
+// MaxBatchOps is the most operations one batch may carry.
+const MaxBatchOps = 1000
+
+// copyThreshold is the size from which an atomic batch of only creates is
+// inserted with COPY rather than one INSERT per task.
+const copyThreshold = 100
+
+// BatchOp is one operation of a batch. Create takes the fields of a
+// NewTask; update takes ID and the fields of a TaskPatch, plus an optional
+// IfVersion (the batch's equivalent of If-Match); delete takes ID. Moving a
+// task to another list is not an update: use Service.Move.
+type BatchOp struct {
+	Op          BatchOpKind  `json:"op"`
+	ID          int32        `json:"id,omitempty"`
+	Title       *string      `json:"title,omitempty"`
+	Done        *bool        `json:"done,omitempty"`
+	Description *string      `json:"description,omitempty"`
+	DueAt       NullableTime `json:"due_at"`
+	RemindAt    NullableTime `json:"remind_at"`
+	Priority    *Priority    `json:"priority,omitempty"`
+	Tags        *[]string    `json:"tags,omitempty"`
+	RRule       *string      `json:"rrule,omitempty"`
+	ListID      *int32       `json:"list_id,omitempty"`
+	IfVersion   *int32       `json:"if_version,omitempty"`
+}
+
+// BatchRequest is a list of operations applied in one transaction.
+type BatchRequest struct {
+	Mode       BatchMode `json:"mode"`
+	Operations []BatchOp `json:"operations"`
+}
+
+// BatchItemResult is the outcome of one operation, at the same index as
+// the operation. Task is the created, updated or trashed task. Err is set,
+// and Task zero, if it failed.
+type BatchItemResult struct {
+	Op   BatchOpKind
+	Task Task
+	Err  error
+}
+
+// normalize validates the batch as a whole and fills in the default mode.
+// The operations themselves are checked one by one (see BatchOp.normalize).
+func (b BatchRequest) normalize() (BatchRequest, error) {
+	switch b.Mode {
+	case "":
+		b.Mode = BatchAtomic
+	case BatchAtomic, BatchPerItem:
+	default:
+		return b, invalidParam("mode")
+	}
+	switch {
+	case len(b.Operations) == 0:
+		return b, apperr.Validation("empty_batch", "operations must not be empty",
+			apperr.FieldError{Field: "operations", Reason: ReasonRequired})
+	case len(b.Operations) > MaxBatchOps:
+		return b, apperr.Validation("batch_too_large", fmt.Sprintf("at most %d operations per batch", MaxBatchOps),
+			apperr.FieldError{Field: "operations", Reason: ReasonTooLong})
+	}
+	return b, nil
+}
+
+// normalize validates op and normalizes its fields the way Create and
+// Update do.
+func (op BatchOp) normalize() (BatchOp, error) {
+	unexpected := func(field string) error {
+		return apperr.Validation("invalid_operation", fmt.Sprintf("%s does not take %s", op.Op, field),
+			apperr.FieldError{Field: field, Reason: "unexpected"})
+	}
+	switch op.Op {
+	case OpCreate:
+		switch {
+		case op.ID != 0:
+			return op, unexpected("id")
+		case op.Done != nil:
+			return op, unexpected("done")
+		case op.IfVersion != nil:
+			return op, unexpected("if_version")
+		case op.Title == nil:
+			return op, titleError(ReasonRequired, "title is required")
+		}
+		n, err := op.newTask().normalize()
+		if err != nil {
+			return op, err
+		}
+		op.Title, op.Description, op.Priority, op.Tags, op.RRule = &n.Title, &n.Description, &n.Priority, &n.Tags, &n.RRule
+	case OpUpdate:
+		if op.ID <= 0 {
+			return op, invalidParam("id")
+		}
+		if op.IfVersion != nil && *op.IfVersion < 1 {
+			return op, invalidParam("if_version")
+		}
+		if op.ListID != nil {
+			return op, unexpected("list_id")
+		}
+		p, err := op.patch().normalize()
+		if err != nil {
+			return op, err
+		}
+		op.Title, op.Description, op.Tags, op.RRule = p.Title, p.Description, p.Tags, p.RRule
+	case OpDelete:
+		switch {
+		case op.ID <= 0:
+			return op, invalidParam("id")
+		case op.Title != nil:
+			return op, unexpected("title")
+		case op.Done != nil:
+			return op, unexpected("done")
+		case op.Description != nil:
+			return op, unexpected("description")
+		case op.DueAt.Set:
+			return op, unexpected("due_at")
+		case op.RemindAt.Set:
+			return op, unexpected("remind_at")
+		case op.Priority != nil:
+			return op, unexpected("priority")
+		case op.Tags != nil:
+			return op, unexpected("tags")
+		case op.RRule != nil:
+			return op, unexpected("rrule")
+		case op.ListID != nil:
+			return op, unexpected("list_id")
+		case op.IfVersion != nil:
+			return op, unexpected("if_version")
+		}
+	default:
+		return op, invalidParam("op")
+	}
+	return op, nil
+}
+
+func (op BatchOp) patch() TaskPatch {
+	return TaskPatch{
+		Title:       op.Title,
+		Done:        op.Done,
+		Description: op.Description,
+		DueAt:       op.DueAt,
+		RemindAt:    op.RemindAt,
+		Priority:    op.Priority,
+		Tags:        op.Tags,
+		RRule:       op.RRule,
+		IfVersion:   op.IfVersion,
+	}
+}
+
+// newTask is the NewTask of a create operation whose title is set.
+func (op BatchOp) newTask() NewTask {
+	n := NewTask{Title: *op.Title, DueAt: op.DueAt.Time, RemindAt: op.RemindAt.Time, ListID: op.ListID}
+	if op.Description != nil {
+		n.Description = *op.Description
+	}
+	if op.Priority != nil {
+		n.Priority = *op.Priority
+	}
+	if op.Tags != nil {
+		n.Tags = *op.Tags
+	}
+	if op.RRule != nil {
+		n.RRule = *op.RRule
+	}
+	return n
+}
+
+// batchItemError reports the failure of operation i of an atomic batch,
+// prefixing its field errors with the operation's position, e.g.
+// "operations[3].title".
+func batchItemError(i int, err error) error {
+	e := *apperr.From(err)
+	prefix := fmt.Sprintf("operations[%d]", i)
+	e.Message = prefix + ": " + e.Message
+	fields := make([]apperr.FieldError, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		fields = append(fields, apperr.FieldError{Field: prefix + "." + f.Field, Reason: f.Reason})
+	}
+	if len(fields) == 0 {
+		fields = append(fields, apperr.FieldError{Field: prefix, Reason: e.Code})
+	}
+	e.Fields = fields
+	return &e
+}
+
+// Batch applies the operations of req in a single transaction, either all
+// or nothing (BatchAtomic) or each on its own (BatchPerItem).
+//
+// In atomic mode the first failing operation, invalid input included, is
+// returned as the error and nothing is applied. In per-item mode the error
+// is only for the batch as a whole (e.g. the database is down); failed
+// operations are reported in their BatchItemResult.
+func (s *Service) Batch(ctx context.Context, req BatchRequest) ([]BatchItemResult, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err = req.normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchItemResult, len(req.Operations))
+	valid := make([]BatchOp, 0, len(req.Operations))
+	index := make([]int, 0, len(req.Operations)) // index[j] is valid[j]'s position in req
+	for i, op := range req.Operations {
+		results[i].Op = op.Op
+		op, err := op.normalize()
+		if err != nil {
+			if req.Mode == BatchAtomic {
+				return nil, batchItemError(i, err)
+			}
+			results[i].Err = err
+			continue
+		}
+		valid = append(valid, op)
+		index = append(index, i)
+	}
+	if len(valid) == 0 {
+		return results, nil
+	}
+
+	applied, err := s.repo.Batch(ctx, owner, valid, req.Mode)
+	if err != nil {
+		return nil, err
+	}
+	evs := make([]Event, 0, len(applied))
+	for j, res := range applied {
+		results[index[j]] = res
+		if res.Err == nil {
+			evs = append(evs, Event{Type: opEventType(res.Op), Task: res.Task})
+		}
+	}
+	s.emitAll(ctx, evs)
+	return results, nil
+}
+
+// emitAll is emit for the events of a batch.
+func (s *Service) emitAll(ctx context.Context, evs []Event) {
+	if len(evs) == 0 {
+		return
+	}
+	if err := s.repo.NotifyAll(context.WithoutCancel(ctx), evs); err != nil {
+		log.Printf("tasks: notify %d batch events: %v", len(evs), err)
+	}
+}
+
+func opEventType(op BatchOpKind) EventType {
+	switch op {
+	case OpCreate:
+		return EventCreated
+	case OpDelete:
+		return EventDeleted
+	}
+	return EventUpdated
+}
+
+// Batch applies already-validated ops for owner in one transaction,
+// returning a result per op. Each op writes its outbox event like the
+// single-task methods do.
+//
+// In BatchAtomic mode the first failure rolls everything back and is
+// returned (see batchItemError). A large batch of only creates is inserted
+// with COPY (see createTasks). In BatchPerItem mode each op runs in its own
+// savepoint, so a failing op is undone without aborting the others.
+func (r *Repo) Batch(ctx context.Context, owner string, ops []BatchOp, mode BatchMode) ([]BatchItemResult, error) {
+	results := make([]BatchItemResult, len(ops))
+	for i, op := range ops {
+		results[i].Op = op.Op
+	}
+
+	if mode == BatchPerItem {
+		err := r.withTxConn(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			for i, op := range ops {
+				sp, err := tx.Begin(ctx) // SAVEPOINT
+				if err != nil {
+					return err
+				}
+				t, err := applyOp(ctx, r.qry.WithTx(sp), owner, op)
+				if err != nil {
+					if rerr := sp.Rollback(ctx); rerr != nil {
+						return rerr
+					}
+					results[i].Err = dbError(err, "task")
+					continue
+				}
+				if err := sp.Commit(ctx); err != nil { // RELEASE SAVEPOINT
+					return err
+				}
+				results[i].Task = t
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, dbError(err, "task")
+		}
+		return results, nil
+	}
+
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		if len(ops) >= copyThreshold && onlyCreates(ops) {
+			tasks := make([]NewTask, len(ops))
+			for i, op := range ops {
+				tasks[i] = op.newTask()
+			}
+			created, err := createTasks(ctx, q, owner, tasks)
+			if err != nil {
+				return err
+			}
+			for i, t := range created {
+				results[i].Task = t
+			}
+			return nil
+		}
+		for i, op := range ops {
+			t, err := applyOp(ctx, q, owner, op)
+			if err != nil {
+				return batchItemError(i, dbError(err, "task"))
+			}
+			results[i].Task = t
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	return results, nil
+}
+
+// applyOp runs one batch operation with q bound to the batch's transaction.
+func applyOp(ctx context.Context, q *gen.Queries, owner string, op BatchOp) (Task, error) {
+	switch op.Op {
+	case OpCreate:
+		return createTask(ctx, q, owner, op.newTask())
+	case OpUpdate:
+		return updateTask(ctx, q, owner, op.ID, op.patch())
+	case OpDelete:
+		return deleteTask(ctx, q, owner, op.ID)
+	}
+	return Task{}, invalidParam("op")
+}
+
+func onlyCreates(ops []BatchOp) bool {
+	for _, op := range ops {
+		if op.Op != OpCreate {
+			return false
+		}
+	}
+	return true
+}
+
+// createTasks inserts the tasks with a single COPY, returning them in the
+// same order. COPY returns no rows, so the ids are reserved from the
+// sequence first and the rows read back afterwards. Tags still take a
+// statement per tagged task. The outbox events and audit entries are
+// written in bulk too (see recordCreations).
+//
+// Tasks for a list go at its end, in batch order, like createTask puts
+// them: the lists are locked (in id order, so concurrent batches can't
+// deadlock) and each gets consecutive positions past its last task.
+func createTasks(ctx context.Context, q *gen.Queries, owner string, tasks []NewTask) ([]Task, error) {
+	ids, err := q.NextTaskIDs(ctx, int32(len(tasks)))
+	if err != nil {
+		return nil, err
+	}
+	var listIDs []int32
+	for _, n := range tasks {
+		if n.ListID != nil {
+			listIDs = append(listIDs, *n.ListID)
+		}
+	}
+	slices.Sort(listIDs)
+	next := make(map[int32]float64) // the position of the next task to append
+	for _, id := range slices.Compact(listIDs) {
+		pos, err := listPosition(ctx, q, owner, id, 0, TaskMove{ListID: &id})
+		if err != nil {
+			return nil, err
+		}
+		next[id] = pos
+	}
+	rows := make([]gen.CopyTasksParams, len(tasks))
+	for i, n := range tasks {
+		s, err := newSchedule(n.RRule, seriesStart(n.DueAt), n.DueAt)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = gen.CopyTasksParams{
+			ID:               ids[i],
+			Title:            n.Title,
+			OwnerID:          owner,
+			Description:      n.Description,
+			DueAt:            timestamptz(n.DueAt),
+			Priority:         gen.TaskPriority(n.Priority),
+			Rrule:            s.rule,
+			RruleStartAt:     s.start,
+			NextOccurrenceAt: s.next,
+			RemindAt:         timestamptz(n.RemindAt),
+		}
+		if n.ListID != nil {
+			rows[i].ListID = pgtype.Int4{Int32: *n.ListID, Valid: true}
+			rows[i].Position = pgtype.Float8{Float64: next[*n.ListID], Valid: true}
+			next[*n.ListID]++
+		}
+	}
+	if _, err := q.CopyTasks(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	inserted, err := q.GetTasksByIDs(ctx, gen.GetTasksByIDsParams{OwnerID: owner, Ids: ids})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int32]Task, len(inserted))
+	for _, row := range inserted {
+		byID[row.ID] = taskFromRow(taskRow(row))
+	}
+	created := make([]Task, len(ids))
+	evs := make([]Event, len(ids))
+	for i, id := range ids {
+		created[i] = byID[id]
+		if len(tasks[i].Tags) > 0 {
+			if created[i].Tags, err = setTags(ctx, q, owner, id, tasks[i].Tags); err != nil {
+				return nil, err
+			}
+		}
+		evs[i] = Event{Type: EventCreated, Task: created[i]}
+	}
+	return created, recordCreations(ctx, q, owner, evs)
+}
@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// TaskBatcher is the optional capability behind POST /api/tasks:batch.
+type TaskBatcher interface {
+	Batch(ctx context.Context, req BatchRequest) ([]BatchItemResult, error)
+}
+
+// batchItem is the JSON form of a BatchItemResult. Status is the HTTP
+// status the operation would have had on its own (201, 200 or 204 on
+// success), and Error its problem body if it failed.
+type batchItem struct {
+	Index  int             `json:"index"`
+	Op     BatchOpKind     `json:"op"`
+	Status int             `json:"status"`
+	Task   *Task           `json:"task,omitempty"`
+	Error  *apperr.Problem `json:"error,omitempty"`
+}
+
+type batchResponse struct {
+	Results []batchItem `json:"results"`
+}
+
+// taskMethods serves the custom methods on the tasks collection,
+// POST /api/tasks:<method>. gin cannot route a literal ':' inside a path
+// segment, so it is registered as "/tasks:method", which matches any
+// /api/tasks<suffix>; everything but ":batch" is a 404.
+func taskMethods(svc TaskLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param("method") != ":batch" {
+			apperr.Write(c, apperr.NotFound("route_not_found", "no such route"))
+			return
+		}
+		b, ok := svc.(TaskBatcher)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("batch_not_supported", "batch not supported"))
+			return
+		}
+
+		var req BatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		results, err := b.Batch(c.Request.Context(), req)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+
+		// Per-item failures don't fail the request: it is a 200 whose
+		// results say what happened to each operation.
+		resp := batchResponse{Results: make([]batchItem, len(results))}
+		for i, res := range results {
+			item := batchItem{Index: i, Op: res.Op}
+			switch {
+			case res.Err != nil:
+				p := apperr.ProblemFor(res.Err, c.Request.URL.Path)
+				item.Status, item.Error = p.Status, &p
+			case res.Op == OpCreate:
+				item.Status, item.Task = http.StatusCreated, &res.Task
+			case res.Op == OpUpdate:
+				item.Status, item.Task = http.StatusOK, &res.Task
+			default:
+				item.Status = http.StatusNoContent
+			}
+			resp.Results[i] = item
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
@@ -0,0 +1,264 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeBatchSvc applies batches through fakeSvc's single-task methods,
+// validating and reporting failures like Service.Batch does.
+type fakeBatchSvc struct {
+	fakeSvc
+}
+
+func (f *fakeBatchSvc) Batch(ctx context.Context, req BatchRequest) ([]BatchItemResult, error) {
+	req, err := req.normalize()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]BatchItemResult, len(req.Operations))
+	for i, op := range req.Operations {
+		results[i].Op = op.Op
+		op, err := op.normalize()
+		if err == nil {
+			switch op.Op {
+			case OpCreate:
+				results[i].Task, err = f.Create(ctx, op.newTask())
+			case OpUpdate:
+				results[i].Task, err = f.Update(ctx, op.ID, op.patch())
+			case OpDelete:
+				err = f.Delete(ctx, op.ID)
+			}
+		}
+		if err != nil {
+			if req.Mode == BatchAtomic {
+				return nil, batchItemError(i, err)
+			}
+			results[i] = BatchItemResult{Op: op.Op, Err: err}
+		}
+	}
+	return results, nil
+}
+
+func decodeBatch(t *testing.T, w *httptest.ResponseRecorder) batchResponse {
+	t.Helper()
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var resp batchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	return resp
+}
+
+func TestBatch_AtomicAppliesEveryOperation(t *testing.T) {
+	r := newTestRouter(&fakeBatchSvc{})
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[
+		{"op":"create","title":"  New  "},
+		{"op":"update","id":1,"done":true,"if_version":1},
+		{"op":"delete","id":2}]}`)
+	resp := decodeBatch(t, w)
+	if len(resp.Results) != 3 {
+		t.Fatalf("expected 3 results, got %+v", resp.Results)
+	}
+	for i, want := range []int{http.StatusCreated, http.StatusOK, http.StatusNoContent} {
+		if got := resp.Results[i]; got.Index != i || got.Status != want || got.Error != nil {
+			t.Fatalf("result %d: expected status %d, got %+v", i, want, got)
+		}
+	}
+	if resp.Results[0].Task.Title != "New" || !resp.Results[1].Task.Done || resp.Results[2].Task != nil {
+		t.Fatalf("unexpected tasks in %+v", resp.Results)
+	}
+}
+
+func TestBatch_CarriesRRule(t *testing.T) {
+	svc := &fakeBatchSvc{}
+	r := newTestRouter(svc)
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[
+		{"op":"create","title":"standup","rrule":"freq=daily"},
+		{"op":"update","id":1,"rrule":""}]}`)
+	resp := decodeBatch(t, w)
+	if got := resp.Results[0].Task; got == nil || got.RRule != "FREQ=DAILY" {
+		t.Fatalf("create: expected the normalized rrule, got %+v", resp.Results[0])
+	}
+	if p := svc.lastPatch; p.RRule == nil || *p.RRule != "" {
+		t.Fatalf("update: expected rrule cleared, got %+v", p)
+	}
+
+	w = doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[{"op":"create","title":"x","rrule":"FREQ=SECONDLY"}]}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "operations[0].rrule" {
+		t.Fatalf("invalid rrule: got %d %+v", w.Code, p)
+	}
+	w = doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[{"op":"delete","id":1,"rrule":"FREQ=DAILY"}]}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Errors[0].Field != "operations[0].rrule" {
+		t.Fatalf("delete with rrule: got %d %+v", w.Code, p)
+	}
+}
+
+func TestBatch_CarriesRemindAt(t *testing.T) {
+	svc := &fakeBatchSvc{}
+	r := newTestRouter(svc)
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[
+		{"op":"create","title":"call mum","remind_at":"2026-05-01T09:00:00Z"},
+		{"op":"update","id":1,"remind_at":null}]}`)
+	resp := decodeBatch(t, w)
+	if got := resp.Results[0].Task; got == nil || got.RemindAt == nil || got.RemindAt.Hour() != 9 {
+		t.Fatalf("create: expected remind_at, got %+v", resp.Results[0])
+	}
+	if p := svc.lastPatch; !p.RemindAt.Set || p.RemindAt.Time != nil {
+		t.Fatalf("update: expected remind_at cleared, got %+v", p)
+	}
+
+	w = doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[{"op":"delete","id":1,"remind_at":null}]}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Errors[0].Field != "operations[0].remind_at" {
+		t.Fatalf("delete with remind_at: got %d %+v", w.Code, p)
+	}
+}
+
+func TestBatch_ListIDOnlyOnCreate(t *testing.T) {
+	r := newTestRouter(&fakeBatchSvc{})
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[{"op":"create","title":"milk","list_id":3}]}`)
+	if resp := decodeBatch(t, w); resp.Results[0].Status != http.StatusCreated {
+		t.Fatalf("create: %+v", resp.Results[0])
+	}
+	for _, body := range []string{
+		`{"operations":[{"op":"update","id":1,"list_id":3}]}`,
+		`{"operations":[{"op":"delete","id":1,"list_id":3}]}`,
+	} {
+		w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", body)
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Errors[0].Field != "operations[0].list_id" {
+			t.Fatalf("%s: got %d %+v", body, w.Code, p)
+		}
+	}
+}
+
+func TestBatch_AtomicFailurePointsAtTheOperation(t *testing.T) {
+	r := newTestRouter(&fakeBatchSvc{})
+
+	cases := []struct {
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{
+			`{"operations":[{"op":"create","title":"ok"},{"op":"delete","id":404}]}`,
+			http.StatusNotFound, "task_not_found", "operations[1]",
+		},
+		{
+			`{"mode":"atomic","operations":[{"op":"create","title":" "}]}`,
+			http.StatusBadRequest, "invalid_title", "operations[0].title",
+		},
+		{
+			`{"operations":[{"op":"update","id":1,"title":"x","if_version":7}]}`,
+			http.StatusPreconditionFailed, "version_mismatch", "operations[0]",
+		},
+		{
+			`{"operations":[{"op":"delete","id":1,"done":true}]}`,
+			http.StatusBadRequest, "invalid_operation", "operations[0].done",
+		},
+	}
+	for _, tc := range cases {
+		w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", tc.body)
+		if w.Code != tc.status {
+			t.Fatalf("%s: expected %d, got %d", tc.body, tc.status, w.Code)
+		}
+		if p := decodeProblem(t, w); p.Code != tc.code || len(p.Errors) != 1 || p.Errors[0].Field != tc.field {
+			t.Fatalf("%s: unexpected problem %+v", tc.body, p)
+		}
+	}
+}
+
+func TestBatch_PerItemReportsEachOperation(t *testing.T) {
+	r := newTestRouter(&fakeBatchSvc{})
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"mode":"per_item","operations":[
+		{"op":"create","title":"kept"},
+		{"op":"update","id":404,"done":true},
+		{"op":"archive","id":1},
+		{"op":"update","id":1,"done":true}]}`)
+	resp := decodeBatch(t, w)
+	want := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusCreated, ""},
+		{http.StatusNotFound, "task_not_found"},
+		{http.StatusBadRequest, "invalid_op"},
+		{http.StatusOK, ""},
+	}
+	for i, res := range resp.Results {
+		code := ""
+		if res.Error != nil {
+			code = res.Error.Code
+		}
+		if res.Status != want[i].status || code != want[i].code || (code == "") != (res.Task != nil) {
+			t.Fatalf("result %d: expected %d %q, got %+v", i, want[i].status, want[i].code, res)
+		}
+	}
+}
+
+func TestBatch_RejectsMalformedBatches(t *testing.T) {
+	r := newTestRouter(&fakeBatchSvc{})
+
+	for body, code := range map[string]string{
+		`{"operations":[]}`: "empty_batch",
+		`{"mode":"best_effort","operations":[{"op":"delete","id":1}]}`: "invalid_mode",
+		`not json`: "invalid_body",
+	} {
+		w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", body)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, w.Code)
+		}
+		if p := decodeProblem(t, w); p.Code != code {
+			t.Fatalf("%s: expected %s, got %+v", body, code, p)
+		}
+	}
+
+	ops := bytes.Repeat([]byte(`{"op":"delete","id":1},`), MaxBatchOps+1)
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[`+string(ops[:len(ops)-1])+`]}`)
+	if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Code != "batch_too_large" {
+		t.Fatalf("oversized batch: got %d %+v", w.Code, p)
+	}
+}
+
+func TestBatch_RoutingAndCapability(t *testing.T) {
+	r := newTestRouter(&fakeBatchSvc{})
+	for _, path := range []string{"/api/tasks:purge", "/api/tasksbatch"} {
+		if w := doJSON(t, r, http.MethodPost, path, `{}`); w.Code != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", path, w.Code)
+		}
+	}
+	if w := doJSON(t, r, http.MethodPost, "/api/tasks", `{"title":"still works"}`); w.Code != http.StatusCreated {
+		t.Fatalf("POST /api/tasks: expected 201, got %d", w.Code)
+	}
+
+	r = newTestRouter(&fakeSvc{})
+	w := doJSON(t, r, http.MethodPost, "/api/tasks:batch", `{"operations":[{"op":"delete","id":1}]}`)
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without TaskBatcher, got %d", w.Code)
+	}
+}
+
+func Test_Server_Batch_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	for _, body := range []string{
+		`{"operations":[{"op":"create","title":"a"},{"op":"update","id":1,"title":"b"},{"op":"delete","id":2}]}`,
+		`{"mode":"per_item","operations":[{"op":"delete","id":404},{"op":"create","title":""}]}`,
+		`{"operations":[{"op":"delete","id":404}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/tasks:batch", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		serveAndValidateWith(t, doc, &fakeBatchSvc{}, req)
+	}
+}
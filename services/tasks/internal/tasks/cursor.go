@@ -0,0 +1,56 @@
+package tasks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// cursor is the keyset position after which the next page starts: the
+// sort key(s) of the last row on the previous page. It is serialized as
+// base64url JSON so clients treat it as an opaque token.
+type cursor struct {
+	Sort      SortOrder `json:"s"`
+	ID        int32     `json:"id"`
+	CreatedAt time.Time `json:"ts"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c) // cannot fail for this struct
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string, sort SortOrder) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == 0 || c.Sort != sort {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// auditCursor is the keyset position in the audit log (see Repo.Audit):
+// the id of the last entry of the previous page.
+type auditCursor struct {
+	ID int64 `json:"a"`
+}
+
+func encodeAuditCursor(id int64) string {
+	b, _ := json.Marshal(auditCursor{ID: id}) // cannot fail for this struct
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeAuditCursor(s string) (int64, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	var c auditCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return c.ID, nil
+}
@@ -0,0 +1,35 @@
+package tasks
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	ts := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	in := cursor{Sort: SortCreatedAtDesc, ID: 42, CreatedAt: ts}
+
+	out, err := decodeCursor(encodeCursor(in), SortCreatedAtDesc)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.ID != 42 || !out.CreatedAt.Equal(ts) || out.Sort != SortCreatedAtDesc {
+		t.Fatalf("round trip mismatch: %#v", out)
+	}
+}
+
+func TestCursor_RejectsGarbageAndSortMismatch(t *testing.T) {
+	valid := encodeCursor(cursor{Sort: SortIDAsc, ID: 7})
+
+	cases := map[string]SortOrder{
+		"not base64!": SortIDAsc,
+		"bm90IGpzb24": SortIDAsc, // "not json"
+		valid:         SortIDDesc,
+	}
+	for s, sort := range cases {
+		if _, err := decodeCursor(s, sort); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("decodeCursor(%q, %q): expected ErrInvalidCursor, got %v", s, sort, err)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// Result count bounds for GET /api/tasks/next (?limit=).
+const (
+	DefaultNextLimit = 20
+	MaxNextLimit     = 100
+)
+
+// depGraph is the "must finish before" graph of an owner's tasks: an edge
+// u → v means u has to be done before v, either because u blocks v or
+// because u is a subtask of v. Links that would make it cyclic are
+// rejected, so it is always a DAG.
+type depGraph map[int32][]int32
+
+func newDepGraph(edges []gen.ListTaskEdgesRow) depGraph {
+	g := depGraph{}
+	for _, e := range edges {
+		g[e.BeforeID] = append(g[e.BeforeID], e.AfterID)
+	}
+	return g
+}
+
+// remove drops one u → v edge, if there is one.
+func (g depGraph) remove(u, v int32) {
+	if i := slices.Index(g[u], v); i >= 0 {
+		g[u] = slices.Delete(g[u], i, i+1)
+	}
+}
+
+// path returns a path from → ... → to, found depth-first, or nil if to
+// cannot be reached from from.
+func (g depGraph) path(from, to int32) []int32 {
+	seen := map[int32]bool{}
+	var res []int32
+	var visit func(int32) bool
+	visit = func(u int32) bool {
+		seen[u] = true
+		res = append(res, u)
+		if u == to {
+			return true
+		}
+		for _, v := range g[u] {
+			if !seen[v] && visit(v) {
+				return true
+			}
+		}
+		res = res[:len(res)-1]
+		return false
+	}
+	if visit(from) {
+		return res
+	}
+	return nil
+}
+
+// reach counts the tasks that depend on u, directly or transitively.
+func (g depGraph) reach(u int32) int {
+	seen := map[int32]bool{u: true}
+	q := []int32{u}
+	for len(q) > 0 {
+		u := q[0]
+		q = q[1:]
+		for _, v := range g[u] {
+			if !seen[v] {
+				seen[v] = true
+				q = append(q, v)
+			}
+		}
+	}
+	return len(seen) - 1
+}
+
+// checkEdge returns a conflict error naming the cycle if adding the edge
+// before → after would close one, that is if after already leads to before.
+func (g depGraph) checkEdge(before, after int32) error {
+	p := g.path(after, before)
+	if p == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(p)+1)
+	for _, id := range append(p, after) {
+		ids = append(ids, fmt.Sprint(id))
+	}
+	return apperr.Conflict("dependency_cycle", "would create a cycle: "+strings.Join(ids, " → "), nil)
+}
+
+// nextActionable returns up to limit of the open tasks that nothing open
+// has to finish before: no open blocker and no open subtask. They are the
+// first layer of a topological order of the open part of g, ranked by
+// priority, then by how many open tasks finishing them would (eventually)
+// unblock, then by due date (soonest first, none last), then by id.
+func nextActionable(open []Task, g depGraph, limit int) []Task {
+	isOpen := make(map[int32]bool, len(open))
+	for _, t := range open {
+		isOpen[t.ID] = true
+	}
+	sub := depGraph{}
+	waiting := map[int32]bool{}
+	for u, vs := range g {
+		for _, v := range vs {
+			if isOpen[u] && isOpen[v] {
+				sub[u] = append(sub[u], v)
+				waiting[v] = true
+			}
+		}
+	}
+
+	type candidate struct {
+		Task
+		unblocks int
+	}
+	var cs []candidate
+	for _, t := range open {
+		if !waiting[t.ID] {
+			cs = append(cs, candidate{t, sub.reach(t.ID)})
+		}
+	}
+	slices.SortFunc(cs, func(a, b candidate) int {
+		if c := cmp.Compare(priorityRank(b.Priority), priorityRank(a.Priority)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.unblocks, a.unblocks); c != 0 {
+			return c
+		}
+		switch {
+		case a.DueAt != nil && b.DueAt != nil:
+			if c := a.DueAt.Compare(*b.DueAt); c != 0 {
+				return c
+			}
+		case a.DueAt != nil:
+			return -1
+		case b.DueAt != nil:
+			return 1
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	out := make([]Task, 0, min(limit, len(cs)))
+	for _, c := range cs[:min(limit, len(cs))] {
+		out = append(out, c.Task)
+	}
+	return out
+}
+
+// priorityRank orders priorities from least to most urgent.
+func priorityRank(p Priority) int {
+	switch p {
+	case PriorityLow:
+		return 0
+	case PriorityHigh:
+		return 2
+	case PriorityUrgent:
+		return 3
+	}
+	return 1
+}
+
+// SetParent makes a task a subtask of parentID, or a top-level task if
+// parentID is nil. It fails with a conflict if parentID is (transitively)
+// waiting on the task, since the parent could then never be finished.
+func (s *Service) SetParent(ctx context.Context, id int32, parentID *int32) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	if parentID != nil && *parentID == id {
+		return Task{}, invalidParam("parent_id")
+	}
+	t, err := s.repo.SetParent(ctx, owner, id, parentID)
+	if err != nil {
+		return Task{}, err
+	}
+	s.emit(ctx, Event{Type: EventUpdated, Task: t})
+	return t, nil
+}
+
+// AddBlocker records that a task cannot be done before blockerID is. It is
+// a no-op if that is already recorded, and a conflict if blockerID is
+// (transitively) waiting on the task.
+func (s *Service) AddBlocker(ctx context.Context, id, blockerID int32) error {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	if blockerID == id {
+		return invalidParam("blocker_id")
+	}
+	return s.repo.AddBlocker(ctx, owner, id, blockerID)
+}
+
+// RemoveBlocker undoes AddBlocker.
+func (s *Service) RemoveBlocker(ctx context.Context, id, blockerID int32) error {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveBlocker(ctx, owner, id, blockerID)
+}
+
+// Subtasks returns the live subtasks of one of the caller's tasks.
+func (s *Service) Subtasks(ctx context.Context, id int32) ([]Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Subtasks(ctx, owner, id)
+}
+
+// Blockers returns the live tasks blocking one of the caller's tasks, done
+// ones included.
+func (s *Service) Blockers(ctx context.Context, id int32) ([]Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Blockers(ctx, owner, id)
+}
+
+// Next returns up to limit of the caller's open tasks that can be worked on
+// now, most pressing first (see nextActionable).
+func (s *Service) Next(ctx context.Context, limit int) ([]Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > MaxNextLimit {
+		return nil, invalidParam("limit")
+	}
+	return s.repo.Next(ctx, owner, limit)
+}
+
+// SetParent sets the parent of one of owner's live tasks and records an
+// EventUpdated. The parent must be a live task of owner's too.
+func (r *Repo) SetParent(ctx context.Context, owner string, id int32, parentID *int32) (Task, error) {
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		params := gen.SetTaskParentParams{ID: id, OwnerID: owner}
+		if parentID != nil {
+			if err := q.LockTaskGraph(ctx, owner); err != nil {
+				return err
+			}
+			g, err := taskGraph(ctx, q, owner)
+			if err != nil {
+				return err
+			}
+			cur, err := q.GetTask(ctx, gen.GetTaskParams{ID: id, OwnerID: owner})
+			if err != nil {
+				return err
+			}
+			if _, err := q.GetTask(ctx, gen.GetTaskParams{ID: *parentID, OwnerID: owner}); err != nil {
+				return err
+			}
+			// The task's current parent edge is being replaced.
+			if cur.ParentID.Valid {
+				g.remove(id, cur.ParentID.Int32)
+			}
+			if err := g.checkEdge(id, *parentID); err != nil {
+				return err
+			}
+			params.ParentID = pgtype.Int4{Int32: *parentID, Valid: true}
+		}
+		before, err := snapshot(ctx, q, owner, id)
+		if err != nil {
+			return err
+		}
+		row, err := q.SetTaskParent(ctx, params)
+		if err != nil {
+			return err
+		}
+		if t, err = taskWithTags(ctx, q, taskFromRow(taskRow(row))); err != nil {
+			return err
+		}
+		return recordChange(ctx, q, before, Event{Type: EventUpdated, Task: t})
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// AddBlocker records that blockerID blocks id; both must be live tasks of
+// owner's.
+func (r *Repo) AddBlocker(ctx context.Context, owner string, id, blockerID int32) error {
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		if err := q.LockTaskGraph(ctx, owner); err != nil {
+			return err
+		}
+		for _, tid := range []int32{id, blockerID} {
+			if _, err := q.GetTask(ctx, gen.GetTaskParams{ID: tid, OwnerID: owner}); err != nil {
+				return err
+			}
+		}
+		g, err := taskGraph(ctx, q, owner)
+		if err != nil {
+			return err
+		}
+		if err := g.checkEdge(blockerID, id); err != nil {
+			return err
+		}
+		return q.AddTaskDependency(ctx, gen.AddTaskDependencyParams{TaskID: id, BlockedByID: blockerID})
+	})
+	return dbError(err, "task")
+}
+
+// RemoveBlocker deletes the record that blockerID blocks id, returning an
+// apperr.KindNotFound error if there is none.
+func (r *Repo) RemoveBlocker(ctx context.Context, owner string, id, blockerID int32) error {
+	if _, err := r.qry.GetTask(ctx, gen.GetTaskParams{ID: id, OwnerID: owner}); err != nil {
+		return dbError(err, "task")
+	}
+	n, err := r.qry.RemoveTaskDependency(ctx, gen.RemoveTaskDependencyParams{TaskID: id, BlockedByID: blockerID})
+	if err != nil {
+		return dbError(err, "dependency")
+	}
+	if n == 0 {
+		return apperr.NotFound("dependency_not_found", "dependency not found")
+	}
+	return nil
+}
+
+// Subtasks returns the live subtasks of one of owner's live tasks by id.
+func (r *Repo) Subtasks(ctx context.Context, owner string, id int32) ([]Task, error) {
+	if _, err := r.qry.GetTask(ctx, gen.GetTaskParams{ID: id, OwnerID: owner}); err != nil {
+		return nil, dbError(err, "task")
+	}
+	rows, err := r.qry.ListSubtasks(ctx, gen.ListSubtasksParams{
+		ParentID: pgtype.Int4{Int32: id, Valid: true}, OwnerID: owner,
+	})
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	out := make([]Task, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, taskFromRow(taskRow(row)))
+	}
+	if err := withTags(ctx, r.qry, out); err != nil {
+		return nil, dbError(err, "task")
+	}
+	return out, nil
+}
+
+// Blockers returns the live tasks blocking one of owner's live tasks by id.
+func (r *Repo) Blockers(ctx context.Context, owner string, id int32) ([]Task, error) {
+	if _, err := r.qry.GetTask(ctx, gen.GetTaskParams{ID: id, OwnerID: owner}); err != nil {
+		return nil, dbError(err, "task")
+	}
+	rows, err := r.qry.ListBlockers(ctx, gen.ListBlockersParams{TaskID: id, OwnerID: owner})
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	out := make([]Task, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, taskFromRow(taskRow(row)))
+	}
+	if err := withTags(ctx, r.qry, out); err != nil {
+		return nil, dbError(err, "task")
+	}
+	return out, nil
+}
+
+// Next returns up to limit of owner's actionable tasks (see nextActionable).
+func (r *Repo) Next(ctx context.Context, owner string, limit int) ([]Task, error) {
+	rows, err := r.qry.ListOpenTasks(ctx, owner)
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	open := make([]Task, 0, len(rows))
+	for _, row := range rows {
+		open = append(open, taskFromRow(taskRow(row)))
+	}
+	g, err := taskGraph(ctx, r.qry, owner)
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	out := nextActionable(open, g, limit)
+	if err := withTags(ctx, r.qry, out); err != nil {
+		return nil, dbError(err, "task")
+	}
+	return out, nil
+}
+
+// taskGraph loads owner's depGraph.
+func taskGraph(ctx context.Context, q *gen.Queries, owner string) (depGraph, error) {
+	edges, err := q.ListTaskEdges(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return newDepGraph(edges), nil
+}
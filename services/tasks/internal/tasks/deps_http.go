@@ -0,0 +1,178 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// DependencyManager is the optional capability behind the subtask and
+// blocker routes under /api/tasks/{id}. Like ListManager it is
+// all-or-nothing: without it each of them returns 501.
+type DependencyManager interface {
+	SetParent(ctx context.Context, id int32, parentID *int32) (Task, error)
+	AddBlocker(ctx context.Context, id, blockerID int32) error
+	RemoveBlocker(ctx context.Context, id, blockerID int32) error
+	Subtasks(ctx context.Context, id int32) ([]Task, error)
+	Blockers(ctx context.Context, id int32) ([]Task, error)
+}
+
+// TaskPlanner enables GET /api/tasks/next.
+type TaskPlanner interface {
+	Next(ctx context.Context, limit int) ([]Task, error)
+}
+
+// registerDependencyRoutes wires up the subtask and blocker routes under r
+// (see RegisterRoutes).
+func registerDependencyRoutes(r *gin.RouterGroup, svc TaskLister) {
+	// manager discovers the capability, writing a 501 if it is missing.
+	manager := func(c *gin.Context) (DependencyManager, bool) {
+		m, ok := svc.(DependencyManager)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("dependencies_not_supported", "dependencies not supported"))
+		}
+		return m, ok
+	}
+
+	// setParent serves both parent routes; parentID is nil for DELETE.
+	setParent := func(c *gin.Context, m DependencyManager, parentID *int32) {
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		t, err := m.SetParent(c.Request.Context(), id, parentID)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", etag(t))
+		c.JSON(http.StatusOK, t)
+	}
+
+	// PUT /api/tasks/{id}/parent/{parent_id}
+	r.PUT("/tasks/:id/parent/:parent_id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		parentID, ok := parseIDParam(c, "parent_id")
+		if !ok {
+			return
+		}
+		setParent(c, m, &parentID)
+	})
+
+	// DELETE /api/tasks/{id}/parent (make it a top-level task again)
+	r.DELETE("/tasks/:id/parent", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		setParent(c, m, nil)
+	})
+
+	// GET /api/tasks/{id}/subtasks
+	r.GET("/tasks/:id/subtasks", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		ts, err := m.Subtasks(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, ts)
+	})
+
+	// GET /api/tasks/{id}/blockers
+	r.GET("/tasks/:id/blockers", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		ts, err := m.Blockers(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, ts)
+	})
+
+	// PUT and DELETE /api/tasks/{id}/blockers/{blocker_id}
+	//
+	// PUT is idempotent: linking an existing blocker again is a 204 too.
+	blocker := func(link bool) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			m, ok := manager(c)
+			if !ok {
+				return
+			}
+			id, ok := parseID(c)
+			if !ok {
+				return
+			}
+			blockerID, ok := parseIDParam(c, "blocker_id")
+			if !ok {
+				return
+			}
+			var err error
+			if link {
+				err = m.AddBlocker(c.Request.Context(), id, blockerID)
+			} else {
+				err = m.RemoveBlocker(c.Request.Context(), id, blockerID)
+			}
+			if err != nil {
+				apperr.Write(c, err)
+				return
+			}
+			c.Status(http.StatusNoContent)
+		}
+	}
+	r.PUT("/tasks/:id/blockers/:blocker_id", blocker(true))
+	r.DELETE("/tasks/:id/blockers/:blocker_id", blocker(false))
+}
+
+// nextTasks serves GET /api/tasks/next?limit=, responding with
+// {"items": [Task...]}.
+func nextTasks(svc TaskLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := svc.(TaskPlanner)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("next_not_supported", "next not supported"))
+			return
+		}
+
+		limit := DefaultNextLimit
+		if v, has := c.GetQuery("limit"); has {
+			var err error
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 || limit > MaxNextLimit {
+				apperr.Write(c, invalidParam("limit"))
+				return
+			}
+		}
+
+		ts, err := p.Next(c.Request.Context(), limit)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		if ts == nil {
+			ts = []Task{} // "items": [], never null
+		}
+		c.JSON(http.StatusOK, gin.H{"items": ts})
+	}
+}
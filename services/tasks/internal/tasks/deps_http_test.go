@@ -0,0 +1,254 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// fakeDepSvc holds tasks 1..n and their blocker edges in memory, checking
+// links with the same depGraph the Repo uses.
+type fakeDepSvc struct {
+	listOnlySvc
+	tasks    []Task
+	blockers map[[2]int32]bool // {task, blocker}
+}
+
+func newFakeDepSvc(n int) *fakeDepSvc {
+	f := &fakeDepSvc{blockers: map[[2]int32]bool{}}
+	for i := 1; i <= n; i++ {
+		f.tasks = append(f.tasks, Task{ID: int32(i), Version: 1, Priority: PriorityNormal, Tags: []string{}})
+	}
+	return f
+}
+
+func (f *fakeDepSvc) task(id int32) (*Task, error) {
+	if id < 1 || int(id) > len(f.tasks) {
+		return nil, errTaskNotFound()
+	}
+	return &f.tasks[id-1], nil
+}
+
+func (f *fakeDepSvc) graph() depGraph {
+	var edges []gen.ListTaskEdgesRow
+	for e := range f.blockers {
+		edges = append(edges, gen.ListTaskEdgesRow{BeforeID: e[1], AfterID: e[0]})
+	}
+	for _, t := range f.tasks {
+		if t.ParentID != nil {
+			edges = append(edges, gen.ListTaskEdgesRow{BeforeID: t.ID, AfterID: *t.ParentID})
+		}
+	}
+	return newDepGraph(edges)
+}
+
+func (f *fakeDepSvc) SetParent(ctx context.Context, id int32, parentID *int32) (Task, error) {
+	if parentID != nil && *parentID == id {
+		return Task{}, invalidParam("parent_id")
+	}
+	t, err := f.task(id)
+	if err != nil {
+		return Task{}, err
+	}
+	if parentID != nil {
+		if _, err := f.task(*parentID); err != nil {
+			return Task{}, err
+		}
+		g := f.graph()
+		if t.ParentID != nil {
+			g.remove(id, *t.ParentID)
+		}
+		if err := g.checkEdge(id, *parentID); err != nil {
+			return Task{}, err
+		}
+	}
+	t.ParentID = parentID
+	t.Version++
+	return *t, nil
+}
+
+func (f *fakeDepSvc) AddBlocker(ctx context.Context, id, blockerID int32) error {
+	if blockerID == id {
+		return invalidParam("blocker_id")
+	}
+	for _, tid := range []int32{id, blockerID} {
+		if _, err := f.task(tid); err != nil {
+			return err
+		}
+	}
+	if err := f.graph().checkEdge(blockerID, id); err != nil {
+		return err
+	}
+	f.blockers[[2]int32{id, blockerID}] = true
+	return nil
+}
+
+func (f *fakeDepSvc) RemoveBlocker(ctx context.Context, id, blockerID int32) error {
+	if !f.blockers[[2]int32{id, blockerID}] {
+		return apperr.NotFound("dependency_not_found", "dependency not found")
+	}
+	delete(f.blockers, [2]int32{id, blockerID})
+	return nil
+}
+
+func (f *fakeDepSvc) Subtasks(ctx context.Context, id int32) ([]Task, error) {
+	if _, err := f.task(id); err != nil {
+		return nil, err
+	}
+	out := []Task{}
+	for _, t := range f.tasks {
+		if t.ParentID != nil && *t.ParentID == id {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDepSvc) Blockers(ctx context.Context, id int32) ([]Task, error) {
+	if _, err := f.task(id); err != nil {
+		return nil, err
+	}
+	out := []Task{}
+	for _, t := range f.tasks {
+		if f.blockers[[2]int32{id, t.ID}] {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeDepSvc) Next(ctx context.Context, limit int) ([]Task, error) {
+	return nextActionable(f.tasks, f.graph(), limit), nil
+}
+
+// nextIDs fetches GET /api/tasks/next and returns the ids it lists.
+func nextIDs(t *testing.T, h http.Handler) []int32 {
+	t.Helper()
+	w := doJSON(t, h, http.MethodGet, "/api/tasks/next", "")
+	var page struct{ Items []Task }
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &page) != nil {
+		t.Fatalf("GET next: %d %s", w.Code, w.Body.String())
+	}
+	var ids []int32
+	for _, t := range page.Items {
+		ids = append(ids, t.ID)
+	}
+	return ids
+}
+
+func TestDependencies_LinkUnlinkAndNext(t *testing.T) {
+	r := newTestRouter(newFakeDepSvc(3))
+
+	// 2 is a subtask of 1, and 3 blocks 2: only 3 can be worked on.
+	w := doJSON(t, r, http.MethodPut, "/api/tasks/2/parent/1", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` || !bytes.Contains(w.Body.Bytes(), []byte(`"parent_id":1`)) {
+		t.Fatalf("PUT parent: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/tasks/2/blockers/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT blocker: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPut, "/api/tasks/2/blockers/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("PUT blocker again: expected 204, got %d", w.Code)
+	}
+	if ids := nextIDs(t, r); len(ids) != 1 || ids[0] != 3 {
+		t.Fatalf("next = %v, want [3]", ids)
+	}
+
+	for path, want := range map[string]string{"/api/tasks/1/subtasks": `"id":2`, "/api/tasks/2/blockers": `"id":3`} {
+		if w := doJSON(t, r, http.MethodGet, path, ""); w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(want)) {
+			t.Fatalf("GET %s: %d %s", path, w.Code, w.Body.String())
+		}
+	}
+
+	if w := doJSON(t, r, http.MethodDelete, "/api/tasks/2/blockers/3", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE blocker: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodDelete, "/api/tasks/2/blockers/3", ""); w.Code != http.StatusNotFound || decodeProblem(t, w).Code != "dependency_not_found" {
+		t.Fatalf("DELETE blocker again: expected 404 dependency_not_found, got %d %s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodDelete, "/api/tasks/2/parent", "")
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(`"parent_id"`)) {
+		t.Fatalf("DELETE parent: %d %s", w.Code, w.Body.String())
+	}
+	if ids := nextIDs(t, r); len(ids) != 3 {
+		t.Fatalf("next = %v, want all three", ids)
+	}
+}
+
+func TestDependencies_RejectsCyclesAndBadIDs(t *testing.T) {
+	r := newTestRouter(newFakeDepSvc(3))
+	doJSON(t, r, http.MethodPut, "/api/tasks/2/blockers/1", "")
+	doJSON(t, r, http.MethodPut, "/api/tasks/3/parent/2", "")
+
+	// 2 waits on 1 (its blocker) and on 3 (its subtask), so neither 1 nor 3
+	// can wait on 2.
+	for _, tc := range []struct{ method, path, code string }{
+		{http.MethodPut, "/api/tasks/1/blockers/2", "dependency_cycle"},
+		{http.MethodPut, "/api/tasks/2/parent/3", "dependency_cycle"},
+		{http.MethodPut, "/api/tasks/3/blockers/2", "dependency_cycle"},
+		{http.MethodPut, "/api/tasks/1/parent/1", "invalid_parent_id"},
+		{http.MethodPut, "/api/tasks/1/blockers/1", "invalid_blocker_id"},
+		{http.MethodPut, "/api/tasks/1/blockers/x", "invalid_blocker_id"},
+		{http.MethodPut, "/api/tasks/1/blockers/9", "task_not_found"},
+		{http.MethodPut, "/api/tasks/9/parent/1", "task_not_found"},
+	} {
+		w := doJSON(t, r, tc.method, tc.path, "")
+		if p := decodeProblem(t, w); p.Code != tc.code {
+			t.Fatalf("%s %s: expected %s, got %d %+v", tc.method, tc.path, tc.code, w.Code, p)
+		}
+	}
+	w := doJSON(t, r, http.MethodPut, "/api/tasks/1/blockers/2", "")
+	if w.Code != http.StatusConflict || !bytes.Contains(w.Body.Bytes(), []byte("1 → 2 → 1")) {
+		t.Fatalf("cycle: expected 409 naming it, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(t, r, http.MethodGet, "/api/tasks/next?limit=0", ""); w.Code != http.StatusBadRequest || decodeProblem(t, w).Code != "invalid_limit" {
+		t.Fatalf("limit=0: expected 400 invalid_limit, got %d", w.Code)
+	}
+}
+
+func TestDependencies_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPut, "/api/tasks/1/parent/2"},
+		{http.MethodDelete, "/api/tasks/1/blockers/2"},
+		{http.MethodGet, "/api/tasks/1/subtasks"},
+		{http.MethodGet, "/api/tasks/next"},
+	} {
+		if w := doJSON(t, r, tc.method, tc.path, ""); w.Code != http.StatusNotImplemented {
+			t.Fatalf("%s %s: expected 501, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func Test_Server_Dependencies_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := newFakeDepSvc(3)
+
+	for _, tc := range []struct {
+		method, target string
+		code           int
+	}{
+		{http.MethodPut, "/api/tasks/2/parent/1", http.StatusOK},
+		{http.MethodPut, "/api/tasks/1/parent/2", http.StatusConflict},
+		{http.MethodPut, "/api/tasks/2/blockers/3", http.StatusNoContent},
+		{http.MethodGet, "/api/tasks/1/subtasks", http.StatusOK},
+		{http.MethodGet, "/api/tasks/2/blockers", http.StatusOK},
+		{http.MethodGet, "/api/tasks/next?limit=5", http.StatusOK},
+		{http.MethodDelete, "/api/tasks/2/blockers/3", http.StatusNoContent},
+		{http.MethodDelete, "/api/tasks/2/blockers/3", http.StatusNotFound},
+		{http.MethodDelete, "/api/tasks/2/parent", http.StatusOK},
+		{http.MethodGet, "/api/tasks/7/subtasks", http.StatusNotFound},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, nil)
+		if rec := serveAndValidateWith(t, doc, svc, req); rec.Code != tc.code {
+			t.Fatalf("%s %s: expected %d, got %d; body=%s", tc.method, tc.target, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+}
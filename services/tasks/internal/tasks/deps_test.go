@@ -0,0 +1,79 @@
+package tasks
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+func TestDepGraph_PathAndCycles(t *testing.T) {
+	// 1 blocks 2, 2 is a subtask of 3, 3 blocks 4.
+	g := newDepGraph([]gen.ListTaskEdgesRow{{BeforeID: 1, AfterID: 2}, {BeforeID: 2, AfterID: 3}, {BeforeID: 3, AfterID: 4}})
+
+	if p := g.path(1, 4); !slices.Equal(p, []int32{1, 2, 3, 4}) {
+		t.Fatalf("path(1, 4) = %v", p)
+	}
+	if p := g.path(4, 1); p != nil {
+		t.Fatalf("path(4, 1) = %v, want nil", p)
+	}
+	if n := g.reach(1); n != 3 {
+		t.Fatalf("reach(1) = %d, want 3", n)
+	}
+
+	if err := g.checkEdge(1, 4); err != nil {
+		t.Fatalf("1 → 4 is a shortcut, not a cycle: %v", err)
+	}
+	err := g.checkEdge(4, 1)
+	if !apperr.IsKind(err, apperr.KindConflict) {
+		t.Fatalf("4 → 1: expected a conflict, got %v", err)
+	}
+	if e := apperr.From(err); e.Code != "dependency_cycle" || e.Message != "would create a cycle: 1 → 2 → 3 → 4 → 1" {
+		t.Fatalf("4 → 1: got %q %q", e.Code, e.Message)
+	}
+
+	// Replacing 2's parent drops the 2 → 3 edge, so 4 may then lead to 2.
+	g.remove(2, 3)
+	if err := g.checkEdge(4, 2); err != nil {
+		t.Fatalf("after remove: %v", err)
+	}
+}
+
+func TestNextActionable_Ordering(t *testing.T) {
+	due := func(d int) *time.Time {
+		t := time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+	open := []Task{
+		{ID: 1, Priority: PriorityNormal},
+		{ID: 2, Priority: PriorityNormal, DueAt: due(9)},
+		{ID: 3, Priority: PriorityNormal, DueAt: due(2)},
+		{ID: 4, Priority: PriorityUrgent}, // blocked by 5
+		{ID: 5, Priority: PriorityLow},    // unblocks 4
+		{ID: 6, Priority: PriorityHigh},   // parent of 7
+		{ID: 7, Priority: PriorityNormal}, // unblocks 6 and, through it, 8
+		{ID: 8, Priority: PriorityNormal}, // blocked by 6
+	}
+	// 9 is done (not open), so its edge to 1 does not block 1.
+	g := newDepGraph([]gen.ListTaskEdgesRow{
+		{BeforeID: 5, AfterID: 4},
+		{BeforeID: 7, AfterID: 6},
+		{BeforeID: 6, AfterID: 8},
+		{BeforeID: 9, AfterID: 1},
+	})
+
+	var ids []int32
+	for _, t := range nextActionable(open, g, 10) {
+		ids = append(ids, t.ID)
+	}
+	// Normal-priority 7 unblocks two tasks, then the due ones soonest
+	// first, then 1, then low-priority 5.
+	if want := []int32{7, 3, 2, 1, 5}; !slices.Equal(ids, want) {
+		t.Fatalf("next = %v, want %v", ids, want)
+	}
+	if got := nextActionable(open, g, 2); len(got) != 2 || got[0].ID != 7 {
+		t.Fatalf("limit 2: got %+v", got)
+	}
+}
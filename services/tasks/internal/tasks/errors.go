@@ -0,0 +1,117 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// ErrInvalidCursor is returned when a ?cursor= value cannot be decoded
+// or was issued for a different sort order.
+var ErrInvalidCursor = apperr.Validation("invalid_cursor", "invalid cursor",
+	apperr.FieldError{Field: "cursor", Reason: "invalid"})
+
+// errTaskNotFound is returned by Get/Update/Delete for an unknown id.
+func errTaskNotFound() error {
+	return apperr.NotFound("task_not_found", "task not found")
+}
+
+// errVersionMismatch is returned by a conditional Update whose IfVersion is
+// no longer current.
+func errVersionMismatch() error {
+	return apperr.PreconditionFailed("version_mismatch",
+		"task was modified by someone else; fetch it again and retry")
+}
+
+// errEventSourcingOff is returned by the event store's reads and undo/redo
+// when the Repo is not in event-sourced mode.
+func errEventSourcingOff() error {
+	return apperr.NotImplemented("event_sourcing_disabled", "task history needs TASKS_EVENT_SOURCING")
+}
+
+// errHistoryUnavailable is returned by GetAsOf for a time before the task's
+// event stream begins, but after the task was created.
+func errHistoryUnavailable() error {
+	return apperr.NotFound("task_history_unavailable", "task history does not reach back that far")
+}
+
+// errUndoNotSupported is returned by Undo and Redo for a change they can't
+// revert, e.g. a move.
+func errUndoNotSupported() error {
+	return apperr.Conflict("change_not_undoable", "this change to the task can't be undone or redone", nil)
+}
+
+// Postgres SQLSTATE codes we translate (https://www.postgresql.org/docs/current/errcodes-appendix.html).
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+)
+
+// constraintFields maps CHECK constraints (see internal/db/migrate) to the
+// field-level error the validation layer would have reported, for rows that
+// get past it (e.g. a future caller that skips NormalizeTitle).
+var constraintFields = map[string]apperr.FieldError{
+	"tasks_title_not_blank":  {Field: "title", Reason: ReasonRequired},
+	"tasks_title_length":     {Field: "title", Reason: ReasonTooLong},
+	"tasks_title_no_control": {Field: "title", Reason: ReasonControlChars},
+	"tasks_title_nfc":        {Field: "title", Reason: "not_normalized"},
+	"lists_name_not_blank":   {Field: "name", Reason: ReasonRequired},
+	"lists_name_length":      {Field: "name", Reason: ReasonTooLong},
+	"lists_name_no_control":  {Field: "name", Reason: ReasonControlChars},
+}
+
+// dbError translates a pgx/Postgres error into an apperr.Error so that raw
+// driver messages never reach clients. what names the resource for
+// not-found codes, e.g. "task" → "task_not_found".
+func dbError(err error, what string) error {
+	if err == nil {
+		return nil
+	}
+	// Already translated, e.g. returned from inside a withTx callback.
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		return err
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperr.NotFound(what+"_not_found", what+" not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return apperr.Conflict(what+"_exists", what+" already exists", err)
+		case pgForeignKeyViolation:
+			return apperr.Conflict(what+"_reference", what+" references a missing or in-use row", err)
+		case pgCheckViolation, pgNotNullViolation:
+			e := apperr.Validation("invalid_"+what, what+" violates a constraint")
+			if fe, ok := constraintFields[pgErr.ConstraintName]; ok {
+				e.Code = "invalid_" + fe.Field
+				e.Message = fe.Field + " is invalid"
+				e.Fields = []apperr.FieldError{fe}
+			}
+			e.Err = err
+			return e
+		}
+		// Class 08: connection exceptions; 57P0x: server shutting down.
+		if len(pgErr.Code) == 5 && (pgErr.Code[:2] == "08" || pgErr.Code[:4] == "57P0") {
+			return apperr.Unavailable("db_unavailable", "database unavailable", err)
+		}
+		return apperr.Internal(err)
+	}
+
+	var netErr net.Error
+	var connErr *pgconn.ConnectError
+	if errors.As(err, &connErr) || errors.As(err, &netErr) ||
+		errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return apperr.Unavailable("db_unavailable", "database unavailable", err)
+	}
+	return apperr.Internal(err)
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+func TestDBError(t *testing.T) {
+	cases := []struct {
+		err  error
+		kind apperr.Kind
+		code string
+	}{
+		{pgx.ErrNoRows, apperr.KindNotFound, "task_not_found"},
+		{&pgconn.PgError{Code: "23505"}, apperr.KindConflict, "task_exists"},
+		{&pgconn.PgError{Code: "23514"}, apperr.KindValidation, "invalid_task"},
+		{&pgconn.PgError{Code: "23514", ConstraintName: "tasks_title_no_control"}, apperr.KindValidation, "invalid_title"},
+		{&pgconn.PgError{Code: "08006"}, apperr.KindUnavailable, "db_unavailable"},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), apperr.KindUnavailable, "db_unavailable"},
+		{&pgconn.PgError{Code: "42P01"}, apperr.KindInternal, "internal"},
+		{errors.New("boom"), apperr.KindInternal, "internal"},
+		{errVersionMismatch(), apperr.KindPreconditionFailed, "version_mismatch"},
+	}
+	for _, tc := range cases {
+		got := apperr.From(dbError(tc.err, "task"))
+		if got.Kind != tc.kind || got.Code != tc.code {
+			t.Errorf("dbError(%v) = %v/%s, want %v/%s", tc.err, got.Kind, got.Code, tc.kind, tc.code)
+		}
+	}
+	if dbError(nil, "task") != nil {
+		t.Errorf("dbError(nil) must be nil")
+	}
+}
@@ -0,0 +1,94 @@
+package tasks
+
+import "sync"
+
+// EventType says what happened to a task.
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// Event is a change to one task, as pushed to /api/tasks/stream clients.
+// For EventDeleted, Task is the task as moved to the trash (DeletedAt set).
+type Event struct {
+	Type EventType `json:"type"`
+	Task Task      `json:"task"`
+}
+
+// subscriberBuffer is how many events a subscriber may fall behind by
+// before the Broker gives up on it.
+const subscriberBuffer = 64
+
+// Broker fans events out to the subscribers in this process, each of whom
+// only receives events for tasks they own.
+//
+// Events reach the Broker from Postgres LISTEN (see Repo.Listen), not
+// directly from the Service, so that every replica sees every change.
+type Broker struct {
+	mu     sync.Mutex
+	subs   map[chan Event]string // channel → owner
+	closed bool
+}
+
+// NewBroker returns an empty Broker.
+func NewBroker() *Broker {
+	return &Broker{subs: map[chan Event]string{}}
+}
+
+// Subscribe registers a subscriber for owner's events. Call cancel when
+// done. The channel is closed on cancel, on Close, or if the subscriber
+// falls too far behind — consumers should then resync (re-fetch) and
+// subscribe again rather than silently miss changes.
+func (b *Broker) Subscribe(owner string) (events <-chan Event, cancel func()) {
+	ch := make(chan Event, subscriberBuffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	b.subs[ch] = owner
+	return ch, func() { b.remove(ch) }
+}
+
+// Publish delivers ev to the owner's subscribers without blocking.
+func (b *Broker) Publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch, owner := range b.subs {
+		if owner != ev.Task.OwnerID {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+			// Slow consumer: drop it rather than block everyone else.
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends every subscription, e.g. at shutdown so that long-lived
+// streams don't hold up draining. Later subscriptions end immediately.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+func (b *Broker) remove(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,59 @@
+package tasks
+
+import "testing"
+
+func TestBroker_DeliversOnlyToOwner(t *testing.T) {
+	b := NewBroker()
+	alice, cancelAlice := b.Subscribe("alice")
+	defer cancelAlice()
+	bob, cancelBob := b.Subscribe("bob")
+	defer cancelBob()
+
+	b.Publish(Event{Type: EventCreated, Task: Task{ID: 1, OwnerID: "alice"}})
+
+	select {
+	case ev := <-alice:
+		if ev.Type != EventCreated || ev.Task.ID != 1 {
+			t.Fatalf("unexpected event: %#v", ev)
+		}
+	default:
+		t.Fatalf("alice got nothing")
+	}
+	select {
+	case ev := <-bob:
+		t.Fatalf("bob got alice's event: %#v", ev)
+	default:
+	}
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	b := NewBroker()
+	events, cancel := b.Subscribe("alice")
+	defer cancel()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.Publish(Event{Type: EventUpdated, Task: Task{ID: int32(i), OwnerID: "alice"}})
+	}
+	n := 0
+	for range events {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("expected %d buffered events then close, got %d", subscriberBuffer, n)
+	}
+}
+
+func TestBroker_CloseEndsSubscriptions(t *testing.T) {
+	b := NewBroker()
+	events, cancel := b.Subscribe("alice")
+	b.Close()
+	cancel() // safe after Close
+
+	if _, ok := <-events; ok {
+		t.Fatalf("expected closed channel")
+	}
+	late, _ := b.Subscribe("alice")
+	if _, ok := <-late; ok {
+		t.Fatalf("expected subscriptions after Close to end immediately")
+	}
+}
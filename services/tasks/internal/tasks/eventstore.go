@@ -0,0 +1,523 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/requestid"
+)
+
+// Event-sourced mode (TASKS_EVENT_SOURCING) keeps a stream of events per
+// task in task_events, appended by recordChange in the same transaction as
+// the change itself, so the tasks table is a projection of the streams.
+// The streams are what GetAsOf replays and what Undo and Redo walk.
+//
+// One change appends one event per kind of thing it did, e.g. a PATCH that
+// renames and completes a task appends a taskRenamed and a taskCompleted.
+// Undo and Redo append compensating events, marked with the change they
+// revert or re-apply; nothing in a stream is ever rewritten.
+
+// storedEventType is the type of an event in a task's stream.
+type storedEventType string
+
+const (
+	// taskImported starts a stream with the whole task as it was, for a
+	// task that was created, or last changed, while the mode was off.
+	taskImported       storedEventType = "TaskImported"
+	taskCreated        storedEventType = "TaskCreated"
+	taskRenamed        storedEventType = "TaskRenamed"
+	taskCompleted      storedEventType = "TaskCompleted"
+	taskReopened       storedEventType = "TaskReopened"
+	taskDetailsChanged storedEventType = "TaskDetailsChanged"
+	taskMoved          storedEventType = "TaskMoved"
+	taskDeleted        storedEventType = "TaskDeleted"
+	taskRestored       storedEventType = "TaskRestored"
+)
+
+// eventData is an event's data: the task fields it changed, with their old
+// and new values. taskCreated and taskImported have no Before, and their
+// After is the whole task.
+type eventData struct {
+	Before taskFields `json:"before,omitempty"`
+	After  taskFields `json:"after"`
+}
+
+// storedEvent is an event about to be appended.
+type storedEvent struct {
+	Type storedEventType
+	Data eventData
+}
+
+// eventStoreKey marks the context of a transaction in event-sourced mode
+// (see Repo.withTxConn); revertKey carries what an undo or redo reverts.
+type (
+	eventStoreKey struct{}
+	revertKey     struct{}
+)
+
+func withEventStore(ctx context.Context) context.Context {
+	return context.WithValue(ctx, eventStoreKey{}, true)
+}
+
+func eventStoreOn(ctx context.Context) bool {
+	on, _ := ctx.Value(eventStoreKey{}).(bool)
+	return on
+}
+
+// revert is the change an undo or redo is reverting or re-applying, by the
+// seq it starts at.
+type revert struct {
+	Undoes, Redoes pgtype.Int4
+}
+
+func withRevert(ctx context.Context, rv revert) context.Context {
+	return context.WithValue(ctx, revertKey{}, rv)
+}
+
+// appendEvents appends the events of a change to the task's stream, for
+// recordChange: before is the task as snapshot returned it, or nil for a
+// created task. It does nothing unless ctx is an event-sourced transaction.
+func appendEvents(ctx context.Context, q *gen.Queries, before *Task, ev Event) error {
+	if !eventStoreOn(ctx) {
+		return nil
+	}
+	var seq int32
+	var evs []storedEvent
+	if before == nil {
+		after, err := fieldsOf(ev.Task)
+		if err != nil {
+			return err
+		}
+		evs = []storedEvent{{Type: taskCreated, Data: eventData{After: after}}}
+	} else {
+		last, err := q.LastTaskEvent(ctx, ev.Task.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+		seq = last.Seq
+		// A stream that doesn't end where the task was (or doesn't exist)
+		// missed changes made while the mode was off: start over from the
+		// task as it is now.
+		if errors.Is(err, pgx.ErrNoRows) || last.Version != before.Version {
+			full, err := fieldsOf(*before)
+			if err != nil {
+				return err
+			}
+			seq++
+			if err := appendEvent(ctx, q, ev.Task, seq, seq, storedEvent{Type: taskImported, Data: eventData{After: full}},
+				revert{}, timestamptz(&before.UpdatedAt)); err != nil {
+				return err
+			}
+		}
+		old, changed, err := diffFields(*before, ev.Task)
+		if err != nil {
+			return err
+		}
+		// Every event records the version it leaves the task at, which
+		// LastTaskEvent reads back, even if the change didn't bump it.
+		if _, ok := changed["version"]; !ok && len(changed) > 0 {
+			v, err := json.Marshal(ev.Task.Version)
+			if err != nil {
+				return err
+			}
+			old["version"], changed["version"] = v, v
+		}
+		evs = splitChange(old, changed)
+	}
+
+	rv, _ := ctx.Value(revertKey{}).(revert)
+	change := seq + 1
+	for _, e := range evs {
+		seq++
+		if err := appendEvent(ctx, q, ev.Task, seq, change, e, rv, pgtype.Timestamptz{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func appendEvent(ctx context.Context, q *gen.Queries, t Task, seq, change int32, e storedEvent, rv revert, at pgtype.Timestamptz) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	return q.AppendTaskEvent(ctx, gen.AppendTaskEventParams{
+		TaskID:     t.ID,
+		Seq:        seq,
+		Change:     change,
+		OwnerID:    t.OwnerID,
+		Type:       string(e.Type),
+		Data:       data,
+		Undoes:     rv.Undoes,
+		Redoes:     rv.Redoes,
+		Actor:      actor(ctx),
+		RequestID:  requestid.FromContext(ctx),
+		OccurredAt: at,
+	})
+}
+
+// eventOrder is the order splitChange appends a change's events in.
+var eventOrder = []storedEventType{
+	taskRestored, taskRenamed, taskCompleted, taskReopened, taskDetailsChanged, taskMoved, taskDeleted,
+}
+
+// splitChange turns the fields a change changed (see diffFields) into its
+// events. version and updated_at, which every change bumps, go with each
+// event, so any prefix of a stream replays to a consistent task; a change
+// that bumped nothing else is a taskDetailsChanged of just those.
+func splitChange(old, changed taskFields) []storedEvent {
+	byType := map[storedEventType][]string{}
+	if _, ok := changed["version"]; ok {
+		byType[taskDetailsChanged] = nil
+	}
+	for k, v := range changed {
+		var typ storedEventType
+		switch k {
+		case "version", "updated_at":
+			continue
+		case "title":
+			typ = taskRenamed
+		case "done":
+			typ = taskReopened
+			if string(v) == "true" {
+				typ = taskCompleted
+			}
+		case "deleted_at":
+			typ = taskDeleted
+			if string(v) == "null" {
+				typ = taskRestored
+			}
+		case "list_id", "position", "parent_id":
+			typ = taskMoved
+		default:
+			typ = taskDetailsChanged
+		}
+		byType[typ] = append(byType[typ], k)
+	}
+	if len(byType) > 1 && byType[taskDetailsChanged] == nil {
+		delete(byType, taskDetailsChanged)
+	}
+
+	var evs []storedEvent
+	for _, typ := range eventOrder {
+		fields, ok := byType[typ]
+		if !ok {
+			continue
+		}
+		d := eventData{Before: taskFields{}, After: taskFields{}}
+		for _, k := range append(fields, "version", "updated_at") {
+			if v, ok := changed[k]; ok {
+				d.Before[k], d.After[k] = old[k], v
+			}
+		}
+		evs = append(evs, storedEvent{Type: typ, Data: d})
+	}
+	return evs
+}
+
+// GetAsOf returns a task as it was at a point in time, replayed from its
+// events. It returns the same not-found error as Get if the task did not
+// exist then, or was in the trash.
+func (s *Service) GetAsOf(ctx context.Context, id int32, at time.Time) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	return s.repo.GetAsOf(ctx, owner, id, at)
+}
+
+// Undo reverts the latest change to a task that is neither undone already
+// nor an undo itself, by appending events that put back what it changed.
+// Undoing a creation moves the task to the trash.
+func (s *Service) Undo(ctx context.Context, id int32) (Task, error) {
+	return s.revert(ctx, id, true)
+}
+
+// Redo re-applies the change the latest Undo of a task reverted, as long as
+// no other change has been made to the task since.
+func (s *Service) Redo(ctx context.Context, id int32) (Task, error) {
+	return s.revert(ctx, id, false)
+}
+
+func (s *Service) revert(ctx context.Context, id int32, undo bool) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := s.repo.revert(ctx, owner, id, undo)
+	if err != nil {
+		return Task{}, err
+	}
+	typ := EventUpdated
+	if t.DeletedAt != nil {
+		typ = EventDeleted
+	}
+	s.emit(ctx, Event{Type: typ, Task: t})
+	return t, nil
+}
+
+// GetAsOf replays one of owner's tasks up to at. A task the mode has not
+// recorded a change to has no stream yet, and is as it is now.
+func (r *Repo) GetAsOf(ctx context.Context, owner string, id int32, at time.Time) (Task, error) {
+	if !r.eventSourced {
+		return Task{}, errEventSourcingOff()
+	}
+	rows, err := r.qry.ListTaskEvents(ctx, gen.ListTaskEventsParams{TaskID: id, OwnerID: owner})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	if len(rows) == 0 {
+		t, err := r.Get(ctx, owner, id)
+		if err != nil {
+			return Task{}, err
+		}
+		if at.Before(t.CreatedAt) {
+			return Task{}, errTaskNotFound()
+		}
+		if at.Before(t.UpdatedAt) {
+			return Task{}, errHistoryUnavailable()
+		}
+		return t, nil
+	}
+
+	t, ok, err := replay(rows, at)
+	if err != nil {
+		return Task{}, apperr.Internal(err)
+	}
+	if !ok {
+		// at is before the stream begins: before the task was created, or
+		// before the change it was imported at.
+		first, _, err := replay(rows[:1], rows[0].OccurredAt.Time)
+		if err != nil {
+			return Task{}, apperr.Internal(err)
+		}
+		if at.Before(first.CreatedAt) {
+			return Task{}, errTaskNotFound()
+		}
+		return Task{}, errHistoryUnavailable()
+	}
+	if t.DeletedAt != nil {
+		return Task{}, errTaskNotFound()
+	}
+	return t, nil
+}
+
+// replay folds the events of a stream that occurred at or before at into
+// the task as it was then. ok is false if the stream begins after at.
+func replay(rows []gen.ListTaskEventsRow, at time.Time) (t Task, ok bool, err error) {
+	var state taskFields
+	for _, row := range rows {
+		if row.OccurredAt.Time.After(at) {
+			break
+		}
+		var d eventData
+		if err := json.Unmarshal(row.Data, &d); err != nil {
+			return Task{}, false, err
+		}
+		switch storedEventType(row.Type) {
+		case taskCreated, taskImported:
+			state = taskFields{}
+		}
+		if state == nil {
+			continue
+		}
+		for k, v := range d.After {
+			if string(v) == "null" {
+				delete(state, k)
+			} else {
+				state[k] = v
+			}
+		}
+	}
+	if state == nil {
+		return Task{}, false, nil
+	}
+	b, err := json.Marshal(state)
+	if err != nil {
+		return Task{}, false, err
+	}
+	return t, true, json.Unmarshal(b, &t)
+}
+
+// storedChange is one change in a stream: its events, merged.
+type storedChange struct {
+	Seq            int32 // of its first event
+	Type           storedEventType
+	Undoes, Redoes pgtype.Int4
+	Data           eventData
+}
+
+// changesOf groups a stream's events by change.
+func changesOf(rows []gen.ListTaskEventsRow) ([]storedChange, error) {
+	var changes []storedChange
+	for _, row := range rows {
+		var d eventData
+		if err := json.Unmarshal(row.Data, &d); err != nil {
+			return nil, err
+		}
+		if len(changes) == 0 || changes[len(changes)-1].Seq != row.Change {
+			changes = append(changes, storedChange{
+				Seq:    row.Change,
+				Type:   storedEventType(row.Type),
+				Undoes: row.Undoes,
+				Redoes: row.Redoes,
+				Data:   eventData{Before: taskFields{}, After: taskFields{}},
+			})
+		}
+		c := &changes[len(changes)-1]
+		for k, v := range d.Before {
+			c.Data.Before[k] = v
+		}
+		for k, v := range d.After {
+			c.Data.After[k] = v
+		}
+	}
+	return changes, nil
+}
+
+// undoStacks walks a stream's changes, returning the ones Undo can revert
+// (done, latest last) and the ones Redo can re-apply (undone, latest
+// last). Any change other than an undo or redo clears undone, and an
+// import clears both: what came before it is not known to lead up to it.
+func undoStacks(changes []storedChange) (done, undone []storedChange) {
+	for _, c := range changes {
+		switch {
+		case c.Type == taskImported:
+			done, undone = nil, nil
+		case c.Undoes.Valid:
+			if n := len(done); n > 0 {
+				undone, done = append(undone, done[n-1]), done[:n-1]
+			}
+		case c.Redoes.Valid:
+			if n := len(undone); n > 0 {
+				done, undone = append(done, undone[n-1]), undone[:n-1]
+			}
+		default:
+			done, undone = append(done, c), nil
+		}
+	}
+	return done, undone
+}
+
+// revertFields is what undoing c (or redoing it, if !undo) sets the task's
+// fields to.
+func (c storedChange) revertFields(undo bool) taskFields {
+	if c.Type == taskCreated {
+		// Undoing a creation trashes the task; any deleted_at but null
+		// does (see applyFields).
+		if undo {
+			return taskFields{"deleted_at": json.RawMessage("true")}
+		}
+		return taskFields{"deleted_at": json.RawMessage("null")}
+	}
+	if undo {
+		return c.Data.Before
+	}
+	return c.Data.After
+}
+
+// revert undoes (or redoes) the latest change Undo (or Redo) can, for
+// Service.Undo and Service.Redo.
+func (r *Repo) revert(ctx context.Context, owner string, id int32, undo bool) (Task, error) {
+	if !r.eventSourced {
+		return Task{}, errEventSourcingOff()
+	}
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		// Lock the task first, so the stream can't grow under us.
+		cur, err := snapshot(ctx, q, owner, id)
+		if err != nil {
+			return err
+		}
+		if cur == nil {
+			return errTaskNotFound()
+		}
+		rows, err := q.ListTaskEvents(ctx, gen.ListTaskEventsParams{TaskID: id, OwnerID: owner})
+		if err != nil {
+			return err
+		}
+		changes, err := changesOf(rows)
+		if err != nil {
+			return err
+		}
+		done, undone := undoStacks(changes)
+
+		var target storedChange
+		var rv revert
+		if undo {
+			if len(done) == 0 {
+				return apperr.Conflict("nothing_to_undo", "task has no change to undo", nil)
+			}
+			target = done[len(done)-1]
+			rv.Undoes = pgtype.Int4{Int32: target.Seq, Valid: true}
+		} else {
+			if len(undone) == 0 {
+				return apperr.Conflict("nothing_to_redo", "task has no undone change to redo", nil)
+			}
+			target = undone[len(undone)-1]
+			rv.Redoes = pgtype.Int4{Int32: target.Seq, Valid: true}
+		}
+		t, err = applyFields(withRevert(ctx, rv), q, owner, id, target.revertFields(undo))
+		return err
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// applyFields sets fields (in Task's JSON form) on a task through the same
+// paths as Delete, Restore and Update, so an undo or redo is recorded like
+// any other change. Moves can't be reverted this way: the list and parent
+// they changed may have changed since.
+func applyFields(ctx context.Context, q *gen.Queries, owner string, id int32, fields taskFields) (Task, error) {
+	if d, ok := fields["deleted_at"]; ok {
+		if string(d) == "null" {
+			return restoreTask(ctx, q, owner, id)
+		}
+		return deleteTask(ctx, q, owner, id)
+	}
+	patch := taskFields{}
+	for k, v := range fields {
+		switch k {
+		case "version", "updated_at", "next_occurrence_at":
+			// Bumped, or derived from rrule, by updateTask.
+		case "title", "done", "description", "due_at", "remind_at", "priority":
+			patch[k] = v
+		case "tags":
+			if string(v) == "null" {
+				v = json.RawMessage("[]")
+			}
+			patch[k] = v
+		case "rrule":
+			if string(v) == "null" {
+				v = json.RawMessage(`""`)
+			}
+			patch[k] = v
+		default:
+			return Task{}, errUndoNotSupported()
+		}
+	}
+	if len(patch) == 0 {
+		return Task{}, errUndoNotSupported()
+	}
+	b, err := json.Marshal(patch)
+	if err != nil {
+		return Task{}, err
+	}
+	var p TaskPatch
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Task{}, err
+	}
+	if p, err = p.normalize(); err != nil {
+		return Task{}, err
+	}
+	return updateTask(ctx, q, owner, id, p)
+}
@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// TaskHistorian enables GET /api/tasks/{id}?as_of=; without it that query
+// returns 501.
+type TaskHistorian interface {
+	GetAsOf(ctx context.Context, id int32, at time.Time) (Task, error)
+}
+
+// TaskUndoer enables POST /api/tasks/{id}/undo and /redo; without it both
+// return 501.
+type TaskUndoer interface {
+	Undo(ctx context.Context, id int32) (Task, error)
+	Redo(ctx context.Context, id int32) (Task, error)
+}
+
+// getAsOf serves GET /api/tasks/{id}?as_of=<RFC 3339 time>: the task as it
+// was then. It has no ETag, as it is not the version a PATCH would apply to.
+func getAsOf(c *gin.Context, svc TaskLister, id int32) {
+	h, ok := svc.(TaskHistorian)
+	if !ok {
+		apperr.Write(c, apperr.NotImplemented("history_not_supported", "task history not supported"))
+		return
+	}
+	at, err := time.Parse(time.RFC3339, c.Query("as_of"))
+	if err != nil {
+		apperr.Write(c, invalidParam("as_of"))
+		return
+	}
+	t, err := h.GetAsOf(c.Request.Context(), id, at)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, t)
+}
+
+// registerUndoRoutes wires up the undo and redo routes under r (see
+// RegisterRoutes).
+func registerUndoRoutes(r *gin.RouterGroup, svc TaskLister) {
+	handle := func(undo bool) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			u, ok := svc.(TaskUndoer)
+			if !ok {
+				apperr.Write(c, apperr.NotImplemented("undo_not_supported", "undo not supported"))
+				return
+			}
+			id, ok := parseID(c)
+			if !ok {
+				return
+			}
+			revert := u.Redo
+			if undo {
+				revert = u.Undo
+			}
+			t, err := revert(c.Request.Context(), id)
+			if err != nil {
+				apperr.Write(c, err)
+				return
+			}
+			c.Header("ETag", etag(t))
+			c.JSON(http.StatusOK, t)
+		}
+	}
+
+	// POST /api/tasks/{id}/undo
+	r.POST("/tasks/:id/undo", handle(true))
+
+	// POST /api/tasks/{id}/redo
+	r.POST("/tasks/:id/redo", handle(false))
+}
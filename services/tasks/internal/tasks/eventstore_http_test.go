@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// fakeHistorySvc replays and reverts task 7; other ids are not found.
+type fakeHistorySvc struct {
+	listOnlySvc
+	asOf   time.Time
+	undone bool
+}
+
+func (f *fakeHistorySvc) Get(ctx context.Context, id int32) (Task, error) {
+	return Task{ID: id, Title: "now", Version: 3}, nil
+}
+
+func (f *fakeHistorySvc) GetAsOf(ctx context.Context, id int32, at time.Time) (Task, error) {
+	if id != 7 {
+		return Task{}, errTaskNotFound()
+	}
+	f.asOf = at
+	return Task{ID: id, Title: "then", Version: 1, Priority: PriorityNormal, Tags: []string{}, CreatedAt: at, UpdatedAt: at}, nil
+}
+
+func (f *fakeHistorySvc) Undo(ctx context.Context, id int32) (Task, error) {
+	if id != 7 {
+		return Task{}, errTaskNotFound()
+	}
+	f.undone = true
+	return Task{ID: id, Title: "undone", Version: 4, Priority: PriorityNormal, Tags: []string{}}, nil
+}
+
+func (f *fakeHistorySvc) Redo(ctx context.Context, id int32) (Task, error) {
+	return Task{}, apperr.Conflict("nothing_to_redo", "task has no undone change to redo", nil)
+}
+
+func TestGetAsOf(t *testing.T) {
+	svc := &fakeHistorySvc{}
+	r := newTestRouter(svc)
+
+	w := doJSON(t, r, http.MethodGet, "/api/tasks/7?as_of=2026-05-01T09:00:00Z", "")
+	if w.Code != http.StatusOK || !svc.asOf.Equal(time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected 200 as of the given time, got %d (%v); body=%s", w.Code, svc.asOf, w.Body.String())
+	}
+	if w.Header().Get("ETag") != "" {
+		t.Fatalf("a past version must not carry an ETag")
+	}
+
+	// Without as_of it is a plain Get.
+	if w := doJSON(t, r, http.MethodGet, "/api/tasks/7", ""); w.Code != http.StatusOK || w.Header().Get("ETag") != `"3"` {
+		t.Fatalf("plain get: %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	for _, path := range []string{"/api/tasks/7?as_of=yesterday", "/api/tasks/7?as_of="} {
+		w := doJSON(t, r, http.MethodGet, path, "")
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "as_of" {
+			t.Fatalf("%s: expected 400 on as_of, got %d %+v", path, w.Code, p)
+		}
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	svc := &fakeHistorySvc{}
+	r := newTestRouter(svc)
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks/7/undo", "")
+	if w.Code != http.StatusOK || !svc.undone || w.Header().Get("ETag") != `"4"` {
+		t.Fatalf("undo: expected 200 with an ETag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	w = doJSON(t, r, http.MethodPost, "/api/tasks/7/redo", "")
+	if p := decodeProblem(t, w); w.Code != http.StatusConflict || p.Code != "nothing_to_redo" {
+		t.Fatalf("redo: expected 409 nothing_to_redo, got %d %+v", w.Code, p)
+	}
+	if w := doJSON(t, r, http.MethodPost, "/api/tasks/abc/undo", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("bad id: expected 400, got %d", w.Code)
+	}
+}
+
+func TestEventStore_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+	if w := doJSON(t, r, http.MethodGet, "/api/tasks/1?as_of=2026-05-01T09:00:00Z", ""); w.Code != http.StatusNotImplemented {
+		t.Fatalf("as_of: expected 501, got %d", w.Code)
+	}
+	for _, path := range []string{"/api/tasks/1/undo", "/api/tasks/1/redo"} {
+		if w := doJSON(t, r, http.MethodPost, path, ""); w.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: expected 501, got %d", path, w.Code)
+		}
+	}
+}
+
+func Test_Server_EventStore_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := &fakeHistorySvc{}
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/tasks/7?as_of=2026-05-01T09:00:00Z", nil),
+		httptest.NewRequest(http.MethodGet, "/api/tasks/8?as_of=2026-05-01T09:00:00Z", nil),
+		httptest.NewRequest(http.MethodPost, "/api/tasks/7/undo", nil),
+		httptest.NewRequest(http.MethodPost, "/api/tasks/7/redo", nil),
+	} {
+		serveAndValidateWith(t, doc, svc, req)
+	}
+}
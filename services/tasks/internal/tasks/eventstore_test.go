@@ -0,0 +1,134 @@
+package tasks
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+func TestSplitChange(t *testing.T) {
+	before := Task{ID: 3, Title: "Pay rent", Priority: PriorityNormal, Version: 1}
+	after := before
+	after.Title, after.Done, after.Priority, after.Version = "Pay rent!", true, PriorityHigh, 2
+
+	old, changed, err := diffFields(before, after)
+	if err != nil {
+		t.Fatalf("diffFields: %v", err)
+	}
+	evs := splitChange(old, changed)
+	if len(evs) != 3 || evs[0].Type != taskRenamed || evs[1].Type != taskCompleted || evs[2].Type != taskDetailsChanged {
+		t.Fatalf("unexpected events: %+v", evs)
+	}
+	for _, e := range evs {
+		if string(e.Data.Before["version"]) != "1" || string(e.Data.After["version"]) != "2" || len(e.Data.After) != 2 {
+			t.Fatalf("%s: every event carries one field and the version: %+v", e.Type, e.Data)
+		}
+	}
+	if string(evs[0].Data.After["title"]) != `"Pay rent!"` || string(evs[2].Data.Before["priority"]) != `"normal"` {
+		t.Fatalf("unexpected data: %+v %+v", evs[0].Data, evs[2].Data)
+	}
+
+	// Trashing, and a change that only bumped the version.
+	now := time.Now()
+	trashed := after
+	trashed.DeletedAt, trashed.Version = &now, 3
+	old, changed, _ = diffFields(after, trashed)
+	if evs := splitChange(old, changed); len(evs) != 1 || evs[0].Type != taskDeleted {
+		t.Fatalf("delete: %+v", evs)
+	}
+	bumped := after
+	bumped.Version = 3
+	old, changed, _ = diffFields(after, bumped)
+	if evs := splitChange(old, changed); len(evs) != 1 || evs[0].Type != taskDetailsChanged || len(evs[0].Data.After) != 1 {
+		t.Fatalf("version only: %+v", evs)
+	}
+}
+
+// eventRow builds a stored event as ListTaskEvents returns it.
+func eventRow(t *testing.T, seq, change int32, typ storedEventType, at time.Time, d eventData) gen.ListTaskEventsRow {
+	t.Helper()
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return gen.ListTaskEventsRow{
+		TaskID: 3, Seq: seq, Change: change, Type: string(typ), Data: data,
+		OccurredAt: pgtype.Timestamptz{Time: at, Valid: true},
+	}
+}
+
+func TestReplay(t *testing.T) {
+	t0 := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	created := Task{ID: 3, Title: "Pay rent", Priority: PriorityNormal, Version: 1, CreatedAt: t0, DueAt: &t0}
+	whole, err := fieldsOf(created)
+	if err != nil {
+		t.Fatalf("fieldsOf: %v", err)
+	}
+	rows := []gen.ListTaskEventsRow{
+		eventRow(t, 1, 1, taskCreated, t0, eventData{After: whole}),
+		eventRow(t, 2, 2, taskRenamed, t0.Add(time.Hour), eventData{
+			Before: taskFields{"title": json.RawMessage(`"Pay rent"`), "version": json.RawMessage("1")},
+			After:  taskFields{"title": json.RawMessage(`"Pay rent!"`), "version": json.RawMessage("2")},
+		}),
+		eventRow(t, 3, 3, taskDetailsChanged, t0.Add(2*time.Hour), eventData{
+			Before: taskFields{"due_at": json.RawMessage(`"2026-05-01T09:00:00Z"`), "version": json.RawMessage("2")},
+			After:  taskFields{"due_at": json.RawMessage("null"), "version": json.RawMessage("3")},
+		}),
+	}
+
+	if _, ok, err := replay(rows, t0.Add(-time.Second)); ok || err != nil {
+		t.Fatalf("before the stream: ok=%v err=%v", ok, err)
+	}
+	got, ok, err := replay(rows, t0.Add(90*time.Minute))
+	if !ok || err != nil || got.Title != "Pay rent!" || got.Version != 2 || got.DueAt == nil {
+		t.Fatalf("after the rename: %+v ok=%v err=%v", got, ok, err)
+	}
+	got, _, _ = replay(rows, t0.Add(24*time.Hour))
+	if got.Title != "Pay rent!" || got.Version != 3 || got.DueAt != nil {
+		t.Fatalf("cleared due_at: %+v", got)
+	}
+}
+
+func TestUndoStacks(t *testing.T) {
+	change := func(seq int32, typ storedEventType, undoes, redoes int32) storedChange {
+		return storedChange{
+			Seq: seq, Type: typ,
+			Undoes: pgtype.Int4{Int32: undoes, Valid: undoes != 0},
+			Redoes: pgtype.Int4{Int32: redoes, Valid: redoes != 0},
+		}
+	}
+	seqs := func(cs []storedChange) (s []int32) {
+		for _, c := range cs {
+			s = append(s, c.Seq)
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		name         string
+		changes      []storedChange
+		done, undone []int32
+	}{
+		{"plain", []storedChange{change(1, taskCreated, 0, 0), change(2, taskRenamed, 0, 0)}, []int32{1, 2}, nil},
+		{"undo", []storedChange{change(1, taskCreated, 0, 0), change(2, taskRenamed, 0, 0), change(3, taskRenamed, 2, 0)}, []int32{1}, []int32{2}},
+		{"undo then redo", []storedChange{
+			change(1, taskCreated, 0, 0), change(2, taskRenamed, 0, 0), change(3, taskRenamed, 2, 0), change(4, taskRenamed, 0, 2),
+		}, []int32{1, 2}, nil},
+		{"a new change drops the undone", []storedChange{
+			change(1, taskCreated, 0, 0), change(2, taskRenamed, 0, 0), change(3, taskRenamed, 2, 0), change(4, taskCompleted, 0, 0),
+		}, []int32{1, 4}, nil},
+		{"an import starts over", []storedChange{change(1, taskCreated, 0, 0), change(2, taskImported, 0, 0), change(3, taskRenamed, 0, 0)}, []int32{3}, nil},
+	} {
+		done, undone := undoStacks(tc.changes)
+		if got := seqs(done); !slices.Equal(got, tc.done) {
+			t.Fatalf("%s: done = %v, want %v", tc.name, got, tc.done)
+		}
+		if got := seqs(undone); !slices.Equal(got, tc.undone) {
+			t.Fatalf("%s: undone = %v, want %v", tc.name, got, tc.undone)
+		}
+	}
+}
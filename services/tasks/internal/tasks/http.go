@@ -3,13 +3,17 @@ package tasks
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
 )
 
 // TaskLister is a *narrow interface* that this HTTP layer depends on.
-// It only requires a List method.
+// It only requires a List method, which takes filter/paging options.
 //
 // This is deliberate: it decouples the HTTP code from the full Service struct.
 // In tests, we can pass in a fake that satisfies this interface.
@@ -19,63 +23,396 @@ import (
 // A Context carries request-scoped data: cancellation, deadlines/timeouts, and key–value metadata.
 // It’s passed down so DB calls and services can stop early or propagate trace/user info.
 type TaskLister interface {
-	List(ctx context.Context) ([]Task, error)
+	List(ctx context.Context, opts ListOptions) (TaskPage, error)
 }
 
 // taskCreator is an optional capability discovered at runtime.
 // If the provided service also implements this, POST /api/tasks will succeed.
 // Otherwise the handler returns 501 Not Implemented.
 type taskCreator interface {
-	Create(ctx context.Context, title string) (Task, error)
+	Create(ctx context.Context, n NewTask) (Task, error)
+}
+
+// idempotentCreator is the capability behind the Idempotency-Key header on
+// POST /api/tasks; without it a request carrying the header gets a 501
+// rather than silently losing its retry protection.
+type idempotentCreator interface {
+	CreateIdempotent(ctx context.Context, key string, n NewTask) (t Task, replayed bool, err error)
+}
+
+// TaskGetter, TaskUpdater and TaskDeleter are further optional capabilities,
+// discovered the same way as taskCreator. Each one enables one of the
+// per-task routes under /api/tasks/{id}; without it that route returns 501.
+type TaskGetter interface {
+	Get(ctx context.Context, id int32) (Task, error)
+}
+
+type TaskUpdater interface {
+	Update(ctx context.Context, id int32, p TaskPatch) (Task, error)
+}
+
+type TaskDeleter interface {
+	Delete(ctx context.Context, id int32) error
+}
+
+// TaskRestorer enables POST /api/tasks/{id}/restore (undo a Delete).
+type TaskRestorer interface {
+	Restore(ctx context.Context, id int32) (Task, error)
 }
 
 // RegisterRoutes wires up HTTP endpoints under a given router group.
 // The svc argument only needs to satisfy TaskLister; if it also implements
-// taskCreator, POST /api/tasks will be enabled.
+// taskCreator, POST /api/tasks will be enabled, and likewise TaskGetter,
+// TaskUpdater and TaskDeleter enable GET/PATCH/DELETE /api/tasks/{id} and
+// TaskRestorer POST /api/tasks/{id}/restore.
+// TaskBatcher enables POST /api/tasks:batch, TaskSearcher GET
+// /api/tasks/search, WebhookManager /api/webhooks, ListManager /api/lists
+// and TaskMover POST /api/tasks/{id}/move. DependencyManager enables the
+// /api/tasks/{id}/parent, /subtasks and /blockers routes, TaskPlanner
+// GET /api/tasks/next, ReminderPreferences /api/reminders/preferences,
+// TaskAuditor GET /api/audit and /api/tasks/{id}/history, TaskHistorian
+// GET /api/tasks/{id}?as_of= and TaskUndoer POST /api/tasks/{id}/undo and
+// /redo.
 func RegisterRoutes(r *gin.RouterGroup, svc TaskLister) {
 	// GET /api/tasks
+	//
+	// Without ?limit= or ?cursor= the response is a plain JSON array of every
+	// matching task, as it has always been. With either one it becomes a
+	// {"items": [...], "next_cursor": ...} page.
 	r.GET("/tasks", func(c *gin.Context) {
+		opts, paged, ok := parseListOptions(c)
+		if !ok {
+			return
+		}
+
 		// Call the service layer to fetch tasks.
-		items, err := svc.List(c.Request.Context())
+		page, err := svc.List(c.Request.Context(), opts)
 		if err != nil {
-			// If the service returns an error (e.g., DB failure),
-			// respond with HTTP 500 and the error message as JSON.
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			// apperr.Write maps the error's kind to a status code (e.g. a bad
+			// cursor → 400, DB unreachable → 503, anything unexpected → 500)
+			// and writes an RFC 7807 problem+json body.
+			apperr.Write(c, err)
 			return
 		}
 
-		// On success, return the list of tasks as JSON with HTTP 200.
-		c.JSON(http.StatusOK, items)
+		// On success, return the tasks as JSON with HTTP 200.
+		if paged {
+			c.JSON(http.StatusOK, page)
+			return
+		}
+		c.JSON(http.StatusOK, page.Items)
 	})
 
 	// POST /api/tasks
-	type createReq struct {
-		Title string `json:"title"`
-	}
 	r.POST("/tasks", func(c *gin.Context) {
 		// Discover create capability at runtime.
 		cr, ok := svc.(taskCreator)
 		if !ok {
-			c.JSON(http.StatusNotImplemented, gin.H{"error": "create not supported"})
+			apperr.Write(c, apperr.NotImplemented("create_not_supported", "create not supported"))
 			return
 		}
 
-		var req createReq
+		var req NewTask
 		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON body"})
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		// Validate here as well as in the Service so bad input is rejected
+		// before we touch the service (and with the same field-level error).
+		req, err := req.normalize()
+		if err != nil {
+			apperr.Write(c, err)
 			return
 		}
-		title := strings.TrimSpace(req.Title)
-		if title == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "title is required"})
+
+		// With an Idempotency-Key, a retry of a request that already
+		// succeeded gets the original 201 body back instead of a new task.
+		if key := c.GetHeader(IdempotencyKeyHeader); key != "" {
+			ic, ok := svc.(idempotentCreator)
+			if !ok {
+				apperr.Write(c, apperr.NotImplemented("idempotency_not_supported", "Idempotency-Key not supported"))
+				return
+			}
+			if !validIdempotencyKey(key) {
+				apperr.Write(c, invalidIdempotencyKey())
+				return
+			}
+			t, replayed, err := ic.CreateIdempotent(c.Request.Context(), key, req)
+			if err != nil {
+				apperr.Write(c, err)
+				return
+			}
+			if replayed {
+				c.Header("Idempotent-Replayed", "true")
+			}
+			c.Header("ETag", etag(t))
+			c.JSON(http.StatusCreated, t)
 			return
 		}
 
-		t, err := cr.Create(c.Request.Context(), title)
+		t, err := cr.Create(c.Request.Context(), req)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			apperr.Write(c, err)
 			return
 		}
+		c.Header("ETag", etag(t))
 		c.JSON(http.StatusCreated, t)
 	})
+
+	// POST /api/tasks:batch (see batch_http.go).
+	r.POST("/tasks:method", taskMethods(svc))
+
+	// GET /api/tasks/stream (SSE or WebSocket; see stream.go).
+	// Registered before /tasks/:id; gin prefers the static segment.
+	r.GET("/tasks/stream", streamTasks(svc))
+
+	// GET /api/tasks/search (full-text; see search.go).
+	r.GET("/tasks/search", searchTasks(svc))
+
+	// GET /api/tasks/next (actionable tasks; see deps_http.go).
+	r.GET("/tasks/next", nextTasks(svc))
+
+	// GET /api/tasks/{id}
+	r.GET("/tasks/:id", func(c *gin.Context) {
+		g, ok := svc.(TaskGetter)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("get_not_supported", "get not supported"))
+			return
+		}
+
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+
+		// ?as_of= reads the task's history instead (see eventstore_http.go).
+		if _, ok := c.GetQuery("as_of"); ok {
+			getAsOf(c, svc, id)
+			return
+		}
+
+		t, err := g.Get(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", etag(t))
+		c.JSON(http.StatusOK, t)
+	})
+
+	// PATCH /api/tasks/{id}
+	r.PATCH("/tasks/:id", func(c *gin.Context) {
+		u, ok := svc.(TaskUpdater)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("update_not_supported", "update not supported"))
+			return
+		}
+
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+
+		var p TaskPatch
+		if err := c.ShouldBindJSON(&p); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		p, err := p.normalize()
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		// Optimistic concurrency: a client that sends back the ETag it read
+		// only overwrites the task if nobody has changed it since (else 412).
+		if p.IfVersion, err = parseIfMatch(c.GetHeader("If-Match")); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+
+		t, err := u.Update(c.Request.Context(), id, p)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", etag(t))
+		c.JSON(http.StatusOK, t)
+	})
+
+	// DELETE /api/tasks/{id}
+	//
+	// Moves the task to the trash (GET /api/tasks?trashed=true), from which
+	// it can be restored until it is purged.
+	r.DELETE("/tasks/:id", func(c *gin.Context) {
+		d, ok := svc.(TaskDeleter)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("delete_not_supported", "delete not supported"))
+			return
+		}
+
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+
+		if err := d.Delete(c.Request.Context(), id); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// POST /api/tasks/{id}/restore
+	r.POST("/tasks/:id/restore", func(c *gin.Context) {
+		rs, ok := svc.(TaskRestorer)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("restore_not_supported", "restore not supported"))
+			return
+		}
+
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+
+		t, err := rs.Restore(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", etag(t))
+		c.JSON(http.StatusOK, t)
+	})
+
+	// /api/webhooks CRUD, delivery log and redelivery (see webhooks_http.go).
+	registerWebhookRoutes(r, svc)
+
+	// /api/lists CRUD, the tasks in a list and moving tasks between and
+	// within lists (see lists_http.go).
+	registerListRoutes(r, svc)
+
+	// Subtasks and blockers (see deps_http.go).
+	registerDependencyRoutes(r, svc)
+
+	// The caller's reminder preferences (see reminders_http.go).
+	registerReminderRoutes(r, svc)
+
+	// The audit log, of all the caller's tasks or of one (see audit_http.go).
+	registerAuditRoutes(r, svc)
+
+	// Undo and redo, in event-sourced mode (see eventstore_http.go).
+	registerUndoRoutes(r, svc)
+}
+
+// parseListOptions reads the GET /api/tasks query string.
+// paged reports whether the client asked for the paginated envelope.
+// On invalid input it writes a 400 problem response and returns ok=false.
+func parseListOptions(c *gin.Context) (opts ListOptions, paged, ok bool) {
+	sort, valid := ParseSortOrder(c.Query("sort"))
+	if !valid {
+		apperr.Write(c, invalidParam("sort"))
+		return opts, false, false
+	}
+	opts.Sort = sort
+	opts.Query = strings.TrimSpace(c.Query("q"))
+	opts.Cursor = c.Query("cursor")
+
+	if v := c.Query("done"); v != "" {
+		done, err := strconv.ParseBool(v)
+		if err != nil {
+			apperr.Write(c, invalidParam("done"))
+			return opts, false, false
+		}
+		opts.Done = &done
+	}
+	if v := c.Query("tag"); v != "" {
+		tag, err := NormalizeTag(v)
+		if err != nil {
+			apperr.Write(c, invalidParam("tag"))
+			return opts, false, false
+		}
+		opts.Tag = tag
+	}
+	if v := c.Query("due_before"); v != "" {
+		due, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apperr.Write(c, invalidParam("due_before"))
+			return opts, false, false
+		}
+		opts.DueBefore = &due
+	}
+	if v := c.Query("trashed"); v != "" {
+		trashed, err := strconv.ParseBool(v)
+		if err != nil {
+			apperr.Write(c, invalidParam("trashed"))
+			return opts, false, false
+		}
+		opts.Trashed = trashed
+	}
+
+	_, hasLimit := c.GetQuery("limit")
+	if hasLimit {
+		limit, err := strconv.Atoi(c.Query("limit"))
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			apperr.Write(c, invalidParam("limit"))
+			return opts, false, false
+		}
+		opts.Limit = limit
+	}
+
+	paged = hasLimit || opts.Cursor != ""
+	if paged && opts.Limit == 0 {
+		opts.Limit = DefaultPageSize
+	}
+	return opts, paged, true
+}
+
+// parseID reads the :id path parameter as an int32.
+// On failure it writes a 400 problem response and returns ok=false.
+func parseID(c *gin.Context) (int32, bool) {
+	return parseIDParam(c, "id")
+}
+
+// parseIDParam is parseID for the path parameter name.
+func parseIDParam(c *gin.Context, name string) (int32, bool) {
+	id64, err := strconv.ParseInt(c.Param(name), 10, 32)
+	if err != nil || id64 <= 0 {
+		apperr.Write(c, invalidParam(name))
+		return 0, false
+	}
+	return int32(id64), true
+}
+
+var errInvalidBody = apperr.Validation("invalid_body", "invalid JSON body")
+
+// invalidParam is the validation error for a malformed path/query parameter.
+func invalidParam(name string) error {
+	return apperr.Validation("invalid_"+name, "invalid "+name,
+		apperr.FieldError{Field: name, Reason: "invalid"})
+}
+
+// etag is the strong entity tag for a task: its quoted version number.
+func etag(t Task) string {
+	return `"` + strconv.FormatInt(int64(t.Version), 10) + `"`
+}
+
+// parseIfMatch turns an If-Match header into the version an update must
+// match. An absent header or "*" (any current representation) means no
+// version check. A weak or malformed tag can never match, so it fails the
+// precondition outright, as RFC 9110 requires for If-Match.
+func parseIfMatch(h string) (*int32, error) {
+	h = strings.TrimSpace(h)
+	if h == "" || h == "*" {
+		return nil, nil
+	}
+	unquoted, ok := strings.CutPrefix(h, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	v, err := strconv.ParseInt(unquoted, 10, 32)
+	if !ok || err != nil || v < 1 {
+		return nil, apperr.PreconditionFailed("version_mismatch", "If-Match does not match the current task version")
+	}
+	v32 := int32(v)
+	return &v32, nil
 }
@@ -4,28 +4,146 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
 )
 
 // Fake that satisfies TaskLister
-type fakeSvc struct{}
+type fakeSvc struct {
+	lastOpts  ListOptions
+	lastPatch TaskPatch
+}
+
+func (f *fakeSvc) List(ctx context.Context, opts ListOptions) (TaskPage, error) {
+	f.lastOpts = opts
+	if opts.Cursor == "bad" {
+		return TaskPage{}, ErrInvalidCursor
+	}
+	now := time.Now().UTC()
+	page := TaskPage{Items: []Task{
+		{ID: 1, Title: "First", Done: false, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}},
+		{ID: 2, Title: "Second", Done: true, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}},
+	}}
+	if opts.Limit > 0 {
+		next := "next-page"
+		page.NextCursor = &next
+	}
+	return page, nil
+}
 
-func (f *fakeSvc) List(ctx context.Context) ([]Task, error) {
+func (f *fakeSvc) Create(ctx context.Context, n NewTask) (Task, error) {
 	now := time.Now().UTC()
-	return []Task{
-		{ID: 1, Title: "First", Done: false, CreatedAt: now, UpdatedAt: now},
-		{ID: 2, Title: "Second", Done: true, CreatedAt: now, UpdatedAt: now},
+	return Task{
+		ID: 3, Title: n.Title, Done: false, CreatedAt: now, UpdatedAt: now, Version: 1,
+		Description: n.Description, DueAt: n.DueAt, RemindAt: n.RemindAt, Priority: n.Priority, Tags: n.Tags, RRule: n.RRule,
 	}, nil
 }
 
-func (f *fakeSvc) Create(ctx context.Context, title string) (Task, error) {
+// fakeMissingID is the id the fakes treat as "not in the database";
+// fakeBrokenID makes them fail the way an unclassified driver error would.
+const (
+	fakeMissingID = 404
+	fakeBrokenID  = 500
+)
+
+func (f *fakeSvc) Get(ctx context.Context, id int32) (Task, error) {
+	switch id {
+	case fakeMissingID:
+		return Task{}, errTaskNotFound()
+	case fakeBrokenID:
+		return Task{}, errors.New(`ERROR: relation "tasks" does not exist (SQLSTATE 42P01)`)
+	}
 	now := time.Now().UTC()
-	return Task{ID: 3, Title: title, Done: false, CreatedAt: now, UpdatedAt: now}, nil
+	return Task{ID: id, Title: "One", Done: false, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}}, nil
+}
+
+func (f *fakeSvc) Update(ctx context.Context, id int32, p TaskPatch) (Task, error) {
+	f.lastPatch = p
+	t, err := f.Get(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if p.IfVersion != nil && *p.IfVersion != t.Version {
+		return Task{}, errVersionMismatch()
+	}
+	if p.Title != nil {
+		t.Title = *p.Title
+	}
+	if p.Done != nil {
+		t.Done = *p.Done
+	}
+	if p.Description != nil {
+		t.Description = *p.Description
+	}
+	if p.DueAt.Set {
+		t.DueAt = p.DueAt.Time
+	}
+	if p.Priority != nil {
+		t.Priority = *p.Priority
+	}
+	if p.Tags != nil {
+		t.Tags = *p.Tags
+	}
+	t.Version++
+	return t, nil
+}
+
+func (f *fakeSvc) Delete(ctx context.Context, id int32) error {
+	if id == fakeMissingID {
+		return errTaskNotFound()
+	}
+	return nil
+}
+
+func (f *fakeSvc) Restore(ctx context.Context, id int32) (Task, error) {
+	if id == fakeMissingID {
+		return Task{}, errTaskNotFound()
+	}
+	return f.Get(ctx, id)
+}
+
+// Subscribe replays one event and then ends the stream.
+func (f *fakeSvc) Subscribe(ctx context.Context) (<-chan Event, error) {
+	ch := make(chan Event, 1)
+	ch <- Event{Type: EventCreated, Task: Task{ID: 9, Title: "Streamed", Version: 1}}
+	close(ch)
+	return ch, nil
+}
+
+// Fake that satisfies only TaskLister (no optional capabilities).
+type listOnlySvc struct{}
+
+func (f *listOnlySvc) List(ctx context.Context, opts ListOptions) (TaskPage, error) {
+	return TaskPage{}, nil
+}
+
+func newTestRouter(svc TaskLister) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r.Group("/api"), svc)
+	return r
+}
+
+// decodeProblem asserts w holds a problem+json error body and returns it.
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) apperr.Problem {
+	t.Helper()
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, apperr.ProblemContentType) {
+		t.Fatalf("expected %s, got %q; body=%s", apperr.ProblemContentType, ct, w.Body.String())
+	}
+	var p apperr.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("json: %v; body=%s", err, w.Body.String())
+	}
+	return p
 }
 
 func TestGETTasks_ReturnsJSONList(t *testing.T) {
@@ -55,6 +173,68 @@ func TestGETTasks_ReturnsJSONList(t *testing.T) {
 	}
 }
 
+func TestGETTasks_PagedEnvelope(t *testing.T) {
+	svc := &fakeSvc{}
+	r := newTestRouter(svc)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/tasks?limit=2&done=false&q=+first+&sort=-created_at&trashed=true&tag=+Work+&due_before=2026-01-02T15:04:05%2B01:00", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var got TaskPage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json unmarshal error: %v; body=%s", err, w.Body.String())
+	}
+	if len(got.Items) != 2 || got.NextCursor == nil || *got.NextCursor != "next-page" {
+		t.Fatalf("unexpected page: %#v", got)
+	}
+
+	o := svc.lastOpts
+	if o.Limit != 2 || o.Done == nil || *o.Done || o.Query != "first" || o.Sort != SortCreatedAtDesc || !o.Trashed {
+		t.Fatalf("options not parsed as expected: %#v", o)
+	}
+	if o.Tag != "work" || o.DueBefore == nil || !o.DueBefore.Equal(time.Date(2026, 1, 2, 14, 4, 5, 0, time.UTC)) {
+		t.Fatalf("tag/due_before not parsed as expected: %q %v", o.Tag, o.DueBefore)
+	}
+}
+
+func TestGETTasks_CursorAloneUsesDefaultPageSize(t *testing.T) {
+	svc := &fakeSvc{}
+	r := newTestRouter(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks?cursor=abc", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if svc.lastOpts.Limit != DefaultPageSize || svc.lastOpts.Cursor != "abc" {
+		t.Fatalf("unexpected options: %#v", svc.lastOpts)
+	}
+}
+
+func TestGETTasks_RejectsInvalidQuery(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	for _, qs := range []string{"limit=0", "limit=201", "limit=x", "done=maybe", "trashed=2", "sort=title", "cursor=bad", "tag=a,b", "due_before=tomorrow"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks?"+qs, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d; body=%s", qs, w.Code, w.Body.String())
+		}
+		if p := decodeProblem(t, w); !strings.HasPrefix(p.Code, "invalid_") || len(p.Errors) != 1 {
+			t.Fatalf("%s: unexpected problem: %#v", qs, p)
+		}
+	}
+}
+
 func TestPOSTTasks_CreatesAndReturnsJSON(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
@@ -78,3 +258,330 @@ func TestPOSTTasks_CreatesAndReturnsJSON(t *testing.T) {
 		t.Fatalf("unexpected response: %#v", got)
 	}
 }
+
+func TestPOSTTasks_WithDetails(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks", `{"title":"Plan trip","description":"  - book\r\n- pack ",
+		"due_at":"2026-05-01T09:00:00Z","priority":"high","tags":["Travel"," travel","2026"],"rrule":"rrule:freq=yearly"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var got Task
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json: %v; body=%s", err, w.Body.String())
+	}
+	if got.Description != "- book\n- pack" || got.DueAt == nil || got.DueAt.Hour() != 9 ||
+		got.Priority != PriorityHigh || strings.Join(got.Tags, ",") != "2026,travel" || got.RRule != "FREQ=YEARLY" {
+		t.Fatalf("unexpected response: %#v", got)
+	}
+
+	for body, code := range map[string]string{
+		`{"title":"x","priority":"asap"}`:        "invalid_priority",
+		`{"title":"x","tags":[""]}`:              "invalid_tags",
+		`{"title":"x","description":"a\u0007"}`:  "invalid_description",
+		`{"title":"x","due_at":"soon"}`:          "invalid_body",
+		`{"title":"x","rrule":"FREQ=SOMETIMES"}`: "invalid_rrule",
+	} {
+		w := doJSON(t, r, http.MethodPost, "/api/tasks", body)
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Code != code {
+			t.Fatalf("%s: expected 400 %s, got %d %+v", body, code, w.Code, p)
+		}
+	}
+}
+
+func TestPATCHTask_DueAtCanBeCleared(t *testing.T) {
+	svc := &fakeSvc{}
+	r := newTestRouter(svc)
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/tasks/5", `{"due_at":null}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if p := svc.lastPatch; !p.DueAt.Set || p.DueAt.Time != nil || p.RemindAt.Set {
+		t.Fatalf("due_at null must clear the due date, got %+v", p.DueAt)
+	}
+	if w := doJSON(t, r, http.MethodPatch, "/api/tasks/5", `{"remind_at":null}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if p := svc.lastPatch; !p.RemindAt.Set || p.RemindAt.Time != nil || p.DueAt.Set {
+		t.Fatalf("remind_at null must clear the reminder, got %+v", p)
+	}
+
+	if w := doJSON(t, r, http.MethodPatch, "/api/tasks/5", `{"priority":"low","tags":[]}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if p := svc.lastPatch; p.DueAt.Set || p.Tags == nil || len(*p.Tags) != 0 || *p.Priority != PriorityLow {
+		t.Fatalf("omitted due_at must be left alone and tags cleared, got %+v", p)
+	}
+}
+
+func TestGETTaskByID_ReturnsTask(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks/7", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var got Task
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json: %v; body=%s", err, w.Body.String())
+	}
+	if got.ID != 7 {
+		t.Fatalf("expected id 7, got %#v", got)
+	}
+}
+
+func TestGETTaskByID_NotFound(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks/404", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d; body=%s", w.Code, w.Body.String())
+	}
+	p := decodeProblem(t, w)
+	if p.Code != "task_not_found" || p.Status != http.StatusNotFound || p.Instance != "/api/tasks/404" {
+		t.Fatalf("unexpected problem: %#v", p)
+	}
+}
+
+func TestGETTaskByID_InternalErrorIsNotLeaked(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks/500", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d; body=%s", w.Code, w.Body.String())
+	}
+	p := decodeProblem(t, w)
+	if p.Code != "internal" || bytes.Contains(w.Body.Bytes(), []byte("SQLSTATE")) {
+		t.Fatalf("driver error leaked to client: %s", w.Body.String())
+	}
+}
+
+func TestGETTaskByID_InvalidID(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	for _, id := range []string{"abc", "0", "-1", "99999999999"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/tasks/"+id, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("id %q: expected 400, got %d; body=%s", id, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestPATCHTask_UpdatesFields(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	body := bytes.NewBufferString(`{"title":"  Renamed  ","done":true}`)
+	req := httptest.NewRequest(http.MethodPatch, "/api/tasks/5", body)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var got Task
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("json: %v; body=%s", err, w.Body.String())
+	}
+	if got.ID != 5 || got.Title != "Renamed" || !got.Done {
+		t.Fatalf("unexpected response: %#v", got)
+	}
+}
+
+func TestCreateAndUpdate_RejectInvalidTitleWithFieldError(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	cases := []struct {
+		method, path, title, reason string
+	}{
+		{http.MethodPost, "/api/tasks", strings.Repeat("x", MaxTitleLength+1), ReasonTooLong},
+		{http.MethodPost, "/api/tasks", "two\nlines", ReasonControlChars},
+		{http.MethodPatch, "/api/tasks/5", strings.Repeat("x", MaxTitleLength+1), ReasonTooLong},
+		{http.MethodPatch, "/api/tasks/5", "  ", ReasonRequired},
+	}
+	for _, tc := range cases {
+		body, _ := json.Marshal(map[string]string{"title": tc.title})
+		req := httptest.NewRequest(tc.method, tc.path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s %s: expected 400, got %d; body=%s", tc.method, tc.path, w.Code, w.Body.String())
+		}
+		p := decodeProblem(t, w)
+		want := apperr.FieldError{Field: "title", Reason: tc.reason}
+		if p.Code != "invalid_title" || len(p.Errors) != 1 || p.Errors[0] != want {
+			t.Fatalf("%s %s: expected %+v, got %#v", tc.method, tc.path, want, p)
+		}
+	}
+}
+
+func TestPATCHTask_IfMatch(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	get := httptest.NewRecorder()
+	r.ServeHTTP(get, httptest.NewRequest(http.MethodGet, "/api/tasks/5", nil))
+	if tag := get.Header().Get("ETag"); tag != `"1"` {
+		t.Fatalf("expected ETag \"1\" on GET, got %q", tag)
+	}
+
+	cases := []struct {
+		ifMatch  string
+		wantCode int
+		wantTag  string
+	}{
+		{`"1"`, http.StatusOK, `"2"`},
+		{`*`, http.StatusOK, `"2"`},
+		{``, http.StatusOK, `"2"`},
+		{`"7"`, http.StatusPreconditionFailed, ``},
+		{`W/"1"`, http.StatusPreconditionFailed, ``},
+		{`garbage`, http.StatusPreconditionFailed, ``},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPatch, "/api/tasks/5", bytes.NewBufferString(`{"done":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		if tc.ifMatch != "" {
+			req.Header.Set("If-Match", tc.ifMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != tc.wantCode || w.Header().Get("ETag") != tc.wantTag {
+			t.Fatalf("If-Match %q: expected %d with ETag %q, got %d %q; body=%s",
+				tc.ifMatch, tc.wantCode, tc.wantTag, w.Code, w.Header().Get("ETag"), w.Body.String())
+		}
+		if tc.wantCode == http.StatusPreconditionFailed {
+			if p := decodeProblem(t, w); p.Code != "version_mismatch" {
+				t.Fatalf("If-Match %q: unexpected problem %#v", tc.ifMatch, p)
+			}
+		}
+	}
+}
+
+func TestPATCHTask_RejectsEmptyPatchAndBlankTitle(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	for _, b := range []string{`{}`, `{"title":"   "}`, `not json`} {
+		req := httptest.NewRequest(http.MethodPatch, "/api/tasks/5", bytes.NewBufferString(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("body %q: expected 400, got %d; body=%s", b, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestDELETETask_NoContentThenNotFound(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/tasks/5", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d; body=%s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/tasks/404", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d; body=%s", w.Code, w.Body.String())
+	}
+}
+
+func TestPOSTRestoreTask(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks/5/restore", "")
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"1"` {
+		t.Fatalf("expected 200 with an ETag, got %d %v; body=%s", w.Code, w.Header(), w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPost, "/api/tasks/404/restore", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	r = newTestRouter(&listOnlySvc{})
+	if w := doJSON(t, r, http.MethodPost, "/api/tasks/5/restore", ""); w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without TaskRestorer, got %d", w.Code)
+	}
+}
+
+func TestPerTaskRoutes_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+
+	for _, method := range []string{http.MethodGet, http.MethodPatch, http.MethodDelete} {
+		req := httptest.NewRequest(method, "/api/tasks/1", bytes.NewBufferString(`{"done":true}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: expected 501, got %d; body=%s", method, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestStreamTasks_SSE(t *testing.T) {
+	r := newTestRouter(&fakeSvc{})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tasks/stream", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected 200 text/event-stream, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "event: created\ndata: {") || !strings.Contains(body, `"title":"Streamed"`) {
+		t.Fatalf("unexpected stream: %q", body)
+	}
+}
+
+func TestStreamTasks_WebSocket(t *testing.T) {
+	srv := httptest.NewServer(newTestRouter(&fakeSvc{}))
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/api/tasks/stream", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	var ev Event
+	if err := conn.ReadJSON(&ev); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if ev.Type != EventCreated || ev.Task.Title != "Streamed" {
+		t.Fatalf("unexpected event: %#v", ev)
+	}
+	// The fake's stream has ended, so the server says goodbye.
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Fatalf("expected close going away, got %v", err)
+	}
+}
+
+func TestStreamTasks_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/tasks/stream", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
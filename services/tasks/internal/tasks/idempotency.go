@@ -0,0 +1,164 @@
+package tasks
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// IdempotencyKeyHeader lets a client retry POST /api/tasks safely: requests
+// repeating a key get the first one's response instead of a new task.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// MaxIdempotencyKeyLength bounds an Idempotency-Key, in bytes.
+const MaxIdempotencyKeyLength = 255
+
+// DefaultIdempotencyTTL is how long a key's response is replayed for.
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// validIdempotencyKey reports whether k is 1..MaxIdempotencyKeyLength
+// visible ASCII characters, which covers UUIDs and the like.
+func validIdempotencyKey(k string) bool {
+	if k == "" || len(k) > MaxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(k); i++ {
+		if k[i] < '!' || k[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// errIdempotencyKeyReused is returned when a key comes back with a
+// different request than the one it was first used for.
+func errIdempotencyKeyReused() error {
+	return apperr.Unprocessable("idempotency_key_reused",
+		"Idempotency-Key was already used for a different request")
+}
+
+// createRequestHash fingerprints a normalized create request, so retries
+// that differ only in whitespace, Unicode form or tag order still match.
+func createRequestHash(n NewTask) string {
+	b, _ := json.Marshal(n) // cannot fail: NewTask has only plain fields
+	sum := sha256.Sum256(append([]byte("create\x00"), b...))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateIdempotent is Create for a request carrying an Idempotency-Key.
+// The first request with key creates the task; until the key expires, a
+// repeat of the same request returns that same task (replayed is true) and
+// creates nothing, and a different request fails with
+// idempotency_key_reused.
+func (s *Service) CreateIdempotent(ctx context.Context, key string, n NewTask) (t Task, replayed bool, err error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, false, err
+	}
+	if !validIdempotencyKey(key) {
+		return Task{}, false, invalidIdempotencyKey()
+	}
+	n, err = n.normalize()
+	if err != nil {
+		return Task{}, false, err
+	}
+	t, replayed, err = s.repo.CreateIdempotent(ctx, owner, key, createRequestHash(n), n, s.idempotencyTTL)
+	if err != nil {
+		return Task{}, false, err
+	}
+	if !replayed {
+		s.emit(ctx, Event{Type: EventCreated, Task: t})
+	}
+	return t, replayed, nil
+}
+
+// SetIdempotencyTTL sets how long Idempotency-Key responses are kept
+// (DefaultIdempotencyTTL unless changed).
+func (s *Service) SetIdempotencyTTL(d time.Duration) {
+	s.idempotencyTTL = d
+}
+
+// RunIdempotencyKeyPurge deletes expired Idempotency-Key rows every
+// interval until ctx is cancelled. Expired keys are already ignored, so this
+// only keeps the table from growing.
+func (s *Service) RunIdempotencyKeyPurge(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if n, err := s.repo.PurgeIdempotencyKeys(ctx); err != nil {
+			log.Printf("tasks: purge idempotency keys: %v", err)
+		} else if n > 0 {
+			log.Printf("tasks: purged %d expired idempotency keys", n)
+		}
+	}
+}
+
+func invalidIdempotencyKey() error {
+	return apperr.Validation("invalid_idempotency_key", "Idempotency-Key must be 1-255 visible ASCII characters",
+		apperr.FieldError{Field: IdempotencyKeyHeader, Reason: "invalid"})
+}
+
+// CreateIdempotent creates a task like Create and stores its response
+// under (owner, key) in the same transaction, or, if the key is already
+// stored and unexpired, returns the stored task with replayed set.
+// Concurrent requests with one key are serialized by an advisory lock, so
+// exactly one of them creates the task.
+func (r *Repo) CreateIdempotent(ctx context.Context, owner, key, requestHash string, n NewTask, ttl time.Duration) (t Task, replayed bool, err error) {
+	err = r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		if err := q.LockIdempotencyKey(ctx, gen.LockIdempotencyKeyParams{OwnerID: owner, Key: key}); err != nil {
+			return err
+		}
+		prev, err := q.GetIdempotencyKey(ctx, gen.GetIdempotencyKeyParams{OwnerID: owner, Key: key})
+		switch {
+		case err == nil:
+			if prev.RequestHash != requestHash {
+				return errIdempotencyKeyReused()
+			}
+			replayed = true
+			return json.Unmarshal(prev.Response, &t)
+		case !errors.Is(err, pgx.ErrNoRows):
+			return err
+		}
+
+		if t, err = createTask(ctx, q, owner, n); err != nil {
+			return err
+		}
+		resp, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		return q.SaveIdempotencyKey(ctx, gen.SaveIdempotencyKeyParams{
+			OwnerID:     owner,
+			Key:         key,
+			RequestHash: requestHash,
+			StatusCode:  http.StatusCreated,
+			Response:    resp,
+			ExpiresAt:   pgtype.Timestamptz{Time: time.Now().Add(ttl), Valid: true},
+		})
+	})
+	if err != nil {
+		return Task{}, false, dbError(err, "task")
+	}
+	return t, replayed, nil
+}
+
+// PurgeIdempotencyKeys deletes expired keys and reports how many.
+func (r *Repo) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	return r.qry.PurgeIdempotencyKeys(ctx)
+}
@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// fakeIdempotentSvc remembers keys in memory the way Repo.CreateIdempotent
+// does in Postgres.
+type fakeIdempotentSvc struct {
+	fakeSvc
+	keys    map[string]Task
+	hashes  map[string]string
+	created int
+}
+
+func newFakeIdempotentSvc() *fakeIdempotentSvc {
+	return &fakeIdempotentSvc{keys: map[string]Task{}, hashes: map[string]string{}}
+}
+
+func (f *fakeIdempotentSvc) CreateIdempotent(ctx context.Context, key string, n NewTask) (Task, bool, error) {
+	if t, ok := f.keys[key]; ok {
+		if f.hashes[key] != createRequestHash(n) {
+			return Task{}, false, errIdempotencyKeyReused()
+		}
+		return t, true, nil
+	}
+	t, err := f.Create(ctx, n)
+	if err != nil {
+		return Task{}, false, err
+	}
+	f.created++
+	t.ID = int32(f.created)
+	f.keys[key], f.hashes[key] = t, createRequestHash(n)
+	return t, false, nil
+}
+
+func postWithKey(t *testing.T, h http.Handler, key, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestPOSTTasks_IdempotencyKeyReplaysOriginalResponse(t *testing.T) {
+	svc := newFakeIdempotentSvc()
+	r := newTestRouter(svc)
+
+	first := postWithKey(t, r, "7c1d4c1e-retry", `{"title":"Buy milk"}`)
+	if first.Code != http.StatusCreated || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first: expected a fresh 201, got %d %v", first.Code, first.Header())
+	}
+	// Differences normalization removes don't count as a different payload.
+	again := postWithKey(t, r, "7c1d4c1e-retry", `{"title":"  Buy milk "}`)
+	if again.Code != http.StatusCreated || again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry: expected a replayed 201, got %d %v", again.Code, again.Header())
+	}
+	if again.Body.String() != first.Body.String() || again.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Fatalf("retry: expected the original response, got %s (was %s)", again.Body, first.Body)
+	}
+	if svc.created != 1 {
+		t.Fatalf("expected one task to be created, got %d", svc.created)
+	}
+
+	if w := postWithKey(t, r, "another-key", `{"title":"Buy milk"}`); w.Code != http.StatusCreated || svc.created != 2 {
+		t.Fatalf("a new key must create a new task: %d, %d created", w.Code, svc.created)
+	}
+}
+
+func TestPOSTTasks_IdempotencyKeyReuseWithDifferentPayload(t *testing.T) {
+	r := newTestRouter(newFakeIdempotentSvc())
+
+	postWithKey(t, r, "k1", `{"title":"Buy milk"}`)
+	w := postWithKey(t, r, "k1", `{"title":"Buy bread"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d; body=%s", w.Code, w.Body.String())
+	}
+	if p := decodeProblem(t, w); p.Code != "idempotency_key_reused" {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+}
+
+func TestPOSTTasks_IdempotencyKeyValidationAndCapability(t *testing.T) {
+	r := newTestRouter(newFakeIdempotentSvc())
+	for _, key := range []string{"has space", "ключ", strings.Repeat("k", MaxIdempotencyKeyLength+1)} {
+		w := postWithKey(t, r, key, `{"title":"x"}`)
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Code != "invalid_idempotency_key" {
+			t.Fatalf("%q: expected 400 invalid_idempotency_key, got %d %+v", key, w.Code, p)
+		}
+	}
+
+	r = newTestRouter(&fakeSvc{})
+	if w := postWithKey(t, r, "k1", `{"title":"x"}`); w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without idempotency support, got %d", w.Code)
+	}
+}
+
+func Test_Server_IdempotentCreate_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := newFakeIdempotentSvc()
+
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{`{"title":"Buy milk"}`, http.StatusCreated},
+		{`{"title":"Buy milk"}`, http.StatusCreated},
+		{`{"title":"Buy bread"}`, http.StatusUnprocessableEntity},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/tasks", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(IdempotencyKeyHeader, "oas-key")
+		if rec := serveAndValidateWith(t, doc, svc, req); rec.Code != tc.code {
+			t.Fatalf("%s: expected %d, got %d", tc.body, tc.code, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,430 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// MaxListNameLength is the longest list name we accept, in Unicode code
+// points, as enforced by the lists_name_length CHECK constraint.
+const MaxListNameLength = 100
+
+// List is a named group of tasks (a project). Its tasks are ordered by
+// their Position; see Service.TasksInList and Service.Move.
+type List struct {
+	ID        int32     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	OwnerID   string    `json:"owner_id"`
+}
+
+// ListInput is the body of POST /api/lists and PATCH /api/lists/{id}.
+type ListInput struct {
+	Name string `json:"name"`
+}
+
+// TaskMove says where Move puts a task: into list ListID, immediately
+// before the task BeforeID or after the task AfterID (at most one of them;
+// neither means at the end). A nil ListID takes the task out of its list.
+type TaskMove struct {
+	ListID   *int32 `json:"list_id"`
+	BeforeID *int32 `json:"before_id"`
+	AfterID  *int32 `json:"after_id"`
+}
+
+// normalizeListName applies the NormalizeTitle rules to a list name.
+func normalizeListName(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", listNameError(ReasonInvalidUTF8, "name is not valid UTF-8")
+	}
+	s = strings.TrimSpace(norm.NFC.String(s))
+	switch {
+	case s == "":
+		return "", listNameError(ReasonRequired, "name is required")
+	case strings.ContainsFunc(s, unicode.IsControl):
+		return "", listNameError(ReasonControlChars, "name must not contain control characters")
+	case utf8.RuneCountInString(s) > MaxListNameLength:
+		return "", listNameError(ReasonTooLong, fmt.Sprintf("name must be at most %d characters", MaxListNameLength))
+	}
+	return s, nil
+}
+
+func (in ListInput) normalize() (ListInput, error) {
+	var err error
+	in.Name, err = normalizeListName(in.Name)
+	return in, err
+}
+
+// validate checks that m can apply to task id: an anchor needs a list, and
+// a task cannot be placed relative to itself.
+func (m TaskMove) validate(id int32) error {
+	switch {
+	case m.BeforeID != nil && m.AfterID != nil:
+		return apperr.Validation("invalid_move", "set at most one of before_id and after_id")
+	case m.ListID == nil && (m.BeforeID != nil || m.AfterID != nil):
+		return apperr.Validation("invalid_move", "before_id and after_id need a list_id",
+			apperr.FieldError{Field: "list_id", Reason: ReasonRequired})
+	case m.BeforeID != nil && *m.BeforeID == id:
+		return invalidParam("before_id")
+	case m.AfterID != nil && *m.AfterID == id:
+		return invalidParam("after_id")
+	}
+	return nil
+}
+
+// Lists returns the caller's lists.
+func (s *Service) Lists(ctx context.Context) ([]List, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Lists(ctx, owner)
+}
+
+// CreateList adds an empty list.
+func (s *Service) CreateList(ctx context.Context, in ListInput) (List, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return List{}, err
+	}
+	if in, err = in.normalize(); err != nil {
+		return List{}, err
+	}
+	return s.repo.CreateList(ctx, owner, in)
+}
+
+// GetList returns one of the caller's lists.
+func (s *Service) GetList(ctx context.Context, id int32) (List, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return List{}, err
+	}
+	return s.repo.GetList(ctx, owner, id)
+}
+
+// UpdateList renames a list.
+func (s *Service) UpdateList(ctx context.Context, id int32, in ListInput) (List, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return List{}, err
+	}
+	if in, err = in.normalize(); err != nil {
+		return List{}, err
+	}
+	return s.repo.UpdateList(ctx, owner, id, in)
+}
+
+// DeleteList deletes a list. Its tasks are not deleted but taken out of it;
+// subscribers see an EventUpdated for each of them.
+func (s *Service) DeleteList(ctx context.Context, id int32) error {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	detached, err := s.repo.DeleteList(ctx, owner, id)
+	if err != nil {
+		return err
+	}
+	for _, t := range detached {
+		s.emit(ctx, Event{Type: EventUpdated, Task: t})
+	}
+	return nil
+}
+
+// TasksInList returns the live tasks of one of the caller's lists, in list
+// order.
+func (s *Service) TasksInList(ctx context.Context, id int32) ([]Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.TasksInList(ctx, owner, id)
+}
+
+// Move puts a task into a list at the place m describes, moves it within
+// its list, or takes it out of its list. Only the moved task changes: its
+// new Position is halfway between its new neighbours'.
+func (s *Service) Move(ctx context.Context, id int32, m TaskMove) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	if err := m.validate(id); err != nil {
+		return Task{}, err
+	}
+	t, err := s.repo.Move(ctx, owner, id, m)
+	if err != nil {
+		return Task{}, err
+	}
+	s.emit(ctx, Event{Type: EventUpdated, Task: t})
+	return t, nil
+}
+
+// Lists returns owner's lists, oldest first.
+func (r *Repo) Lists(ctx context.Context, owner string) ([]List, error) {
+	rows, err := r.qry.ListLists(ctx, owner)
+	if err != nil {
+		return nil, dbError(err, "list")
+	}
+	out := make([]List, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, listFromRow(row))
+	}
+	return out, nil
+}
+
+// CreateList inserts a list owned by owner.
+func (r *Repo) CreateList(ctx context.Context, owner string, in ListInput) (List, error) {
+	row, err := r.qry.CreateList(ctx, gen.CreateListParams{OwnerID: owner, Name: in.Name})
+	if err != nil {
+		return List{}, dbError(err, "list")
+	}
+	return listFromRow(row), nil
+}
+
+// GetList fetches one of owner's lists.
+func (r *Repo) GetList(ctx context.Context, owner string, id int32) (List, error) {
+	row, err := r.qry.GetList(ctx, gen.GetListParams{ID: id, OwnerID: owner})
+	if err != nil {
+		return List{}, dbError(err, "list")
+	}
+	return listFromRow(row), nil
+}
+
+// UpdateList renames one of owner's lists.
+func (r *Repo) UpdateList(ctx context.Context, owner string, id int32, in ListInput) (List, error) {
+	row, err := r.qry.UpdateList(ctx, gen.UpdateListParams{ID: id, OwnerID: owner, Name: in.Name})
+	if err != nil {
+		return List{}, dbError(err, "list")
+	}
+	return listFromRow(row), nil
+}
+
+// DeleteList deletes one of owner's lists after taking its tasks (trashed
+// ones included) out of it, recording an EventUpdated for each live one.
+// It returns those live tasks.
+func (r *Repo) DeleteList(ctx context.Context, owner string, id int32) ([]Task, error) {
+	var detached []Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		if err := lockList(ctx, q, owner, id); err != nil {
+			return err
+		}
+		before, err := snapshotList(ctx, q, owner, id)
+		if err != nil {
+			return err
+		}
+		rows, err := q.DetachListTasks(ctx, pgtype.Int4{Int32: id, Valid: true})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			if row.DeletedAt.Valid {
+				continue
+			}
+			t, err := taskWithTags(ctx, q, taskFromRow(taskRow(row)))
+			if err != nil {
+				return err
+			}
+			if err := recordChange(ctx, q, before[t.ID], Event{Type: EventUpdated, Task: t}); err != nil {
+				return err
+			}
+			detached = append(detached, t)
+		}
+		_, err = q.DeleteList(ctx, gen.DeleteListParams{ID: id, OwnerID: owner})
+		return err
+	})
+	if err != nil {
+		return nil, dbError(err, "list")
+	}
+	return detached, nil
+}
+
+// TasksInList returns the live tasks of one of owner's lists by position.
+func (r *Repo) TasksInList(ctx context.Context, owner string, id int32) ([]Task, error) {
+	if _, err := r.GetList(ctx, owner, id); err != nil {
+		return nil, err
+	}
+	rows, err := r.qry.ListTasksInList(ctx, gen.ListTasksInListParams{
+		ListID: pgtype.Int4{Int32: id, Valid: true}, OwnerID: owner,
+	})
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	out := make([]Task, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, taskFromRow(taskRow(row)))
+	}
+	if err := withTags(ctx, r.qry, out); err != nil {
+		return nil, dbError(err, "task")
+	}
+	return out, nil
+}
+
+// Move applies m to one of owner's live tasks and records an EventUpdated.
+// It returns an apperr.KindNotFound error for an unknown task or list, and
+// a validation error if the before_id/after_id anchor is not a live task of
+// that list.
+func (r *Repo) Move(ctx context.Context, owner string, id int32, m TaskMove) (Task, error) {
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		params := gen.MoveTaskParams{ID: id, OwnerID: owner}
+		if m.ListID != nil {
+			pos, err := listPosition(ctx, q, owner, *m.ListID, id, m)
+			if err != nil {
+				return err
+			}
+			params.ListID = pgtype.Int4{Int32: *m.ListID, Valid: true}
+			params.Position = pgtype.Float8{Float64: pos, Valid: true}
+		}
+		before, err := snapshot(ctx, q, owner, id)
+		if err != nil {
+			return err
+		}
+		row, err := q.MoveTask(ctx, params)
+		if err != nil {
+			return err
+		}
+		if t, err = taskWithTags(ctx, q, taskFromRow(taskRow(row))); err != nil {
+			return err
+		}
+		return recordChange(ctx, q, before, Event{Type: EventUpdated, Task: t})
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// listPosition locks owner's list listID and returns the position at which
+// task taskID (0 for a task not created yet) goes to land where m says.
+//
+// That is the midpoint of the gap between its new neighbours, so nothing
+// else moves. Only once repeated moves into the same gap have halved it
+// beyond what a float64 can tell apart is the list renumbered 1, 2, 3, ...
+func listPosition(ctx context.Context, q *gen.Queries, owner string, listID, taskID int32, m TaskMove) (float64, error) {
+	if err := lockList(ctx, q, owner, listID); err != nil {
+		return 0, err
+	}
+	for renumbered := false; ; renumbered = true {
+		lo, hi, err := listGap(ctx, q, listID, taskID, m)
+		if err != nil {
+			return 0, err
+		}
+		if mid := lo + (hi-lo)/2; lo < mid && mid < hi {
+			return mid, nil
+		}
+		if renumbered {
+			return 0, fmt.Errorf("list %d: no position between %v and %v", listID, lo, hi)
+		}
+		if err := renumberList(ctx, q, owner, listID); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// renumberList spreads owner's list listID back out (see RenumberList) and
+// records an EventUpdated for each live task that moved.
+func renumberList(ctx context.Context, q *gen.Queries, owner string, listID int32) error {
+	before, err := snapshotList(ctx, q, owner, listID)
+	if err != nil {
+		return err
+	}
+	rows, err := q.RenumberList(ctx, pgtype.Int4{Int32: listID, Valid: true})
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if row.DeletedAt.Valid {
+			continue
+		}
+		t, err := taskWithTags(ctx, q, taskFromRow(taskRow(row)))
+		if err != nil {
+			return err
+		}
+		if err := recordChange(ctx, q, before[t.ID], Event{Type: EventUpdated, Task: t}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listGap returns the positions between which m puts task taskID. An open
+// end (the start or end of the list) is one unit past the anchor.
+func listGap(ctx context.Context, q *gen.Queries, listID, taskID int32, m TaskMove) (lo, hi float64, err error) {
+	list := pgtype.Int4{Int32: listID, Valid: true}
+	switch {
+	case m.BeforeID != nil:
+		if hi, err = anchorPosition(ctx, q, list, *m.BeforeID, "before_id"); err != nil {
+			return 0, 0, err
+		}
+		lo, err = q.ListPositionBefore(ctx, gen.ListPositionBeforeParams{
+			ListID: list, ExcludeID: taskID, Position: hi, AnchorID: *m.BeforeID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return hi - 2, hi, nil
+		}
+		return lo, hi, err
+	case m.AfterID != nil:
+		if lo, err = anchorPosition(ctx, q, list, *m.AfterID, "after_id"); err != nil {
+			return 0, 0, err
+		}
+		hi, err = q.ListPositionAfter(ctx, gen.ListPositionAfterParams{
+			ListID: list, ExcludeID: taskID, Position: lo, AnchorID: *m.AfterID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return lo, lo + 2, nil
+		}
+		return lo, hi, err
+	default:
+		lo, err = q.LastListPosition(ctx, gen.LastListPositionParams{ListID: list, ExcludeID: taskID})
+		return lo, lo + 2, err
+	}
+}
+
+// anchorPosition returns the position of task id, which the move request
+// names in field; it must be a live task of list.
+func anchorPosition(ctx context.Context, q *gen.Queries, list pgtype.Int4, id int32, field string) (float64, error) {
+	pos, err := q.ListTaskPosition(ctx, gen.ListTaskPositionParams{ID: id, ListID: list})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, apperr.Validation("invalid_"+field, field+" is not a task in the list",
+			apperr.FieldError{Field: field, Reason: "not_in_list"})
+	}
+	return pos, err
+}
+
+// lockList locks one of owner's lists (see LockList in queries/lists.sql),
+// returning a list-not-found error if there is no such list.
+func lockList(ctx context.Context, q *gen.Queries, owner string, id int32) error {
+	_, err := q.LockList(ctx, gen.LockListParams{ID: id, OwnerID: owner})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperr.NotFound("list_not_found", "list not found")
+	}
+	return err
+}
+
+func listFromRow(row gen.List) List {
+	return List{
+		ID:        row.ID,
+		Name:      row.Name,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+		OwnerID:   row.OwnerID,
+	}
+}
+
+func listNameError(reason, msg string) error {
+	return apperr.Validation("invalid_name", msg, apperr.FieldError{Field: "name", Reason: reason})
+}
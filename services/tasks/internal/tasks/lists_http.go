@@ -0,0 +1,215 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// ListManager is the optional capability behind /api/lists. Like
+// WebhookManager it is all-or-nothing: without it every list route returns
+// 501. Creating a task in a list (POST /api/lists/{id}/tasks) needs
+// taskCreator instead, since it is an ordinary create.
+type ListManager interface {
+	Lists(ctx context.Context) ([]List, error)
+	CreateList(ctx context.Context, in ListInput) (List, error)
+	GetList(ctx context.Context, id int32) (List, error)
+	UpdateList(ctx context.Context, id int32, in ListInput) (List, error)
+	DeleteList(ctx context.Context, id int32) error
+	TasksInList(ctx context.Context, id int32) ([]Task, error)
+}
+
+// TaskMover enables POST /api/tasks/{id}/move.
+type TaskMover interface {
+	Move(ctx context.Context, id int32, m TaskMove) (Task, error)
+}
+
+// registerListRoutes wires up /lists and /tasks/{id}/move under r (see
+// RegisterRoutes).
+func registerListRoutes(r *gin.RouterGroup, svc TaskLister) {
+	// manager discovers the capability, writing a 501 if it is missing.
+	manager := func(c *gin.Context) (ListManager, bool) {
+		m, ok := svc.(ListManager)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("lists_not_supported", "lists not supported"))
+		}
+		return m, ok
+	}
+
+	// GET /api/lists
+	r.GET("/lists", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		lists, err := m.Lists(c.Request.Context())
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, lists)
+	})
+
+	// POST /api/lists
+	r.POST("/lists", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		var in ListInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		l, err := m.CreateList(c.Request.Context(), in)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, l)
+	})
+
+	// GET /api/lists/{id}
+	r.GET("/lists/:id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		l, err := m.GetList(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, l)
+	})
+
+	// PATCH /api/lists/{id}
+	r.PATCH("/lists/:id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		var in ListInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		l, err := m.UpdateList(c.Request.Context(), id, in)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, l)
+	})
+
+	// DELETE /api/lists/{id}
+	//
+	// The list's tasks are kept; they just no longer belong to a list.
+	r.DELETE("/lists/:id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		if err := m.DeleteList(c.Request.Context(), id); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// GET /api/lists/{id}/tasks (in list order)
+	r.GET("/lists/:id/tasks", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		ts, err := m.TasksInList(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, ts)
+	})
+
+	// POST /api/lists/{id}/tasks
+	//
+	// POST /api/tasks with the list_id taken from the path: the task is
+	// appended to the list.
+	r.POST("/lists/:id/tasks", func(c *gin.Context) {
+		cr, ok := svc.(taskCreator)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("create_not_supported", "create not supported"))
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		var req NewTask
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		req.ListID = &id
+		req, err := req.normalize()
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		t, err := cr.Create(c.Request.Context(), req)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", etag(t))
+		c.JSON(http.StatusCreated, t)
+	})
+
+	// POST /api/tasks/{id}/move
+	r.POST("/tasks/:id/move", func(c *gin.Context) {
+		mv, ok := svc.(TaskMover)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("move_not_supported", "move not supported"))
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		var m TaskMove
+		if err := c.ShouldBindJSON(&m); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		if err := m.validate(id); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		t, err := mv.Move(c.Request.Context(), id, m)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Header("ETag", etag(t))
+		c.JSON(http.StatusOK, t)
+	})
+}
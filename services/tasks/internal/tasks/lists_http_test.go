@@ -0,0 +1,244 @@
+package tasks
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// fakeListSvc keeps lists and the tasks created in them in memory, appending
+// each new task at the next whole position.
+type fakeListSvc struct {
+	listOnlySvc
+	lists map[int32]List
+	tasks []Task
+	moves []TaskMove
+}
+
+func newFakeListSvc() *fakeListSvc {
+	return &fakeListSvc{lists: map[int32]List{}}
+}
+
+func (f *fakeListSvc) Lists(ctx context.Context) ([]List, error) {
+	out := []List{}
+	for _, l := range f.lists {
+		out = append(out, l)
+	}
+	slices.SortFunc(out, func(a, b List) int { return cmp.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+func (f *fakeListSvc) CreateList(ctx context.Context, in ListInput) (List, error) {
+	in, err := in.normalize()
+	if err != nil {
+		return List{}, err
+	}
+	now := time.Now().UTC()
+	l := List{ID: int32(len(f.lists) + 1), Name: in.Name, CreatedAt: now, UpdatedAt: now, OwnerID: "anonymous"}
+	f.lists[l.ID] = l
+	return l, nil
+}
+
+func (f *fakeListSvc) GetList(ctx context.Context, id int32) (List, error) {
+	l, ok := f.lists[id]
+	if !ok {
+		return List{}, apperr.NotFound("list_not_found", "list not found")
+	}
+	return l, nil
+}
+
+func (f *fakeListSvc) UpdateList(ctx context.Context, id int32, in ListInput) (List, error) {
+	in, err := in.normalize()
+	if err != nil {
+		return List{}, err
+	}
+	l, err := f.GetList(ctx, id)
+	if err != nil {
+		return List{}, err
+	}
+	l.Name = in.Name
+	f.lists[id] = l
+	return l, nil
+}
+
+func (f *fakeListSvc) DeleteList(ctx context.Context, id int32) error {
+	if _, err := f.GetList(ctx, id); err != nil {
+		return err
+	}
+	delete(f.lists, id)
+	return nil
+}
+
+func (f *fakeListSvc) TasksInList(ctx context.Context, id int32) ([]Task, error) {
+	if _, err := f.GetList(ctx, id); err != nil {
+		return nil, err
+	}
+	out := []Task{}
+	for _, t := range f.tasks {
+		if t.ListID != nil && *t.ListID == id {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeListSvc) Create(ctx context.Context, n NewTask) (Task, error) {
+	n, err := n.normalize()
+	if err != nil {
+		return Task{}, err
+	}
+	t := Task{
+		ID: int32(len(f.tasks) + 1), Title: n.Title, CreatedAt: time.Now().UTC(), Version: 1,
+		Priority: n.Priority, Tags: n.Tags,
+	}
+	t.UpdatedAt = t.CreatedAt
+	if n.ListID != nil {
+		ts, err := f.TasksInList(ctx, *n.ListID)
+		if err != nil {
+			return Task{}, err
+		}
+		pos := float64(len(ts) + 1)
+		t.ListID, t.Position = n.ListID, &pos
+	}
+	f.tasks = append(f.tasks, t)
+	return t, nil
+}
+
+func (f *fakeListSvc) Move(ctx context.Context, id int32, m TaskMove) (Task, error) {
+	if err := m.validate(id); err != nil {
+		return Task{}, err
+	}
+	if id < 1 || int(id) > len(f.tasks) {
+		return Task{}, errTaskNotFound()
+	}
+	f.moves = append(f.moves, m)
+	t := f.tasks[id-1]
+	t.ListID, t.Position = m.ListID, nil
+	if m.ListID != nil {
+		pos := 0.5
+		t.Position = &pos
+	}
+	t.Version++
+	f.tasks[id-1] = t
+	return t, nil
+}
+
+func TestLists_CRUDAndTasks(t *testing.T) {
+	r := newTestRouter(newFakeListSvc())
+
+	w := doJSON(t, r, http.MethodPost, "/api/lists", `{"name":"  Home "}`)
+	var l List
+	if w.Code != http.StatusCreated || json.Unmarshal(w.Body.Bytes(), &l) != nil || l.Name != "Home" {
+		t.Fatalf("POST: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPost, "/api/lists", `{"name":" "}`); w.Code != http.StatusBadRequest || decodeProblem(t, w).Code != "invalid_name" {
+		t.Fatalf("blank name: expected 400 invalid_name, got %d %s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodPatch, "/api/lists/1", `{"name":"House"}`)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"name":"House"`)) {
+		t.Fatalf("PATCH: %d %s", w.Code, w.Body.String())
+	}
+
+	for _, title := range []string{"Paint", "Mow"} {
+		if w := doJSON(t, r, http.MethodPost, "/api/lists/1/tasks", `{"title":"`+title+`"}`); w.Code != http.StatusCreated {
+			t.Fatalf("POST task: %d %s", w.Code, w.Body.String())
+		}
+	}
+	w = doJSON(t, r, http.MethodGet, "/api/lists/1/tasks", "")
+	var ts []Task
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &ts) != nil || len(ts) != 2 ||
+		*ts[0].ListID != 1 || *ts[1].Position != 2 {
+		t.Fatalf("GET tasks: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPost, "/api/lists/9/tasks", `{"title":"Lost"}`); w.Code != http.StatusNotFound || decodeProblem(t, w).Code != "list_not_found" {
+		t.Fatalf("unknown list: expected 404 list_not_found, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(t, r, http.MethodDelete, "/api/lists/1", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: expected 204, got %d", w.Code)
+	}
+	if w := doJSON(t, r, http.MethodGet, "/api/lists/1/tasks", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("GET tasks after delete: expected 404, got %d", w.Code)
+	}
+}
+
+func TestPOSTMove_ValidatesBody(t *testing.T) {
+	svc := newFakeListSvc()
+	r := newTestRouter(svc)
+	doJSON(t, r, http.MethodPost, "/api/lists", `{"name":"Home"}`)
+	doJSON(t, r, http.MethodPost, "/api/lists/1/tasks", `{"title":"Paint"}`)
+	doJSON(t, r, http.MethodPost, "/api/lists/1/tasks", `{"title":"Mow"}`)
+
+	for body, code := range map[string]string{
+		`{"list_id":1,"before_id":1,"after_id":2}`: "invalid_move",
+		`{"before_id":1}`:                          "invalid_move",
+		`{"list_id":1,"before_id":2}`:              "invalid_before_id",
+		`{"list_id":1,"after_id":2}`:               "invalid_after_id",
+		`{"list_id":"one"}`:                        "invalid_body",
+	} {
+		w := doJSON(t, r, http.MethodPost, "/api/tasks/2/move", body)
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Code != code {
+			t.Fatalf("%s: expected 400 %s, got %d %+v", body, code, w.Code, p)
+		}
+	}
+	if len(svc.moves) != 0 {
+		t.Fatalf("invalid moves must not reach the service, got %+v", svc.moves)
+	}
+
+	w := doJSON(t, r, http.MethodPost, "/api/tasks/2/move", `{"list_id":1,"before_id":1}`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` || len(svc.moves) != 1 || *svc.moves[0].BeforeID != 1 {
+		t.Fatalf("move: %d %s", w.Code, w.Body.String())
+	}
+	w = doJSON(t, r, http.MethodPost, "/api/tasks/2/move", `{"list_id":null}`)
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(`"list_id"`)) {
+		t.Fatalf("move out of list: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLists_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api/lists"},
+		{http.MethodPost, "/api/lists/1/tasks"},
+		{http.MethodPost, "/api/tasks/1/move"},
+	} {
+		if w := doJSON(t, r, tc.method, tc.path, `{"title":"x"}`); w.Code != http.StatusNotImplemented {
+			t.Fatalf("%s %s: expected 501, got %d", tc.method, tc.path, w.Code)
+		}
+	}
+}
+
+func Test_Server_Lists_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := newFakeListSvc()
+
+	for _, tc := range []struct {
+		method, target, body string
+		code                 int
+	}{
+		{http.MethodPost, "/api/lists", `{"name":"Home"}`, http.StatusCreated},
+		{http.MethodPost, "/api/lists/1/tasks", `{"title":"Paint"}`, http.StatusCreated},
+		{http.MethodPost, "/api/lists/1/tasks", `{"title":"Mow","priority":"high"}`, http.StatusCreated},
+		{http.MethodGet, "/api/lists", "", http.StatusOK},
+		{http.MethodGet, "/api/lists/1/tasks", "", http.StatusOK},
+		{http.MethodPatch, "/api/lists/1", `{"name":"House"}`, http.StatusOK},
+		{http.MethodPost, "/api/tasks/2/move", `{"list_id":1,"before_id":1}`, http.StatusOK},
+		{http.MethodPost, "/api/tasks/2/move", `{"list_id":1,"before_id":2}`, http.StatusBadRequest},
+		{http.MethodGet, "/api/lists/7", "", http.StatusNotFound},
+		{http.MethodDelete, "/api/lists/1", "", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		if rec := serveAndValidateWith(t, doc, svc, req); rec.Code != tc.code {
+			t.Fatalf("%s %s: expected %d, got %d; body=%s", tc.method, tc.target, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+}
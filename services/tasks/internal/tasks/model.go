@@ -1,6 +1,13 @@
 package tasks
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
 
 // Task is the domain model for a to-do item.
 // This is the shape used throughout your service and exposed via HTTP/GraphQL.
@@ -10,4 +17,231 @@ type Task struct {
 	Done      bool      `json:"done"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Version starts at 1 and is bumped by the database on every change.
+	// HTTP exposes it as the ETag; see IfVersion on TaskPatch.
+	Version int32 `json:"version"`
+	// OwnerID is the subject of the caller that created the task
+	// (see internal/auth); only that caller can see or change it.
+	OwnerID string `json:"owner_id"`
+	// DeletedAt is set while the task is in the trash (see Service.Delete
+	// and Service.Restore); live tasks omit it.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Description is free-form markdown; empty if the task has none.
+	Description string `json:"description"`
+	// DueAt is when the task is due, if it has a due date.
+	DueAt *time.Time `json:"due_at,omitempty"`
+	// RemindAt is when the owner wants to be reminded of the task. Without
+	// it they are reminded at DueAt, less the lead time of their
+	// ReminderPrefs.
+	RemindAt *time.Time `json:"remind_at,omitempty"`
+	Priority Priority   `json:"priority"`
+	// Tags are the task's normalized tag names (see NormalizeTag), sorted.
+	Tags []string `json:"tags"`
+	// ListID is the list the task is in, if any, and Position its place in
+	// that list: lists show their tasks by ascending Position. Positions are
+	// only meaningful relative to each other; see Service.Move.
+	ListID   *int32   `json:"list_id,omitempty"`
+	Position *float64 `json:"position,omitempty"`
+	// ParentID is set on a subtask: the task it is part of. A parent is not
+	// actionable until its subtasks are done (see Service.Next).
+	ParentID *int32 `json:"parent_id,omitempty"`
+	// RRule makes the task recur: it is an RFC 5545 RRULE value (see
+	// NormalizeRRule), and NextOccurrenceAt is when the task's next
+	// occurrence is due to be created. It is created then, or once this one
+	// is done if that is sooner, after which NextOccurrenceAt is cleared.
+	RRule            string     `json:"rrule,omitempty"`
+	NextOccurrenceAt *time.Time `json:"next_occurrence_at,omitempty"`
+}
+
+// Priority is how urgent a task is. Tasks are PriorityNormal unless told
+// otherwise.
+type Priority string
+
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+	PriorityUrgent Priority = "urgent"
+)
+
+// NewTask is the input to Create. Only Title is required; the zero values
+// of the rest mean no description, no due date, PriorityNormal, no tags and
+// no list. A task created in a list goes at the end of it. With an RRule it
+// recurs, its due date (or, without one, its creation) starting the series.
+type NewTask struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	DueAt       *time.Time `json:"due_at"`
+	RemindAt    *time.Time `json:"remind_at"`
+	Priority    Priority   `json:"priority"`
+	Tags        []string   `json:"tags"`
+	ListID      *int32     `json:"list_id"`
+	RRule       string     `json:"rrule"`
+}
+
+// TaskPatch is a partial update to a Task.
+// A nil field means "leave unchanged", which is how PATCH distinguishes
+// an omitted field from an explicit false/empty value.
+//
+// DueAt and RemindAt can also be cleared, so they are NullableTimes rather
+// than pointers, and Tags, when set, replaces the task's tags as a whole.
+// RRule, when set, restarts the task's series from its due date; "" stops
+// it recurring.
+//
+// IfVersion, when set, makes the update conditional: it only applies if the
+// task is still at that version (HTTP fills it from If-Match).
+type TaskPatch struct {
+	Title       *string      `json:"title"`
+	Done        *bool        `json:"done"`
+	Description *string      `json:"description"`
+	DueAt       NullableTime `json:"due_at"`
+	RemindAt    NullableTime `json:"remind_at"`
+	Priority    *Priority    `json:"priority"`
+	Tags        *[]string    `json:"tags"`
+	RRule       *string      `json:"rrule"`
+	IfVersion   *int32       `json:"-"`
+}
+
+// NullableTime is a PATCH field that may be set, set to null, or left out.
+// Set reports whether it was present at all; Time is nil for null.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+// UnmarshalJSON only runs for a field that is present, which is what Set
+// records.
+func (n *NullableTime) UnmarshalJSON(b []byte) error {
+	n.Set = true
+	n.Time = nil
+	if string(b) == "null" {
+		return nil
+	}
+	return json.Unmarshal(b, &n.Time)
+}
+
+// MarshalJSON writes the time, or null if it is unset or cleared.
+func (n NullableTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.Time)
+}
+
+// SortOrder is the ordering of a task listing. A leading "-" means descending.
+type SortOrder string
+
+const (
+	SortIDAsc         SortOrder = "id"
+	SortIDDesc        SortOrder = "-id"
+	SortCreatedAtAsc  SortOrder = "created_at"
+	SortCreatedAtDesc SortOrder = "-created_at"
+
+	DefaultSortOrder = SortIDAsc
+)
+
+// Page size bounds for paginated listings (?limit=).
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 200
+)
+
+// ParseSortOrder validates a ?sort= value. An empty string selects the default.
+func ParseSortOrder(s string) (SortOrder, bool) {
+	switch o := SortOrder(s); o {
+	case "":
+		return DefaultSortOrder, true
+	case SortIDAsc, SortIDDesc, SortCreatedAtAsc, SortCreatedAtDesc:
+		return o, true
+	}
+	return "", false
+}
+
+// ListOptions narrows and pages a task listing.
+// The zero value lists every task ordered by id, which is what
+// existing clients of the plain-array GET /api/tasks rely on.
+type ListOptions struct {
+	Limit  int       // max items per page; 0 means no limit
+	Cursor string    // opaque next_cursor from a previous page
+	Done   *bool     // filter on completion state; nil means any
+	Query  string    // case-insensitive substring match on title
+	Sort   SortOrder // defaults to DefaultSortOrder
+	// Trashed lists the trash (deleted, not yet purged tasks) instead of
+	// live tasks.
+	Trashed   bool
+	Tag       string     // only tasks with this tag (see NormalizeTag)
+	DueBefore *time.Time // only tasks due before this time
+}
+
+// TaskPage is one page of a listing. NextCursor is nil on the last page.
+type TaskPage struct {
+	Items      []Task  `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// taskRow holds the task columns every task query selects. sqlc generates
+// a row type per query (the table also carries the search vector, which no
+// query returns), all convertible to taskRow: taskFromRow(taskRow(row)).
+type taskRow struct {
+	ID               int32
+	Title            string
+	Done             bool
+	CreatedAt        pgtype.Timestamptz
+	UpdatedAt        pgtype.Timestamptz
+	Version          int32
+	OwnerID          string
+	DeletedAt        pgtype.Timestamptz
+	Description      string
+	DueAt            pgtype.Timestamptz
+	Priority         gen.TaskPriority
+	ListID           pgtype.Int4
+	Position         pgtype.Float8
+	ParentID         pgtype.Int4
+	Rrule            pgtype.Text
+	NextOccurrenceAt pgtype.Timestamptz
+	RemindAt         pgtype.Timestamptz
+}
+
+// taskFromRow maps a sqlc row struct into the domain Task. Tags live in
+// their own table; Tags is empty until withTags fills it in.
+func taskFromRow(row taskRow) Task {
+	t := Task{
+		ID:          row.ID,
+		Title:       row.Title,
+		Done:        row.Done,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+		Version:     row.Version,
+		OwnerID:     row.OwnerID,
+		Description: row.Description,
+		Priority:    Priority(row.Priority),
+		Tags:        []string{},
+	}
+	if row.DeletedAt.Valid {
+		t.DeletedAt = &row.DeletedAt.Time
+	}
+	if row.DueAt.Valid {
+		t.DueAt = &row.DueAt.Time
+	}
+	if row.ListID.Valid {
+		t.ListID, t.Position = &row.ListID.Int32, &row.Position.Float64
+	}
+	if row.ParentID.Valid {
+		t.ParentID = &row.ParentID.Int32
+	}
+	if row.Rrule.Valid {
+		t.RRule = row.Rrule.String
+	}
+	if row.NextOccurrenceAt.Valid {
+		t.NextOccurrenceAt = &row.NextOccurrenceAt.Time
+	}
+	if row.RemindAt.Valid {
+		t.RemindAt = &row.RemindAt.Time
+	}
+	return t
+}
+
+// timestamptz is the query parameter for an optional time.
+func timestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
 }
@@ -0,0 +1,105 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// eventsChannel is the Postgres NOTIFY channel task events travel on.
+const eventsChannel = "tasks_events"
+
+// maxNotifyPayload is just under Postgres' 8000-byte NOTIFY payload limit.
+const maxNotifyPayload = 7900
+
+// listenRetryDelay is how long Listen waits before reconnecting.
+var listenRetryDelay = 2 * time.Second
+
+// Notify broadcasts ev to every replica's Listen via pg_notify.
+func (r *Repo) Notify(ctx context.Context, ev Event) error {
+	payload, err := notifyPayload(ev)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Exec(ctx, `SELECT pg_notify($1, $2)`, eventsChannel, payload)
+	return err
+}
+
+// NotifyAll is Notify for several events, sent in one round trip.
+func (r *Repo) NotifyAll(ctx context.Context, evs []Event) error {
+	b := &pgx.Batch{}
+	for _, ev := range evs {
+		payload, err := notifyPayload(ev)
+		if err != nil {
+			return err
+		}
+		b.Queue(`SELECT pg_notify($1, $2)`, eventsChannel, payload)
+	}
+	return r.db.SendBatch(ctx, b).Close()
+}
+
+// notifyPayload encodes ev for pg_notify.
+func notifyPayload(ev Event) (string, error) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return "", err
+	}
+	if len(payload) > maxNotifyPayload {
+		// Too big to send whole; listeners still learn which task changed.
+		ev.Task = Task{ID: ev.Task.ID, OwnerID: ev.Task.OwnerID, Version: ev.Task.Version}
+		if payload, err = json.Marshal(ev); err != nil {
+			return "", err
+		}
+	}
+	return string(payload), nil
+}
+
+// Listen receives the events sent by Notify (from any replica) and passes
+// them to fn until ctx is cancelled. It holds one pooled connection for
+// LISTEN and reconnects if that connection drops; events sent while
+// disconnected are lost, which is why subscribers resync on reconnect.
+func (r *Repo) Listen(ctx context.Context, fn func(Event)) error {
+	for {
+		err := r.listenOnce(ctx, fn)
+		if ctx.Err() != nil {
+			return nil
+		}
+		log.Printf("tasks: change feed: %v; reconnecting in %s", err, listenRetryDelay)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(listenRetryDelay):
+		}
+	}
+}
+
+func (r *Repo) listenOnce(ctx context.Context, fn func(Event)) error {
+	pc, err := r.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// Take the connection out of the pool for good: a connection that has
+	// run LISTEN must not be handed to other queries afterwards.
+	conn := pc.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+eventsChannel); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(n.Payload), &ev); err != nil {
+			log.Printf("tasks: change feed: bad payload %q: %v", n.Payload, err)
+			continue
+		}
+		fn(ev)
+	}
+}
@@ -27,17 +27,70 @@ func closeNoErr(t *testing.T, c io.Closer) {
 // distinct name to avoid clashing with fakeSvc in http_test.go
 type oasFakeSvc struct{}
 
-func (f *oasFakeSvc) List(ctx context.Context) ([]Task, error) {
+func (f *oasFakeSvc) List(ctx context.Context, opts ListOptions) (TaskPage, error) {
 	now := time.Now().UTC()
-	return []Task{
-		{ID: 1, Title: "First", Done: false, CreatedAt: now, UpdatedAt: now},
-		{ID: 2, Title: "Second", Done: true, CreatedAt: now, UpdatedAt: now},
-	}, nil
+	page := TaskPage{Items: []Task{
+		{ID: 1, Title: "First", Done: false, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}},
+		{ID: 2, Title: "Second", Done: true, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}},
+	}}
+	if opts.Trashed {
+		for i := range page.Items {
+			page.Items[i].DeletedAt = &now
+		}
+	}
+	if opts.Limit > 0 {
+		next := encodeCursor(cursor{Sort: opts.Sort, ID: 2})
+		page.NextCursor = &next
+	}
+	return page, nil
 }
 
-func (f *oasFakeSvc) Create(ctx context.Context, title string) (Task, error) {
+func (f *oasFakeSvc) Create(ctx context.Context, n NewTask) (Task, error) {
 	now := time.Now().UTC()
-	return Task{ID: 3, Title: title, Done: false, CreatedAt: now, UpdatedAt: now}, nil
+	t := Task{
+		ID: 3, Title: n.Title, Done: false, CreatedAt: now, UpdatedAt: now, Version: 1,
+		Description: n.Description, DueAt: n.DueAt, RemindAt: n.RemindAt, Priority: n.Priority, Tags: n.Tags, RRule: n.RRule,
+	}
+	if n.RRule != "" {
+		start := seriesStart(n.DueAt)
+		next, err := nextOccurrence(n.RRule, start, start)
+		if err != nil {
+			return Task{}, err
+		}
+		t.NextOccurrenceAt = next
+	}
+	return t, nil
+}
+
+func (f *oasFakeSvc) Get(ctx context.Context, id int32) (Task, error) {
+	if id == fakeMissingID {
+		return Task{}, errTaskNotFound()
+	}
+	now := time.Now().UTC()
+	return Task{ID: id, Title: "One", Done: false, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}}, nil
+}
+
+func (f *oasFakeSvc) Update(ctx context.Context, id int32, p TaskPatch) (Task, error) {
+	t, err := f.Get(ctx, id)
+	if err != nil {
+		return Task{}, err
+	}
+	if p.IfVersion != nil && *p.IfVersion != t.Version {
+		return Task{}, errVersionMismatch()
+	}
+	if p.Done != nil {
+		t.Done = *p.Done
+	}
+	t.Version++
+	return t, nil
+}
+
+func (f *oasFakeSvc) Delete(ctx context.Context, id int32) error {
+	return nil
+}
+
+func (f *oasFakeSvc) Restore(ctx context.Context, id int32) (Task, error) {
+	return f.Get(ctx, id)
 }
 
 func Test_Server_GetTasks_MatchesOpenAPI(t *testing.T) {
@@ -150,3 +203,156 @@ func Test_Server_PostTasks_MatchesOpenAPI(t *testing.T) {
 		t.Fatalf("response does not match OpenAPI: %v", err)
 	}
 }
+
+// loadSpec loads and validates openapi.yaml relative to this package.
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+	specPath := filepath.Join("..", "..", "api", "openapi.yaml")
+	doc, err := openapi3.NewLoader().LoadFromFile(specPath)
+	if err != nil {
+		t.Fatalf("load openapi.yaml: %v", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatalf("spec validation error: %v", err)
+	}
+	return doc
+}
+
+// serveAndValidate runs req through the live handlers and validates the
+// recorded response against the spec. It returns the recorder so callers
+// can also assert on the status code.
+func serveAndValidate(t *testing.T, doc *openapi3.T, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveAndValidateWith(t, doc, &oasFakeSvc{}, req)
+}
+
+// serveAndValidateWith is serveAndValidate with handlers backed by svc.
+func serveAndValidateWith(t *testing.T, doc *openapi3.T, svc TaskLister, req *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	RegisterRoutes(r.Group("/api"), svc)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	router, err := legacyrouter.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("build OAS router: %v", err)
+	}
+	route, pathParams, err := router.FindRoute(req)
+	if err != nil {
+		t.Fatalf("find route in spec for %s %s: %v", req.Method, req.URL.Path, err)
+	}
+
+	body := io.NopCloser(bytes.NewReader(rec.Body.Bytes()))
+	defer closeNoErr(t, body)
+
+	in := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{},
+		},
+		Status: rec.Code,
+		Header: rec.Header(),
+		Body:   body,
+	}
+	if err := openapi3filter.ValidateResponse(req.Context(), in); err != nil {
+		t.Fatalf("response does not match OpenAPI: %v", err)
+	}
+	return rec
+}
+
+func Test_Server_GetTaskByID_MatchesOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	rec := serveAndValidate(t, doc, httptest.NewRequest(http.MethodGet, "/api/tasks/1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	rec = serveAndValidate(t, doc, httptest.NewRequest(http.MethodGet, "/api/tasks/404", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func Test_Server_PatchTask_MatchesOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/tasks/1", bytes.NewBufferString(`{"done":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := serveAndValidate(t, doc, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/api/tasks/1", bytes.NewBufferString(`{"done":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"9"`)
+	rec = serveAndValidate(t, doc, req)
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412, got %d", rec.Code)
+	}
+}
+
+func Test_Server_DeleteTask_MatchesOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	rec := serveAndValidate(t, doc, httptest.NewRequest(http.MethodDelete, "/api/tasks/1", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+
+	rec = serveAndValidate(t, doc, httptest.NewRequest(http.MethodGet, "/api/tasks?trashed=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("trash: expected 200, got %d", rec.Code)
+	}
+	for path, code := range map[string]int{"/api/tasks/1/restore": http.StatusOK, "/api/tasks/404/restore": http.StatusNotFound} {
+		if rec := serveAndValidate(t, doc, httptest.NewRequest(http.MethodPost, path, nil)); rec.Code != code {
+			t.Fatalf("%s: expected %d, got %d", path, code, rec.Code)
+		}
+	}
+}
+
+func Test_Server_GetTasksPaged_MatchesOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	rec := serveAndValidate(t, doc, httptest.NewRequest(http.MethodGet, "/api/tasks?limit=2&sort=-id&done=true", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	rec = serveAndValidate(t, doc, httptest.NewRequest(http.MethodGet, "/api/tasks?limit=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func Test_Server_TaskDetails_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	for _, tc := range []struct {
+		method, target, body string
+		code                 int
+	}{
+		{http.MethodPost, "/api/tasks", `{"title":"Plan","description":"- a\n- b","due_at":"2026-05-01T09:00:00Z","priority":"urgent","tags":["Home"]}`, http.StatusCreated},
+		{http.MethodPost, "/api/tasks", `{"title":"Plan","tags":["a,b"]}`, http.StatusBadRequest},
+		{http.MethodPost, "/api/tasks", `{"title":"Standup","due_at":"2026-05-01T09:00:00Z","rrule":"FREQ=WEEKLY;BYDAY=MO,TH"}`, http.StatusCreated},
+		{http.MethodPost, "/api/tasks", `{"title":"Standup","rrule":"FREQ=MINUTELY"}`, http.StatusBadRequest},
+		{http.MethodPatch, "/api/tasks/1", `{"rrule":""}`, http.StatusOK},
+		{http.MethodPost, "/api/tasks", `{"title":"Call","remind_at":"2026-05-01T08:30:00Z"}`, http.StatusCreated},
+		{http.MethodPatch, "/api/tasks/1", `{"remind_at":null}`, http.StatusOK},
+		{http.MethodPatch, "/api/tasks/1", `{"due_at":null,"tags":[]}`, http.StatusOK},
+		{http.MethodGet, "/api/tasks?tag=home&due_before=2026-06-01T00:00:00Z", "", http.StatusOK},
+	} {
+		req := httptest.NewRequest(tc.method, tc.target, bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		if rec := serveAndValidate(t, doc, req); rec.Code != tc.code {
+			t.Fatalf("%s %s: expected %d, got %d; body=%s", tc.method, tc.target, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/outbox"
+)
+
+// OutboxTopic is the outbox topic for an event type, e.g. "task.created".
+func OutboxTopic(t EventType) string {
+	return "task." + string(t)
+}
+
+// enqueueEvent writes ev to the outbox, and queues a delivery of it to each
+// of the owner's matching webhooks, using q, which must be bound to the
+// transaction making the change (see Repo.withTx).
+func enqueueEvent(ctx context.Context, q *gen.Queries, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	topic := OutboxTopic(ev.Type)
+	id, err := q.EnqueueOutbox(ctx, gen.EnqueueOutboxParams{Topic: topic, Payload: payload})
+	if err != nil {
+		return err
+	}
+	return q.EnqueueWebhookDeliveries(ctx, gen.EnqueueWebhookDeliveriesParams{
+		EventID: id, Topic: topic, Payload: payload, OwnerID: ev.Task.OwnerID,
+	})
+}
+
+// enqueueEvents is enqueueEvent for several events of one owner, in two
+// statements however many there are (see Repo.Batch).
+func enqueueEvents(ctx context.Context, q *gen.Queries, owner string, evs []Event) error {
+	params := gen.EnqueueOutboxBatchParams{
+		Topics:   make([]string, 0, len(evs)),
+		Payloads: make([]string, 0, len(evs)),
+	}
+	for _, ev := range evs {
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		params.Topics = append(params.Topics, OutboxTopic(ev.Type))
+		params.Payloads = append(params.Payloads, string(payload))
+	}
+	ids, err := q.EnqueueOutboxBatch(ctx, params)
+	if err != nil {
+		return err
+	}
+	return q.EnqueueWebhookDeliveriesBatch(ctx, gen.EnqueueWebhookDeliveriesBatchParams{
+		OwnerID: owner, EventIds: ids,
+	})
+}
+
+// The methods below make Repo an outbox.Store for the relay.
+
+// ClaimOutbox leases up to limit due outbox messages, oldest first.
+func (r *Repo) ClaimOutbox(ctx context.Context, limit int, leaseUntil time.Time) ([]outbox.Message, error) {
+	rows, err := r.qry.ClaimOutbox(ctx, gen.ClaimOutboxParams{
+		LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		Lim:        int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	msgs := make([]outbox.Message, 0, len(rows))
+	for _, row := range rows {
+		msgs = append(msgs, outbox.Message{
+			ID:        row.ID,
+			Topic:     row.Topic,
+			Payload:   row.Payload,
+			CreatedAt: row.CreatedAt.Time,
+			Attempts:  row.Attempts,
+		})
+	}
+	// UPDATE ... RETURNING does not preserve the subquery's ORDER BY.
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	return msgs, nil
+}
+
+// MarkOutboxDelivered records that message id reached the sink.
+func (r *Repo) MarkOutboxDelivered(ctx context.Context, id int64) error {
+	return r.qry.MarkOutboxDelivered(ctx, id)
+}
+
+// MarkOutboxFailed records a failed delivery of message id and when to retry.
+func (r *Repo) MarkOutboxFailed(ctx context.Context, id int64, reason string, retryAt time.Time) error {
+	return r.qry.MarkOutboxFailed(ctx, gen.MarkOutboxFailedParams{
+		ID:        id,
+		LastError: pgtype.Text{String: reason, Valid: true},
+		RetryAt:   pgtype.Timestamptz{Time: retryAt, Valid: true},
+	})
+}
@@ -0,0 +1,375 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/teambition/rrule-go"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// MaxRRuleLength bounds an RRULE, in bytes, as enforced by the
+// tasks_rrule_length CHECK constraint.
+const MaxRRuleLength = 500
+
+// schedulerLock names the advisory lock that elects the replica running the
+// scheduler.
+const schedulerLock = "tasks.scheduler"
+
+// occurrenceBatchSize is how many occurrences MaterializeOccurrences creates
+// per transaction.
+const occurrenceBatchSize = 100
+
+// NormalizeRRule validates an RFC 5545 RRULE value, e.g.
+// "FREQ=WEEKLY;BYDAY=MO" (an "RRULE:" prefix is accepted too), and returns
+// it in canonical form. "" means no recurrence and is returned as is.
+//
+// The series starts at the task's due date, so the rule cannot set DTSTART,
+// and a task cannot recur more often than hourly. Times are UTC.
+func NormalizeRRule(s string) (string, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return "", nil
+	case len(s) > MaxRRuleLength:
+		return "", rruleError(ReasonTooLong, fmt.Sprintf("rrule must be at most %d bytes", MaxRRuleLength))
+	case strings.ContainsAny(s, "\r\n"):
+		return "", rruleError("invalid", "rrule must be a single RRULE value")
+	}
+	opt, err := rrule.StrToROption(strings.TrimPrefix(s, "RRULE:"))
+	if err != nil || !opt.Dtstart.IsZero() {
+		return "", rruleError("invalid", "rrule is not a valid RRULE value (without DTSTART)")
+	}
+	if opt.Freq > rrule.HOURLY || len(opt.Byminute) > 1 || len(opt.Bysecond) > 1 {
+		return "", rruleError("too_frequent", "rrule must not recur more often than hourly")
+	}
+	opt.Dtstart = time.Now()
+	if _, err := rrule.NewRRule(*opt); err != nil {
+		return "", rruleError("invalid", "rrule is not a valid RRULE value (without DTSTART)")
+	}
+	return opt.RRuleString(), nil
+}
+
+// nextOccurrence returns the first occurrence of rule (a NormalizeRRule
+// result) after t, for a series that started at start, or nil if the
+// series has no more occurrences.
+func nextOccurrence(rule string, start, t time.Time) (*time.Time, error) {
+	r, err := parseRRule(rule, start)
+	if err != nil {
+		return nil, err
+	}
+	next := r.After(t, false)
+	if next.IsZero() {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// latestOccurrence returns the last occurrence of rule at or before t, or
+// the zero time if there is none.
+func latestOccurrence(rule string, start, t time.Time) (time.Time, error) {
+	r, err := parseRRule(rule, start)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return r.Before(t, true), nil
+}
+
+func parseRRule(rule string, start time.Time) (*rrule.RRule, error) {
+	opt, err := rrule.StrToROption(rule)
+	if err != nil {
+		return nil, fmt.Errorf("rrule %q: %w", rule, err)
+	}
+	opt.Dtstart = start.UTC()
+	return rrule.NewRRule(*opt)
+}
+
+// schedule is the recurrence columns of a task following rule, its series
+// starting at its due date, or now if it has none.
+type schedule struct {
+	rule  pgtype.Text
+	start pgtype.Timestamptz
+	next  pgtype.Timestamptz
+}
+
+// newSchedule returns the schedule of a task due at due that follows rule
+// from start ("" for no recurrence, the zero schedule).
+func newSchedule(rule string, start time.Time, due *time.Time) (schedule, error) {
+	if rule == "" {
+		return schedule{}, nil
+	}
+	at := start
+	if due != nil {
+		at = *due
+	}
+	next, err := nextOccurrence(rule, start, at)
+	if err != nil {
+		return schedule{}, err
+	}
+	return schedule{
+		rule:  pgtype.Text{String: rule, Valid: true},
+		start: pgtype.Timestamptz{Time: start, Valid: true},
+		next:  timestamptz(next),
+	}, nil
+}
+
+// seriesStart is where a series set on a task due at due starts.
+func seriesStart(due *time.Time) time.Time {
+	if due != nil {
+		return *due
+	}
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// patchSchedule returns the schedule for task id after p, and whether p
+// changes it at all: it does if it sets RRule, or moves the due date of a
+// task whose next occurrence is still to come. It reports no change for an
+// unknown task, leaving the update itself to say so.
+func patchSchedule(ctx context.Context, q *gen.Queries, owner string, id int32, p TaskPatch) (schedule, bool, error) {
+	if p.RRule == nil && !p.DueAt.Set {
+		return schedule{}, false, nil
+	}
+	cur, err := q.GetTaskRecurrence(ctx, gen.GetTaskRecurrenceParams{ID: id, OwnerID: owner})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return schedule{}, false, nil
+	}
+	if err != nil {
+		return schedule{}, false, err
+	}
+	if p.RRule == nil && !cur.NextOccurrenceAt.Valid {
+		return schedule{}, false, nil
+	}
+
+	var due *time.Time
+	if cur.DueAt.Valid {
+		due = &cur.DueAt.Time
+	}
+	if p.DueAt.Set {
+		due = p.DueAt.Time
+	}
+	rule, start := cur.Rrule.String, cur.RruleStartAt.Time
+	if p.RRule != nil {
+		rule, start = *p.RRule, seriesStart(due)
+	}
+	s, err := newSchedule(rule, start, due)
+	return s, true, err
+}
+
+// RunScheduler creates the next occurrence of recurring tasks (see
+// Repo.MaterializeOccurrences), checking every interval until ctx is
+// cancelled. Only one replica does so at a time: the one holding the
+// scheduler's advisory lock. The others keep trying to take it over, so if
+// the leader goes away another replica takes over within an interval.
+func (s *Service) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var lead *leaderLock
+	defer func() {
+		if lead != nil {
+			lead.release()
+		}
+	}()
+	for {
+		if lead != nil {
+			if err := lead.check(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("tasks: scheduler: lost leadership: %v", err)
+				lead.release()
+				lead = nil
+			}
+		}
+		if lead == nil {
+			l, err := s.repo.tryLeaderLock(ctx, schedulerLock)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("tasks: scheduler: %v", err)
+			} else if l != nil {
+				log.Printf("tasks: scheduler: elected leader")
+				lead = l
+			}
+		}
+		if lead != nil {
+			s.materializeOccurrences(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// materializeOccurrences creates every occurrence that is due, in batches.
+func (s *Service) materializeOccurrences(ctx context.Context) {
+	for {
+		evs, err := s.repo.MaterializeOccurrences(ctx, time.Now(), occurrenceBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("tasks: scheduler: %v", err)
+			}
+			return
+		}
+		for _, ev := range evs {
+			s.emit(ctx, ev)
+		}
+		// Each claimed task yields a created and an updated event.
+		if len(evs) < 2*occurrenceBatchSize {
+			return
+		}
+	}
+}
+
+// MaterializeOccurrences creates, for up to limit of every owner's
+// recurring tasks, the task's next occurrence if it is due by now or the
+// task is done, and returns the events recorded for them: an EventCreated
+// for the new task and an EventUpdated for the old one, whose
+// NextOccurrenceAt is cleared.
+//
+// The new task is a copy of the old one (tags and list included, at the
+// end of the list), due at the occurrence. If occurrences were missed (the
+// scheduler was down), only the latest of them is created. Tasks claimed by
+// a concurrent call are skipped, so each occurrence is created once.
+func (r *Repo) MaterializeOccurrences(ctx context.Context, now time.Time, limit int) ([]Event, error) {
+	var evs []Event
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) error {
+		evs = nil
+		due, err := q.ClaimDueOccurrences(ctx, gen.ClaimDueOccurrencesParams{
+			Now: pgtype.Timestamptz{Time: now, Valid: true},
+			Lim: int32(limit),
+		})
+		if err != nil {
+			return err
+		}
+		for _, d := range due {
+			before, err := snapshot(ctx, q, d.OwnerID, d.ID)
+			if err != nil {
+				return err
+			}
+			created, updated, err := createOccurrence(ctx, q, d, now)
+			if err != nil {
+				return fmt.Errorf("task %d: %w", d.ID, err)
+			}
+			if err := recordChange(ctx, q, nil, Event{Type: EventCreated, Task: created}); err != nil {
+				return err
+			}
+			if err := recordChange(ctx, q, before, Event{Type: EventUpdated, Task: updated}); err != nil {
+				return err
+			}
+			evs = append(evs, Event{Type: EventCreated, Task: created}, Event{Type: EventUpdated, Task: updated})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	return evs, nil
+}
+
+// createOccurrence creates the next occurrence of the claimed task d and
+// clears d's next_occurrence_at, returning both tasks.
+func createOccurrence(ctx context.Context, q *gen.Queries, d gen.ClaimDueOccurrencesRow, now time.Time) (created, updated Task, err error) {
+	at := d.NextOccurrenceAt.Time
+	if at.Before(now) {
+		latest, err := latestOccurrence(d.Rrule, d.RruleStartAt.Time, now)
+		if err != nil {
+			return Task{}, Task{}, err
+		}
+		if latest.After(at) {
+			at = latest
+		}
+	}
+	next, err := nextOccurrence(d.Rrule, d.RruleStartAt.Time, at)
+	if err != nil {
+		return Task{}, Task{}, err
+	}
+
+	params := gen.CreateOccurrenceParams{
+		ID:               d.ID,
+		DueAt:            pgtype.Timestamptz{Time: at, Valid: true},
+		NextOccurrenceAt: timestamptz(next),
+	}
+	if d.ListID.Valid {
+		pos, err := listPosition(ctx, q, d.OwnerID, d.ListID.Int32, 0, TaskMove{ListID: &d.ListID.Int32})
+		if err != nil {
+			return Task{}, Task{}, err
+		}
+		params.ListID = d.ListID
+		params.Position = pgtype.Float8{Float64: pos, Valid: true}
+	}
+	row, err := q.CreateOccurrence(ctx, params)
+	if err != nil {
+		return Task{}, Task{}, err
+	}
+	if err := q.CopyTaskTags(ctx, gen.CopyTaskTagsParams{ToID: row.ID, FromID: d.ID}); err != nil {
+		return Task{}, Task{}, err
+	}
+	if created, err = taskWithTags(ctx, q, taskFromRow(taskRow(row))); err != nil {
+		return Task{}, Task{}, err
+	}
+
+	old, err := q.ClearNextOccurrence(ctx, d.ID)
+	if err != nil {
+		return Task{}, Task{}, err
+	}
+	if updated, err = taskWithTags(ctx, q, taskFromRow(taskRow(old))); err != nil {
+		return Task{}, Task{}, err
+	}
+	return created, updated, nil
+}
+
+// leaderLock is a session-level advisory lock, held on a connection taken
+// out of the pool for as long as it is held (see withLock in
+// internal/db/migrate).
+type leaderLock struct {
+	conn *pgxpool.Conn
+	name string
+}
+
+// tryLeaderLock takes the advisory lock name if no other session holds it,
+// returning nil if one does.
+func (r *Repo) tryLeaderLock(ctx context.Context, name string) (*leaderLock, error) {
+	conn, err := r.db.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("acquire connection: %w", err)
+	}
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, name).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, fmt.Errorf("take %s lock: %w", name, err)
+	}
+	if !ok {
+		conn.Release()
+		return nil, nil
+	}
+	return &leaderLock{conn: conn, name: name}, nil
+}
+
+// check reports an error if the lock may have been lost with its session.
+func (l *leaderLock) check(ctx context.Context) error {
+	return l.conn.Ping(ctx)
+}
+
+// release gives up the lock and returns the connection to the pool.
+func (l *leaderLock) release() {
+	// A fresh context: the caller's is typically cancelled by now, and the
+	// connection must not go back to the pool still holding the lock.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := l.conn.Exec(ctx, `SELECT pg_advisory_unlock(hashtext($1))`, l.name); err != nil {
+		_ = l.conn.Conn().Close(ctx) // dropping the session also releases the lock
+	}
+	l.conn.Release()
+}
+
+func rruleError(reason, msg string) error {
+	return apperr.Validation("invalid_rrule", msg, apperr.FieldError{Field: "rrule", Reason: reason})
+}
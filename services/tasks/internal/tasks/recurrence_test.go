@@ -0,0 +1,65 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+func TestNormalizeRRule(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"", ""},
+		{"  ", ""},
+		{"freq=weekly;byday=mo,we", "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{"RRULE:FREQ=DAILY;COUNT=3", "FREQ=DAILY;COUNT=3"},
+		{"FREQ=HOURLY;INTERVAL=4", "FREQ=HOURLY;INTERVAL=4"},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1;UNTIL=20301231T000000Z", "FREQ=MONTHLY;UNTIL=20301231T000000Z;BYMONTHDAY=-1"},
+	} {
+		got, err := NormalizeRRule(tc.in)
+		if err != nil || got != tc.want {
+			t.Fatalf("NormalizeRRule(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+
+	for _, tc := range []struct{ in, reason string }{
+		{"FREQ=FORTNIGHTLY", "invalid"},
+		{"BYDAY=MO", "invalid"},
+		{"FREQ=DAILY;DTSTART=20240101T000000Z", "invalid"},
+		{"FREQ=DAILY\nFREQ=WEEKLY", "invalid"},
+		{"FREQ=MINUTELY", "too_frequent"},
+		{"FREQ=HOURLY;BYMINUTE=0,30", "too_frequent"},
+		{"FREQ=DAILY;BYDAY=" + strings.Repeat("MO,", 200) + "MO", ReasonTooLong},
+	} {
+		_, err := NormalizeRRule(tc.in)
+		e := apperr.From(err)
+		if !apperr.IsKind(err, apperr.KindValidation) || e.Code != "invalid_rrule" || len(e.Fields) != 1 || e.Fields[0].Reason != tc.reason {
+			t.Fatalf("NormalizeRRule(%q): expected invalid_rrule/%s, got %v", tc.in, tc.reason, err)
+		}
+	}
+}
+
+func TestNextOccurrence(t *testing.T) {
+	start := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC) // a Monday
+	day := func(d int) time.Time { return time.Date(2024, 6, d, 9, 0, 0, 0, time.UTC) }
+
+	next, err := nextOccurrence("FREQ=WEEKLY;BYDAY=MO,TH", start, start)
+	if err != nil || next == nil || !next.Equal(day(6)) {
+		t.Fatalf("after the start: got %v, %v; want Thursday", next, err)
+	}
+	if next, _ = nextOccurrence("FREQ=WEEKLY;BYDAY=MO,TH", start, day(6)); next == nil || !next.Equal(day(10)) {
+		t.Fatalf("after Thursday: got %v, want next Monday", next)
+	}
+	if latest, _ := latestOccurrence("FREQ=DAILY", start, day(20).Add(time.Hour)); !latest.Equal(day(20)) {
+		t.Fatalf("latest: got %v, want %v", latest, day(20))
+	}
+
+	// COUNT includes the start itself, so a 2-count series ends after one more.
+	if next, _ = nextOccurrence("FREQ=DAILY;COUNT=2", start, start); next == nil || !next.Equal(day(4)) {
+		t.Fatalf("count: got %v", next)
+	}
+	if next, err = nextOccurrence("FREQ=DAILY;COUNT=2", start, day(4)); err != nil || next != nil {
+		t.Fatalf("exhausted: got %v, %v; want nil", next, err)
+	}
+}
@@ -0,0 +1,184 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/mail"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/notify"
+)
+
+// MaxReminderLeadMinutes bounds ReminderPrefs.LeadMinutes (a week).
+const MaxReminderLeadMinutes = 7 * 24 * 60
+
+// ReminderPrefs is how the owner wants to be reminded of their tasks (see
+// internal/notify): on Channel, at Email or WebhookURL for those channels,
+// LeadMinutes before a task is due unless it has its own RemindAt. Owners
+// who never set them get DefaultReminderPrefs.
+type ReminderPrefs struct {
+	Channel     notify.Channel `json:"channel"`
+	Email       string         `json:"email,omitempty"`
+	WebhookURL  string         `json:"webhook_url,omitempty"`
+	LeadMinutes int            `json:"lead_minutes"`
+}
+
+// DefaultReminderPrefs mirrors the defaults of the reminder_prefs table.
+var DefaultReminderPrefs = ReminderPrefs{Channel: notify.ChannelLog}
+
+// normalize validates p, keeping only the address its channel uses.
+func (p ReminderPrefs) normalize() (ReminderPrefs, error) {
+	email, hook := p.Email, p.WebhookURL
+	p.Email, p.WebhookURL = "", ""
+	switch p.Channel {
+	case notify.ChannelNone, notify.ChannelLog:
+	case notify.ChannelEmail:
+		a, err := mail.ParseAddress(email)
+		if err != nil || a.Name != "" {
+			return p, apperr.Validation("invalid_email", "email must be a plain email address",
+				apperr.FieldError{Field: "email", Reason: "invalid"})
+		}
+		p.Email = a.Address
+	case notify.ChannelWebhook:
+		u, err := normalizeWebhookURL(hook)
+		if err != nil && apperr.From(err).Code == "blocked_url" {
+			return p, apperr.Validation("blocked_webhook_url", "webhook_url must not point to a private or local address",
+				apperr.FieldError{Field: "webhook_url", Reason: "blocked"})
+		}
+		if err != nil {
+			return p, apperr.Validation("invalid_webhook_url", "webhook_url must be an absolute http or https URL",
+				apperr.FieldError{Field: "webhook_url", Reason: "invalid"})
+		}
+		p.WebhookURL = u
+	default:
+		return p, invalidParam("channel")
+	}
+	if p.LeadMinutes < 0 || p.LeadMinutes > MaxReminderLeadMinutes {
+		return p, invalidParam("lead_minutes")
+	}
+	return p, nil
+}
+
+// ReminderPrefs returns the caller's reminder preferences.
+func (s *Service) ReminderPrefs(ctx context.Context) (ReminderPrefs, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return ReminderPrefs{}, err
+	}
+	return s.repo.ReminderPrefs(ctx, owner)
+}
+
+// SetReminderPrefs replaces the caller's reminder preferences. They apply
+// to reminders queued from then on.
+func (s *Service) SetReminderPrefs(ctx context.Context, p ReminderPrefs) (ReminderPrefs, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return ReminderPrefs{}, err
+	}
+	if p, err = p.normalize(); err != nil {
+		return ReminderPrefs{}, err
+	}
+	return s.repo.SetReminderPrefs(ctx, owner, p)
+}
+
+// ReminderPrefs returns owner's reminder preferences, or
+// DefaultReminderPrefs if they never set any.
+func (r *Repo) ReminderPrefs(ctx context.Context, owner string) (ReminderPrefs, error) {
+	row, err := r.qry.GetReminderPrefs(ctx, owner)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultReminderPrefs, nil
+	}
+	if err != nil {
+		return ReminderPrefs{}, dbError(err, "reminder_prefs")
+	}
+	return reminderPrefsFromRow(row), nil
+}
+
+// SetReminderPrefs stores owner's reminder preferences.
+func (r *Repo) SetReminderPrefs(ctx context.Context, owner string, p ReminderPrefs) (ReminderPrefs, error) {
+	row, err := r.qry.UpsertReminderPrefs(ctx, gen.UpsertReminderPrefsParams{
+		OwnerID:     owner,
+		Channel:     string(p.Channel),
+		Email:       pgtype.Text{String: p.Email, Valid: p.Email != ""},
+		WebhookUrl:  pgtype.Text{String: p.WebhookURL, Valid: p.WebhookURL != ""},
+		LeadMinutes: int32(p.LeadMinutes),
+	})
+	if err != nil {
+		return ReminderPrefs{}, dbError(err, "reminder_prefs")
+	}
+	return reminderPrefsFromRow(row), nil
+}
+
+// The methods below make Repo a notify.Store for the reminder worker.
+
+// EnqueueReminders queues every owner's reminders that fell due in
+// (since, now]. Each (task, reminder time) is queued at most once.
+func (r *Repo) EnqueueReminders(ctx context.Context, since, now time.Time) (int64, error) {
+	return r.qry.EnqueueDueReminders(ctx, gen.EnqueueDueRemindersParams{
+		Since: pgtype.Timestamptz{Time: since, Valid: true},
+		Now:   pgtype.Timestamptz{Time: now, Valid: true},
+	})
+}
+
+// ClaimReminders cancels reminders for tasks done or trashed since they
+// were queued, then leases up to limit due ones, oldest first.
+func (r *Repo) ClaimReminders(ctx context.Context, limit int, leaseUntil time.Time) ([]notify.Reminder, error) {
+	if _, err := r.qry.CancelStaleReminders(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := r.qry.ClaimReminders(ctx, gen.ClaimRemindersParams{
+		LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		Lim:        int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]notify.Reminder, 0, len(rows))
+	for _, row := range rows {
+		rem := notify.Reminder{
+			ID:       row.ID,
+			TaskID:   row.TaskID,
+			OwnerID:  row.OwnerID,
+			Title:    row.Title,
+			RemindAt: row.RemindAt.Time,
+			Channel:  notify.Channel(row.Channel),
+			Address:  row.Address,
+			Attempts: row.Attempts,
+		}
+		if row.DueAt.Valid {
+			rem.DueAt = &row.DueAt.Time
+		}
+		out = append(out, rem)
+	}
+	slices.SortFunc(out, func(a, b notify.Reminder) int { return cmp.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+// RecordReminderAttempt stores the outcome of one attempt.
+func (r *Repo) RecordReminderAttempt(ctx context.Context, id int64, res notify.Result) error {
+	params := gen.RecordReminderAttemptParams{
+		ID:            id,
+		Status:        string(res.Status),
+		NextAttemptAt: pgtype.Timestamptz{Time: res.NextAttemptAt, Valid: true},
+	}
+	if res.Error != "" {
+		params.LastError = pgtype.Text{String: res.Error, Valid: true}
+	}
+	return r.qry.RecordReminderAttempt(ctx, params)
+}
+
+func reminderPrefsFromRow(row gen.ReminderPref) ReminderPrefs {
+	return ReminderPrefs{
+		Channel:     notify.Channel(row.Channel),
+		Email:       row.Email.String,
+		WebhookURL:  row.WebhookUrl.String,
+		LeadMinutes: int(row.LeadMinutes),
+	}
+}
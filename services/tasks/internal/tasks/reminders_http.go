@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// ReminderPreferences is the optional capability behind
+// /api/reminders/preferences; without it both routes return 501.
+type ReminderPreferences interface {
+	ReminderPrefs(ctx context.Context) (ReminderPrefs, error)
+	SetReminderPrefs(ctx context.Context, p ReminderPrefs) (ReminderPrefs, error)
+}
+
+// registerReminderRoutes wires up /reminders under r (see RegisterRoutes).
+func registerReminderRoutes(r *gin.RouterGroup, svc TaskLister) {
+	// prefs discovers the capability, writing a 501 if it is missing.
+	prefs := func(c *gin.Context) (ReminderPreferences, bool) {
+		p, ok := svc.(ReminderPreferences)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("reminders_not_supported", "reminders not supported"))
+		}
+		return p, ok
+	}
+
+	// GET /api/reminders/preferences
+	r.GET("/reminders/preferences", func(c *gin.Context) {
+		rp, ok := prefs(c)
+		if !ok {
+			return
+		}
+		p, err := rp.ReminderPrefs(c.Request.Context())
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+
+	// PUT /api/reminders/preferences (replaces them as a whole)
+	r.PUT("/reminders/preferences", func(c *gin.Context) {
+		rp, ok := prefs(c)
+		if !ok {
+			return
+		}
+		var in ReminderPrefs
+		if err := c.ShouldBindJSON(&in); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		p, err := rp.SetReminderPrefs(c.Request.Context(), in)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, p)
+	})
+}
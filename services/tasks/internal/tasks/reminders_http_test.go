@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/notify"
+)
+
+// fakeReminderSvc keeps one caller's reminder preferences in memory,
+// normalizing them like Service.SetReminderPrefs.
+type fakeReminderSvc struct {
+	listOnlySvc
+	prefs *ReminderPrefs
+}
+
+func (f *fakeReminderSvc) ReminderPrefs(ctx context.Context) (ReminderPrefs, error) {
+	if f.prefs == nil {
+		return DefaultReminderPrefs, nil
+	}
+	return *f.prefs, nil
+}
+
+func (f *fakeReminderSvc) SetReminderPrefs(ctx context.Context, p ReminderPrefs) (ReminderPrefs, error) {
+	p, err := p.normalize()
+	if err != nil {
+		return ReminderPrefs{}, err
+	}
+	f.prefs = &p
+	return p, nil
+}
+
+func TestReminderPrefs_GetAndSet(t *testing.T) {
+	r := newTestRouter(&fakeReminderSvc{})
+
+	w := doJSON(t, r, http.MethodGet, "/api/reminders/preferences", "")
+	if w.Code != http.StatusOK || w.Body.String() != `{"channel":"log","lead_minutes":0}` {
+		t.Fatalf("GET defaults: %d %s", w.Code, w.Body.String())
+	}
+
+	// Only the address the channel uses is kept.
+	w = doJSON(t, r, http.MethodPut, "/api/reminders/preferences",
+		`{"channel":"email","email":" Ann@Example.com ","webhook_url":"https://example.com/hook","lead_minutes":30}`)
+	var got ReminderPrefs
+	if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &got) != nil {
+		t.Fatalf("PUT: %d %s", w.Code, w.Body.String())
+	}
+	if want := (ReminderPrefs{Channel: notify.ChannelEmail, Email: "Ann@Example.com", LeadMinutes: 30}); got != want {
+		t.Fatalf("PUT: got %+v, want %+v", got, want)
+	}
+	if w := doJSON(t, r, http.MethodGet, "/api/reminders/preferences", ""); !bytes.Contains(w.Body.Bytes(), []byte(`"lead_minutes":30`)) {
+		t.Fatalf("GET after PUT: %s", w.Body.String())
+	}
+
+	for body, code := range map[string]string{
+		`{"channel":"sms"}`:   "invalid_channel",
+		`{"channel":"email"}`: "invalid_email",
+		`{"channel":"email","email":"Ann <ann@example.com>"}`:          "invalid_email",
+		`{"channel":"webhook","webhook_url":"ftp://x"}`:                "invalid_webhook_url",
+		`{"channel":"webhook","webhook_url":"http://10.0.0.7/remind"}`: "blocked_webhook_url",
+		`{"channel":"log","lead_minutes":-1}`:                          "invalid_lead_minutes",
+		`{"channel":"log","lead_minutes":10081}`:                       "invalid_lead_minutes",
+		`{"channel":`:                                                  "invalid_body",
+	} {
+		w := doJSON(t, r, http.MethodPut, "/api/reminders/preferences", body)
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Code != code {
+			t.Fatalf("%s: expected 400 %s, got %d %+v", body, code, w.Code, p)
+		}
+	}
+}
+
+func TestReminderPrefs_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		if w := doJSON(t, r, method, "/api/reminders/preferences", `{"channel":"log"}`); w.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: expected 501, got %d", method, w.Code)
+		}
+	}
+}
+
+func Test_Server_ReminderPrefs_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := &fakeReminderSvc{}
+
+	for _, tc := range []struct {
+		method, body string
+		code         int
+	}{
+		{http.MethodGet, "", http.StatusOK},
+		{http.MethodPut, `{"channel":"webhook","webhook_url":"https://example.com/remind","lead_minutes":15}`, http.StatusOK},
+		{http.MethodGet, "", http.StatusOK},
+		{http.MethodPut, `{"channel":"email","email":"nope"}`, http.StatusBadRequest},
+	} {
+		req := httptest.NewRequest(tc.method, "/api/reminders/preferences", bytes.NewBufferString(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		if rec := serveAndValidateWith(t, doc, svc, req); rec.Code != tc.code {
+			t.Fatalf("%s: expected %d, got %d; body=%s", tc.method, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+}
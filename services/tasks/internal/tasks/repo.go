@@ -3,12 +3,16 @@ package tasks
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/config"
 	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -16,9 +20,11 @@ import (
 //
 // db holds the shared connection pool.
 // qry holds sqlc’s generated query wrapper bound to that pool.
+// eventSourced turns on the event store (see eventstore.go).
 type Repo struct {
-	db  *pgxpool.Pool
-	qry *gen.Queries
+	db           *pgxpool.Pool
+	qry          *gen.Queries
+	eventSourced bool
 }
 
 // NewRepo creates a pgx pool using a bounded context and verifies connectivity.
@@ -37,8 +43,9 @@ func NewRepo(parent context.Context, cfg config.Config) (*Repo, error) {
 		return nil, fmt.Errorf("db ping: %w", err)
 	}
 	return &Repo{
-		db:  pool,
-		qry: gen.New(pool),
+		db:           pool,
+		qry:          gen.New(pool),
+		eventSourced: cfg.EventSourcing,
 	}, nil
 }
 
@@ -48,41 +55,402 @@ func (r *Repo) Close() {
 	r.db.Close()
 }
 
-// List fetches all tasks from the database.
-// It calls the sqlc-generated query ListTasks(ctx),
+// PoolStats is a snapshot of the pgx connection pool, reported by /readyz.
+type PoolStats struct {
+	MaxConns      int32 `json:"max_conns"`
+	TotalConns    int32 `json:"total_conns"`
+	IdleConns     int32 `json:"idle_conns"`
+	AcquiredConns int32 `json:"acquired_conns"`
+	// EmptyAcquireCount counts acquires that had to wait for a connection;
+	// if it keeps climbing the pool is too small for the load.
+	EmptyAcquireCount int64 `json:"empty_acquire_count"`
+}
+
+// HealthCheck pings Postgres through the pool and returns pool stats.
+// Callers should pass a context with a short deadline: a hung ping must
+// make the readiness probe fail, not hang.
+func (r *Repo) HealthCheck(ctx context.Context) (PoolStats, error) {
+	s := r.db.Stat()
+	stats := PoolStats{
+		MaxConns:          s.MaxConns(),
+		TotalConns:        s.TotalConns(),
+		IdleConns:         s.IdleConns(),
+		AcquiredConns:     s.AcquiredConns(),
+		EmptyAcquireCount: s.EmptyAcquireCount(),
+	}
+	if err := r.db.Ping(ctx); err != nil {
+		return stats, fmt.Errorf("db ping: %w", err)
+	}
+	return stats, nil
+}
+
+// List fetches one page of tasks from the database.
+// It calls the sqlc-generated query ListTasks(ctx, ...),
 // then maps the raw DB row structs into the domain Task model.
-func (r *Repo) List(ctx context.Context) ([]Task, error) {
-	// Run the sqlc-generated query (SELECT * FROM tasks ...).
-	rows, err := r.qry.ListTasks(ctx)
+//
+// Pagination is keyset-based: the opaque cursor holds the sort key of the
+// last row already returned, and the query resumes strictly after it. We ask
+// for one row more than the limit to learn whether another page exists.
+//
+// Like every Repo method, it only sees tasks belonging to owner.
+func (r *Repo) List(ctx context.Context, owner string, opts ListOptions) (TaskPage, error) {
+	if opts.Sort == "" {
+		opts.Sort = DefaultSortOrder
+	}
+	params := gen.ListTasksParams{OwnerID: owner, Sort: string(opts.Sort), Trashed: opts.Trashed}
+	if opts.Done != nil {
+		params.Done = pgtype.Bool{Bool: *opts.Done, Valid: true}
+	}
+	if opts.Query != "" {
+		params.Q = pgtype.Text{String: escapeLike(opts.Query), Valid: true}
+	}
+	if opts.Tag != "" {
+		params.Tag = pgtype.Text{String: opts.Tag, Valid: true}
+	}
+	params.DueBefore = timestamptz(opts.DueBefore)
+	if opts.Cursor != "" {
+		c, err := decodeCursor(opts.Cursor, opts.Sort)
+		if err != nil {
+			return TaskPage{}, err
+		}
+		params.AfterID = pgtype.Int4{Int32: c.ID, Valid: true}
+		params.AfterCreatedAt = pgtype.Timestamptz{Time: c.CreatedAt, Valid: true}
+	}
+	if opts.Limit > 0 {
+		params.Lim = pgtype.Int4{Int32: int32(opts.Limit) + 1, Valid: true}
+	}
+
+	// Run the sqlc-generated query (SELECT ... FROM tasks WHERE ... LIMIT ...).
+	rows, err := r.qry.ListTasks(ctx, params)
 	if err != nil {
-		return nil, err
+		return TaskPage{}, dbError(err, "task")
+	}
+
+	page := TaskPage{}
+	if opts.Limit > 0 && len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		last := rows[len(rows)-1]
+		next := encodeCursor(cursor{Sort: opts.Sort, ID: last.ID, CreatedAt: last.CreatedAt.Time})
+		page.NextCursor = &next
 	}
 
 	// Map sqlc's row structs into our domain model Task.
-	out := make([]Task, 0, len(rows))
+	page.Items = make([]Task, 0, len(rows))
 	for _, t := range rows {
-		out = append(out, Task{
-			ID:        t.ID,
-			Title:     t.Title,
-			Done:      t.Done,
-			CreatedAt: t.CreatedAt.Time,
-			UpdatedAt: t.UpdatedAt.Time,
+		page.Items = append(page.Items, taskFromRow(taskRow(t)))
+	}
+	if err := withTags(ctx, r.qry, page.Items); err != nil {
+		return TaskPage{}, dbError(err, "task")
+	}
+	return page, nil
+}
+
+// escapeLike escapes LIKE/ILIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// Get fetches a single task by id.
+// It returns an apperr.KindNotFound error if no such task exists, or if it
+// belongs to someone else (we don't reveal that it exists).
+func (r *Repo) Get(ctx context.Context, owner string, id int32) (Task, error) {
+	row, err := r.qry.GetTask(ctx, gen.GetTaskParams{ID: id, OwnerID: owner})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	t, err := taskWithTags(ctx, r.qry, taskFromRow(taskRow(row)))
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// Create inserts a new task owned by owner, with its tags, and returns the
+// created row. Its EventCreated is written to the outbox in the same
+// transaction.
+func (r *Repo) Create(ctx context.Context, owner string, n NewTask) (Task, error) {
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) (err error) {
+		t, err = createTask(ctx, q, owner, n)
+		return err
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// Update applies a partial update and returns the updated row.
+// Nil fields in the patch are left unchanged by the query (COALESCE); set
+// Tags replace the task's tags.
+// Like Create, it records an EventUpdated in the outbox transactionally.
+// It returns an apperr.KindNotFound error if no such task exists, and an
+// apperr.KindPreconditionFailed error if p.IfVersion is set but stale.
+func (r *Repo) Update(ctx context.Context, owner string, id int32, p TaskPatch) (Task, error) {
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) (err error) {
+		t, err = updateTask(ctx, q, owner, id, p)
+		return err
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// Delete moves a task to the trash and returns it, deleted_at set. It stays
+// there, invisible to Get/Update, until Restore or PurgeTrash.
+// It returns the same not-found error as Get/Update if no such task exists
+// (or it is already in the trash).
+func (r *Repo) Delete(ctx context.Context, owner string, id int32) (Task, error) {
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) (err error) {
+		t, err = deleteTask(ctx, q, owner, id)
+		return err
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// Restore takes a task out of the trash. Its outbox event is an
+// EventUpdated (deleted_at cleared), like any other change to a live task.
+func (r *Repo) Restore(ctx context.Context, owner string, id int32) (Task, error) {
+	var t Task
+	err := r.withTx(ctx, func(ctx context.Context, q *gen.Queries) (err error) {
+		t, err = restoreTask(ctx, q, owner, id)
+		return err
+	})
+	if err != nil {
+		return Task{}, dbError(err, "task")
+	}
+	return t, nil
+}
+
+// purgeBatchSize is how many trashed tasks PurgeTrash deletes per statement.
+const purgeBatchSize = 1000
+
+// PurgeTrash permanently deletes every owner's tasks that were trashed
+// before cutoff, and reports how many. Their EventDeleted went out when
+// they were trashed, so nothing more is recorded.
+func (r *Repo) PurgeTrash(ctx context.Context, cutoff time.Time) (int64, error) {
+	var total int64
+	for {
+		n, err := r.qry.PurgeTrashedTasks(ctx, gen.PurgeTrashedTasksParams{
+			Cutoff: pgtype.Timestamptz{Time: cutoff, Valid: true},
+			Lim:    purgeBatchSize,
 		})
+		total += n
+		if err != nil {
+			return total, dbError(err, "task")
+		}
+		if n < purgeBatchSize {
+			return total, nil
+		}
 	}
-	return out, nil
 }
 
-// Create inserts a new task and returns the created row.
-func (r *Repo) Create(ctx context.Context, title string) (Task, error) {
-	row, err := r.qry.CreateTask(ctx, title)
+// createTask, updateTask and deleteTask are the bodies of Create, Update and
+// Delete, run with q bound to the caller's transaction so that Batch can
+// apply several of them atomically.
+func createTask(ctx context.Context, q *gen.Queries, owner string, n NewTask) (Task, error) {
+	params := gen.CreateTaskParams{
+		Title:       n.Title,
+		OwnerID:     owner,
+		Description: n.Description,
+		DueAt:       timestamptz(n.DueAt),
+		RemindAt:    timestamptz(n.RemindAt),
+		Priority:    gen.TaskPriority(n.Priority),
+	}
+	if n.ListID != nil {
+		pos, err := listPosition(ctx, q, owner, *n.ListID, 0, TaskMove{ListID: n.ListID})
+		if err != nil {
+			return Task{}, err
+		}
+		params.ListID = pgtype.Int4{Int32: *n.ListID, Valid: true}
+		params.Position = pgtype.Float8{Float64: pos, Valid: true}
+	}
+	s, err := newSchedule(n.RRule, seriesStart(n.DueAt), n.DueAt)
 	if err != nil {
 		return Task{}, err
 	}
-	return Task{
-		ID:        row.ID,
-		Title:     row.Title,
-		Done:      row.Done,
-		CreatedAt: row.CreatedAt.Time,
-		UpdatedAt: row.UpdatedAt.Time,
-	}, nil
+	params.Rrule, params.RruleStartAt, params.NextOccurrenceAt = s.rule, s.start, s.next
+	row, err := q.CreateTask(ctx, params)
+	if err != nil {
+		return Task{}, err
+	}
+	t := taskFromRow(taskRow(row))
+	if t.Tags, err = setTags(ctx, q, owner, t.ID, n.Tags); err != nil {
+		return Task{}, err
+	}
+	return t, recordChange(ctx, q, nil, Event{Type: EventCreated, Task: t})
+}
+
+func updateTask(ctx context.Context, q *gen.Queries, owner string, id int32, p TaskPatch) (Task, error) {
+	params := gen.UpdateTaskParams{ID: id, OwnerID: owner}
+	if p.Title != nil {
+		params.Title = pgtype.Text{String: *p.Title, Valid: true}
+	}
+	if p.Done != nil {
+		params.Done = pgtype.Bool{Bool: *p.Done, Valid: true}
+	}
+	if p.Description != nil {
+		params.Description = pgtype.Text{String: *p.Description, Valid: true}
+	}
+	if p.Priority != nil {
+		params.Priority = gen.NullTaskPriority{TaskPriority: gen.TaskPriority(*p.Priority), Valid: true}
+	}
+	if p.DueAt.Set {
+		params.SetDueAt, params.DueAt = true, timestamptz(p.DueAt.Time)
+	}
+	if p.RemindAt.Set {
+		params.SetRemindAt, params.RemindAt = true, timestamptz(p.RemindAt.Time)
+	}
+	if p.IfVersion != nil {
+		params.IfVersion = pgtype.Int4{Int32: *p.IfVersion, Valid: true}
+	}
+	params.SetTags = p.Tags != nil
+	s, set, err := patchSchedule(ctx, q, owner, id, p)
+	if err != nil {
+		return Task{}, err
+	}
+	if set {
+		params.SetRecurrence = true
+		params.Rrule, params.RruleStartAt, params.NextOccurrenceAt = s.rule, s.start, s.next
+	}
+	before, err := snapshot(ctx, q, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+	row, err := q.UpdateTask(ctx, params)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// No row matched id (AND version). Tell "gone" apart from "changed".
+		if p.IfVersion != nil {
+			if _, gerr := q.GetTask(ctx, gen.GetTaskParams{ID: id, OwnerID: owner}); gerr == nil {
+				return Task{}, errVersionMismatch()
+			}
+		}
+		return Task{}, errTaskNotFound()
+	}
+	if err != nil {
+		return Task{}, err
+	}
+	t := taskFromRow(taskRow(row))
+	if p.Tags != nil {
+		t.Tags, err = setTags(ctx, q, owner, t.ID, *p.Tags)
+	} else {
+		t, err = taskWithTags(ctx, q, t)
+	}
+	if err != nil {
+		return Task{}, err
+	}
+	return t, recordChange(ctx, q, before, Event{Type: EventUpdated, Task: t})
+}
+
+func deleteTask(ctx context.Context, q *gen.Queries, owner string, id int32) (Task, error) {
+	before, err := snapshot(ctx, q, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+	row, err := q.DeleteTask(ctx, gen.DeleteTaskParams{ID: id, OwnerID: owner})
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := taskWithTags(ctx, q, taskFromRow(taskRow(row)))
+	if err != nil {
+		return Task{}, err
+	}
+	return t, recordChange(ctx, q, before, Event{Type: EventDeleted, Task: t})
+}
+
+func restoreTask(ctx context.Context, q *gen.Queries, owner string, id int32) (Task, error) {
+	before, err := snapshot(ctx, q, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+	row, err := q.RestoreTask(ctx, gen.RestoreTaskParams{ID: id, OwnerID: owner})
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := taskWithTags(ctx, q, taskFromRow(taskRow(row)))
+	if err != nil {
+		return Task{}, err
+	}
+	return t, recordChange(ctx, q, before, Event{Type: EventUpdated, Task: t})
+}
+
+// setTags makes tags (normalized, see normalizeTags) the task's only tags
+// and returns them.
+func setTags(ctx context.Context, q *gen.Queries, owner string, taskID int32, tags []string) ([]string, error) {
+	if err := q.ClearTaskTags(ctx, taskID); err != nil {
+		return nil, err
+	}
+	if len(tags) > 0 {
+		if err := q.AddTaskTags(ctx, gen.AddTaskTagsParams{TaskID: taskID, OwnerID: owner, Names: tags}); err != nil {
+			return nil, err
+		}
+	}
+	return tags, nil
+}
+
+// taskWithTags is withTags for a single task.
+func taskWithTags(ctx context.Context, q *gen.Queries, t Task) (Task, error) {
+	ts := []Task{t}
+	err := withTags(ctx, q, ts)
+	return ts[0], err
+}
+
+// withTags loads the tags of tasks into their Tags fields, in one query.
+func withTags(ctx context.Context, q *gen.Queries, tasks []Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	ids := make([]int32, len(tasks))
+	byID := make(map[int32]*Task, len(tasks))
+	for i := range tasks {
+		ids[i] = tasks[i].ID
+		byID[tasks[i].ID] = &tasks[i]
+	}
+	rows, err := q.ListTaskTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		t := byID[row.TaskID]
+		t.Tags = append(t.Tags, row.Name)
+	}
+	return nil
+}
+
+// withTx runs fn with queries bound to a new transaction, committing if fn
+// returns nil and rolling back otherwise. Mutations use it so that the row
+// change, its outbox event, its audit entry and (in event-sourced mode) its
+// stored events (see recordChange) commit (or not) together.
+//
+// fn gets the transaction's context: ctx plus what recordChange needs to
+// know about the Repo.
+func (r *Repo) withTx(ctx context.Context, fn func(ctx context.Context, q *gen.Queries) error) error {
+	return r.withTxConn(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		return fn(ctx, r.qry.WithTx(tx))
+	})
+}
+
+// withTxConn is withTx for callers that need the transaction itself, e.g.
+// to open savepoints (see Batch).
+func (r *Repo) withTxConn(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	// Rollback after a successful Commit is a no-op.
+	defer func() { _ = tx.Rollback(context.WithoutCancel(ctx)) }()
+
+	if r.eventSourced {
+		ctx = withEventStore(ctx)
+	}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+)
+
+// Result count bounds for GET /api/tasks/search (?limit=).
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// MaxSearchQueryLength bounds a search query, in Unicode code points.
+const MaxSearchQueryLength = 256
+
+// SearchResult is one hit of a full-text search. Rank orders the results
+// (higher is better); Snippet is the best-matching part of the task's title
+// and description, HTML-escaped, with each matched word wrapped in
+// <mark>...</mark>.
+type SearchResult struct {
+	Task    Task    `json:"task"`
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// Search returns the caller's live tasks whose title or description matches
+// q, best match first (title matches rank higher), at most limit of them.
+// q uses web-search syntax: words are ANDed, "quoted text" is a phrase,
+// "or" separates alternatives and a leading "-" excludes a word. Words are
+// stemmed, so "buying" finds "Buy milk".
+func (s *Service) Search(ctx context.Context, q string, limit int) ([]SearchResult, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q, err = normalizeSearchQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if limit < 1 || limit > MaxSearchLimit {
+		return nil, invalidParam("limit")
+	}
+	return s.repo.Search(ctx, owner, q, limit)
+}
+
+// normalizeSearchQuery trims q and checks it is non-empty and not too long.
+func normalizeSearchQuery(q string) (string, error) {
+	q = strings.TrimSpace(q)
+	switch {
+	case q == "":
+		return "", apperr.Validation("invalid_q", "q is required",
+			apperr.FieldError{Field: "q", Reason: ReasonRequired})
+	case !utf8.ValidString(q):
+		return "", apperr.Validation("invalid_q", "q is not valid UTF-8",
+			apperr.FieldError{Field: "q", Reason: ReasonInvalidUTF8})
+	case utf8.RuneCountInString(q) > MaxSearchQueryLength:
+		return "", apperr.Validation("invalid_q", fmt.Sprintf("q must be at most %d characters", MaxSearchQueryLength),
+			apperr.FieldError{Field: "q", Reason: ReasonTooLong})
+	}
+	return q, nil
+}
+
+// Search runs the full-text query (see SearchTasks in queries/tasks.sql).
+// A query with no searchable words, e.g. only stop words like "the", matches
+// nothing rather than failing.
+func (r *Repo) Search(ctx context.Context, owner, q string, limit int) ([]SearchResult, error) {
+	rows, err := r.qry.SearchTasks(ctx, gen.SearchTasksParams{OwnerID: owner, Q: q, Lim: int32(limit)})
+	if err != nil {
+		return nil, dbError(err, "task")
+	}
+	results := make([]SearchResult, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, SearchResult{
+			Task: taskFromRow(taskRow{
+				ID:               row.ID,
+				Title:            row.Title,
+				Done:             row.Done,
+				CreatedAt:        row.CreatedAt,
+				UpdatedAt:        row.UpdatedAt,
+				Version:          row.Version,
+				OwnerID:          row.OwnerID,
+				DeletedAt:        row.DeletedAt,
+				Description:      row.Description,
+				DueAt:            row.DueAt,
+				Priority:         row.Priority,
+				ListID:           row.ListID,
+				Position:         row.Position,
+				ParentID:         row.ParentID,
+				Rrule:            row.Rrule,
+				NextOccurrenceAt: row.NextOccurrenceAt,
+				RemindAt:         row.RemindAt,
+			}),
+			Rank:    row.Rank,
+			Snippet: highlightSnippet(row.Snippet),
+		})
+	}
+	found := make([]Task, len(results))
+	for i := range results {
+		found[i] = results[i].Task
+	}
+	if err := withTags(ctx, r.qry, found); err != nil {
+		return nil, dbError(err, "task")
+	}
+	for i := range results {
+		results[i].Task = found[i]
+	}
+	return results, nil
+}
+
+// highlightSnippet turns a ts_headline result, whose matches are delimited
+// by \x02 and \x03, into HTML: the text is escaped and the delimiters become
+// <mark> tags. Titles and descriptions cannot contain those control
+// characters, so the delimiters are unambiguous.
+func highlightSnippet(s string) string {
+	return strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(html.EscapeString(s))
+}
@@ -0,0 +1,52 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// TaskSearcher is the optional capability behind GET /api/tasks/search.
+type TaskSearcher interface {
+	Search(ctx context.Context, q string, limit int) ([]SearchResult, error)
+}
+
+// searchTasks serves GET /api/tasks/search?q=&limit=, responding with
+// {"items": [SearchResult...]}.
+func searchTasks(svc TaskLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s, ok := svc.(TaskSearcher)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("search_not_supported", "search not supported"))
+			return
+		}
+
+		q, err := normalizeSearchQuery(c.Query("q"))
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		limit := DefaultSearchLimit
+		if v, has := c.GetQuery("limit"); has {
+			limit, err = strconv.Atoi(v)
+			if err != nil || limit < 1 || limit > MaxSearchLimit {
+				apperr.Write(c, invalidParam("limit"))
+				return
+			}
+		}
+
+		results, err := s.Search(c.Request.Context(), q, limit)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		if results == nil {
+			results = []SearchResult{} // "items": [], never null
+		}
+		c.JSON(http.StatusOK, gin.H{"items": results})
+	}
+}
@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSearchSvc matches titles containing q, case-insensitively, and
+// highlights the match the way Repo.Search's snippets do.
+type fakeSearchSvc struct {
+	fakeSvc
+	lastQ     string
+	lastLimit int
+}
+
+func (f *fakeSearchSvc) Search(ctx context.Context, q string, limit int) ([]SearchResult, error) {
+	f.lastQ, f.lastLimit = q, limit
+	now := time.Now().UTC()
+	var out []SearchResult
+	for i, title := range []string{"Buy milk", "Walk the dog", "Milk & cookies"} {
+		at := strings.Index(strings.ToLower(title), strings.ToLower(q))
+		if at < 0 || len(out) == limit {
+			continue
+		}
+		out = append(out, SearchResult{
+			Task:    Task{ID: int32(i + 1), Title: title, CreatedAt: now, UpdatedAt: now, Version: 1, Priority: PriorityNormal, Tags: []string{}},
+			Rank:    0.1,
+			Snippet: highlightSnippet(title[:at] + "\x02" + title[at:at+len(q)] + "\x03" + title[at+len(q):]),
+		})
+	}
+	return out, nil
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	got := highlightSnippet("a <b> & \x02milk\x03 \"\x02run\x03\"")
+	if want := "a &lt;b&gt; &amp; <mark>milk</mark> &#34;<mark>run</mark>&#34;"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestGETSearch_ReturnsRankedItems(t *testing.T) {
+	svc := &fakeSearchSvc{}
+	r := newTestRouter(svc)
+
+	w := doJSON(t, r, http.MethodGet, "/api/tasks/search?q=+milk+", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Items []SearchResult `json:"items"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if svc.lastQ != "milk" || svc.lastLimit != DefaultSearchLimit {
+		t.Fatalf("expected q=milk limit=%d, got %q %d", DefaultSearchLimit, svc.lastQ, svc.lastLimit)
+	}
+	if len(resp.Items) != 2 || resp.Items[1].Snippet != "<mark>Milk</mark> &amp; cookies" {
+		t.Fatalf("unexpected items %+v", resp.Items)
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/tasks/search?q=dragons&limit=5", "")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"items":[]}` || svc.lastLimit != 5 {
+		t.Fatalf("no match: expected empty items, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGETSearch_RejectsInvalidQuery(t *testing.T) {
+	r := newTestRouter(&fakeSearchSvc{})
+	for query, code := range map[string]string{
+		"":      "invalid_q",
+		"q=+++": "invalid_q",
+		"q=" + strings.Repeat("m", MaxSearchQueryLength+1): "invalid_q",
+		"q=milk&limit=0":   "invalid_limit",
+		"q=milk&limit=101": "invalid_limit",
+		"q=milk&limit=ten": "invalid_limit",
+	} {
+		w := doJSON(t, r, http.MethodGet, "/api/tasks/search?"+query, "")
+		if p := decodeProblem(t, w); w.Code != http.StatusBadRequest || p.Code != code {
+			t.Fatalf("%q: expected 400 %s, got %d %+v", query, code, w.Code, p)
+		}
+	}
+
+	r = newTestRouter(&fakeSvc{})
+	if w := doJSON(t, r, http.MethodGet, "/api/tasks/search?q=milk", ""); w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without TaskSearcher, got %d", w.Code)
+	}
+}
+
+func Test_Server_Search_MatchesOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+
+	for _, target := range []string{
+		"/api/tasks/search?q=milk",
+		"/api/tasks/search?q=milk&limit=1",
+		"/api/tasks/search?q=dragons",
+		"/api/tasks/search?q=",
+	} {
+		serveAndValidateWith(t, doc, &fakeSearchSvc{}, httptest.NewRequest(http.MethodGet, target, nil))
+	}
+}
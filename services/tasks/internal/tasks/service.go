@@ -1,29 +1,193 @@
 package tasks
 
-import "context"
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/auth"
+)
 
 // Service is the domain layer for "tasks" use-cases.
 // It depends on a Repo to persist/fetch data, but exposes
 // business-oriented methods to the HTTP/GraphQL layers.
+//
+// Successful mutations emit an Event (see events.go), which subscribers
+// receive via Subscribe once RunChangeFeed is running, and are recorded in
+// the audit log with the caller and request that made them (see audit.go).
 type Service struct {
-	repo *Repo
+	repo           *Repo
+	broker         *Broker
+	idempotencyTTL time.Duration
 }
 
 // NewService wires a Service to a concrete Repo.
 func NewService(r *Repo) *Service {
-	return &Service{repo: r}
+	return &Service{repo: r, broker: NewBroker(), idempotencyTTL: DefaultIdempotencyTTL}
 }
 
-// List returns all tasks for the current request.
+// List returns a page of tasks matching opts.
 // It forwards the request Context so cancellations/timeouts propagate
 // down to the DB queries via the repo.
-func (s *Service) List(ctx context.Context) ([]Task, error) {
-	return s.repo.List(ctx)
+//
+// Every method is scoped to the caller identified in ctx (set by the
+// auth middleware): a caller only ever sees and changes their own tasks.
+func (s *Service) List(ctx context.Context, opts ListOptions) (TaskPage, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return TaskPage{}, err
+	}
+	if opts.Tag != "" {
+		if opts.Tag, err = NormalizeTag(opts.Tag); err != nil {
+			return TaskPage{}, err
+		}
+	}
+	return s.repo.List(ctx, owner, opts)
+}
+
+// Create adds a new task.
+// Its fields are validated and normalized first (see NormalizeTitle,
+// NormalizeDescription and NormalizeTag), so every caller — REST, GraphQL,
+// tests — gets the same rules.
+func (s *Service) Create(ctx context.Context, n NewTask) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	n, err = n.normalize()
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := s.repo.Create(ctx, owner, n)
+	if err != nil {
+		return Task{}, err
+	}
+	s.emit(ctx, Event{Type: EventCreated, Task: t})
+	return t, nil
+}
+
+// Get returns a single task by id.
+func (s *Service) Get(ctx context.Context, id int32) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	return s.repo.Get(ctx, owner, id)
+}
+
+// Update applies a partial update to the task with the given id.
+// Like Create, it validates and normalizes the patch first.
+func (s *Service) Update(ctx context.Context, id int32, p TaskPatch) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	p, err = p.normalize()
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := s.repo.Update(ctx, owner, id, p)
+	if err != nil {
+		return Task{}, err
+	}
+	s.emit(ctx, Event{Type: EventUpdated, Task: t})
+	return t, nil
+}
+
+// Delete moves the task with the given id to the trash. It can be brought
+// back with Restore until the purger removes it for good (see RunTrashPurge).
+func (s *Service) Delete(ctx context.Context, id int32) error {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	t, err := s.repo.Delete(ctx, owner, id)
+	if err != nil {
+		return err
+	}
+	s.emit(ctx, Event{Type: EventDeleted, Task: t})
+	return nil
+}
+
+// Restore takes the task with the given id out of the trash.
+// Subscribers see it as an EventUpdated with deleted_at cleared.
+func (s *Service) Restore(ctx context.Context, id int32) (Task, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Task{}, err
+	}
+	t, err := s.repo.Restore(ctx, owner, id)
+	if err != nil {
+		return Task{}, err
+	}
+	s.emit(ctx, Event{Type: EventUpdated, Task: t})
+	return t, nil
+}
+
+// RunTrashPurge permanently deletes tasks that have been in the trash for
+// longer than retention, checking every interval until ctx is cancelled.
+// It covers every owner, and is safe to run on every replica.
+func (s *Service) RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := s.repo.PurgeTrash(ctx, time.Now().Add(-retention)); err != nil && ctx.Err() == nil {
+			log.Printf("tasks: purge trash: %v", err)
+		} else if n > 0 {
+			log.Printf("tasks: purged %d tasks trashed more than %s ago", n, retention)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Subscribe streams change events for the caller's tasks until ctx is
+// done. The channel is also closed if the subscriber falls behind or the
+// service shuts down; clients should re-fetch and subscribe again.
+func (s *Service) Subscribe(ctx context.Context) (<-chan Event, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events, cancel := s.broker.Subscribe(owner)
+	context.AfterFunc(ctx, cancel)
+	return events, nil
+}
+
+// RunChangeFeed relays events from Postgres LISTEN/NOTIFY to this
+// process's subscribers until ctx is cancelled. Because every replica
+// listens, a change made through any replica reaches every subscriber.
+func (s *Service) RunChangeFeed(ctx context.Context) error {
+	return s.repo.Listen(ctx, s.broker.Publish)
+}
+
+// CloseSubscriptions ends every open Subscribe stream (used at shutdown).
+func (s *Service) CloseSubscriptions() {
+	s.broker.Close()
+}
+
+// emit publishes ev to all replicas. The change is already committed, so a
+// failure here is logged rather than failing the request. The request may
+// be finishing as we notify, hence WithoutCancel.
+func (s *Service) emit(ctx context.Context, ev Event) {
+	if err := s.repo.Notify(context.WithoutCancel(ctx), ev); err != nil {
+		log.Printf("tasks: notify %s task %d: %v", ev.Type, ev.Task.ID, err)
+	}
 }
 
-// Create adds a new task with the given title.
-func (s *Service) Create(ctx context.Context, title string) (Task, error) {
-	return s.repo.Create(ctx, title)
+// callerID returns the subject of the authenticated caller. A context
+// without an identity means the request bypassed the auth middleware,
+// which we treat as unauthenticated rather than as "everyone".
+func callerID(ctx context.Context) (string, error) {
+	id, ok := auth.FromContext(ctx)
+	if !ok || id.Subject == "" {
+		return "", apperr.Unauthenticated("unauthenticated", "authentication required")
+	}
+	return id.Subject, nil
 }
 
 // A note about the func (receiver) syntax.
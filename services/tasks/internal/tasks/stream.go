@@ -0,0 +1,145 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// TaskSubscriber is the optional capability behind GET /api/tasks/stream.
+type TaskSubscriber interface {
+	Subscribe(ctx context.Context) (<-chan Event, error)
+}
+
+// streamHeartbeat is how often an idle stream sends a keep-alive, so that
+// proxies and load balancers don't time the connection out.
+var streamHeartbeat = 15 * time.Second
+
+// wsWriteTimeout bounds each WebSocket write to a stalled client.
+const wsWriteTimeout = 10 * time.Second
+
+var upgrader = websocket.Upgrader{
+	// The default origin check (same host only) applies: the frontends are
+	// served through a proxy on the API's origin.
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// streamTasks serves GET /api/tasks/stream: the caller's task events, as
+// Server-Sent Events, or as JSON WebSocket messages if the client asks to
+// upgrade. Each message is an Event: {"type":"created","task":{...}}.
+//
+// The stream ends when the client goes away, when it falls too far behind,
+// or at shutdown. Clients should then re-fetch GET /api/tasks and reconnect
+// (EventSource reconnects by itself).
+func streamTasks(svc TaskLister) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sub, ok := svc.(TaskSubscriber)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("stream_not_supported", "stream not supported"))
+			return
+		}
+
+		if websocket.IsWebSocketUpgrade(c.Request) {
+			serveWebSocket(c, sub)
+			return
+		}
+
+		events, err := sub.Subscribe(c.Request.Context())
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		serveSSE(c, events)
+	}
+}
+
+func serveSSE(c *gin.Context, events <-chan Event) {
+	// The http.Server WriteTimeout would cut the stream off mid-way, so lift
+	// it for this response. (Not supported by test recorders; ignore that.)
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no") // tell nginx-style proxies not to buffer
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(ev) // cannot fail for Event
+			fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", ev.Type, data)
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+func serveWebSocket(c *gin.Context, sub TaskSubscriber) {
+	// Subscribe before upgrading so errors (e.g. 401) are still plain HTTP.
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+	events, err := sub.Subscribe(ctx)
+	if err != nil {
+		apperr.Write(c, err)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return // Upgrade has already written an HTTP error
+	}
+	defer conn.Close()
+
+	// We never expect messages from the client, but must read to process
+	// control frames and to notice when it goes away.
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-events:
+			if !ok {
+				_ = conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "resubscribe"))
+				return
+			}
+			if err := conn.WriteJSON(ev); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
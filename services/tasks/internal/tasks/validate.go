@@ -0,0 +1,205 @@
+package tasks
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// MaxTitleLength is the longest title we accept, in Unicode code points
+// (not bytes). The same limit is declared in api/openapi.yaml and enforced
+// by the tasks_title_length CHECK constraint.
+const MaxTitleLength = 200
+
+// Description, tag and tag count limits, enforced the same way as
+// MaxTitleLength (the tasks_description_length and tags_name_lower CHECKs).
+const (
+	MaxDescriptionLength = 10000
+	MaxTagLength         = 32
+	MaxTags              = 20
+)
+
+// Field-level validation reasons, reported as {"field":..., "reason":...}.
+const (
+	ReasonRequired     = "required"
+	ReasonTooLong      = "too_long"
+	ReasonControlChars = "control_chars"
+	ReasonInvalidUTF8  = "invalid_utf8"
+)
+
+// NormalizeTitle returns the canonical form of a task title, or a
+// validation error describing why it is unacceptable.
+//
+// Titles are NFC-normalized (so "é" typed as e + combining accent is stored
+// the same as the precomposed "é", and compares/searches equal), trimmed,
+// and must be 1..MaxTitleLength code points with no control characters
+// (newlines, tabs, NUL, escape sequences, ...).
+func NormalizeTitle(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", titleError(ReasonInvalidUTF8, "title is not valid UTF-8")
+	}
+	s = strings.TrimSpace(norm.NFC.String(s))
+	switch {
+	case s == "":
+		return "", titleError(ReasonRequired, "title is required")
+	case strings.ContainsFunc(s, unicode.IsControl):
+		return "", titleError(ReasonControlChars, "title must not contain control characters")
+	case utf8.RuneCountInString(s) > MaxTitleLength:
+		return "", titleError(ReasonTooLong, fmt.Sprintf("title must be at most %d characters", MaxTitleLength))
+	}
+	return s, nil
+}
+
+// NormalizeDescription is NormalizeTitle for descriptions: NFC-normalized,
+// trimmed, with line endings turned into "\n". Descriptions are markdown, so
+// newlines and tabs are allowed, but no other control characters. An empty
+// description is fine.
+func NormalizeDescription(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", descriptionError(ReasonInvalidUTF8, "description is not valid UTF-8")
+	}
+	s = strings.ReplaceAll(norm.NFC.String(s), "\r\n", "\n")
+	s = strings.TrimSpace(strings.ReplaceAll(s, "\r", "\n"))
+	switch {
+	case strings.ContainsFunc(s, func(r rune) bool { return unicode.IsControl(r) && r != '\n' && r != '\t' }):
+		return "", descriptionError(ReasonControlChars, "description must not contain control characters other than newlines and tabs")
+	case utf8.RuneCountInString(s) > MaxDescriptionLength:
+		return "", descriptionError(ReasonTooLong, fmt.Sprintf("description must be at most %d characters", MaxDescriptionLength))
+	}
+	return s, nil
+}
+
+// NormalizeTag returns the canonical form of a tag name: NFC-normalized,
+// trimmed and lower-cased, so "Work" and " work" are the same tag. It must
+// be 1..MaxTagLength code points with no control characters or commas.
+func NormalizeTag(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", tagError(ReasonInvalidUTF8, "tag is not valid UTF-8")
+	}
+	s = strings.ToLower(strings.TrimSpace(norm.NFC.String(s)))
+	switch {
+	case s == "":
+		return "", tagError(ReasonRequired, "tags must not be empty")
+	case strings.ContainsFunc(s, unicode.IsControl), strings.Contains(s, ","):
+		return "", tagError(ReasonControlChars, "tags must not contain control characters or commas")
+	case utf8.RuneCountInString(s) > MaxTagLength:
+		return "", tagError(ReasonTooLong, fmt.Sprintf("tags must be at most %d characters", MaxTagLength))
+	}
+	return s, nil
+}
+
+// normalizeTags normalizes each tag and returns them sorted, without
+// duplicates, and never nil.
+func normalizeTags(tags []string) ([]string, error) {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	out = slices.Compact(out)
+	if len(out) > MaxTags {
+		return nil, tagError(ReasonTooLong, fmt.Sprintf("at most %d tags per task", MaxTags))
+	}
+	return out, nil
+}
+
+// valid reports whether p is one of the Priority constants.
+func (p Priority) valid() bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// NormalizeNewTask validates and normalizes n the way Create does, for
+// callers that check their input before calling it (HTTP, GraphQL).
+func NormalizeNewTask(n NewTask) (NewTask, error) {
+	return n.normalize()
+}
+
+// normalize validates and normalizes a new task, filling in the default
+// priority.
+func (n NewTask) normalize() (NewTask, error) {
+	var err error
+	if n.Title, err = NormalizeTitle(n.Title); err != nil {
+		return n, err
+	}
+	if n.Description, err = NormalizeDescription(n.Description); err != nil {
+		return n, err
+	}
+	if n.Priority == "" {
+		n.Priority = PriorityNormal
+	} else if !n.Priority.valid() {
+		return n, invalidParam("priority")
+	}
+	if n.Tags, err = normalizeTags(n.Tags); err != nil {
+		return n, err
+	}
+	if n.RRule, err = NormalizeRRule(n.RRule); err != nil {
+		return n, err
+	}
+	return n, nil
+}
+
+// normalize validates and normalizes the fields present in the patch.
+func (p TaskPatch) normalize() (TaskPatch, error) {
+	if p.Title == nil && p.Done == nil && p.Description == nil && !p.DueAt.Set && !p.RemindAt.Set && p.Priority == nil && p.Tags == nil && p.RRule == nil {
+		return p, apperr.Validation("empty_patch", "no fields to update")
+	}
+	if p.Title != nil {
+		title, err := NormalizeTitle(*p.Title)
+		if err != nil {
+			return p, err
+		}
+		p.Title = &title
+	}
+	if p.Description != nil {
+		desc, err := NormalizeDescription(*p.Description)
+		if err != nil {
+			return p, err
+		}
+		p.Description = &desc
+	}
+	if p.Priority != nil && !p.Priority.valid() {
+		return p, invalidParam("priority")
+	}
+	if p.Tags != nil {
+		tags, err := normalizeTags(*p.Tags)
+		if err != nil {
+			return p, err
+		}
+		p.Tags = &tags
+	}
+	if p.RRule != nil {
+		rule, err := NormalizeRRule(*p.RRule)
+		if err != nil {
+			return p, err
+		}
+		p.RRule = &rule
+	}
+	return p, nil
+}
+
+func titleError(reason, msg string) error {
+	return apperr.Validation("invalid_title", msg, apperr.FieldError{Field: "title", Reason: reason})
+}
+
+func descriptionError(reason, msg string) error {
+	return apperr.Validation("invalid_description", msg, apperr.FieldError{Field: "description", Reason: reason})
+}
+
+func tagError(reason, msg string) error {
+	return apperr.Validation("invalid_tags", msg, apperr.FieldError{Field: "tags", Reason: reason})
+}
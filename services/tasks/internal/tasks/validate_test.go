@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"  Buy milk ", "Buy milk"},
+		{"Cafe\u0301", "Caf\u00e9"}, // e + combining acute → precomposed é
+		{strings.Repeat("é", MaxTitleLength), strings.Repeat("é", MaxTitleLength)},
+	}
+	for _, tc := range cases {
+		got, err := NormalizeTitle(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizeTitle(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestNormalizeTitle_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"":                                      ReasonRequired,
+		" \t ":                                  ReasonRequired,
+		strings.Repeat("x", MaxTitleLength+1):   ReasonTooLong,
+		"line one\nline two":                    ReasonControlChars,
+		"bell\a":                                ReasonControlChars,
+		"nul\x00byte":                           ReasonControlChars,
+		"bad \xff utf8":                         ReasonInvalidUTF8,
+		"Cafe" + strings.Repeat("\u0301", 1000): ReasonTooLong,
+	}
+	for in, reason := range cases {
+		_, err := NormalizeTitle(in)
+		e := apperr.From(err)
+		if e == nil || e.Code != "invalid_title" || len(e.Fields) != 1 ||
+			e.Fields[0] != (apperr.FieldError{Field: "title", Reason: reason}) {
+			t.Errorf("NormalizeTitle(%q): want reason %q, got %#v", in, reason, e)
+		}
+	}
+}
+
+func TestNormalizeDescription(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"  # Plan\r\n\r\n- milk\r- eggs\n\t- free range  \n", "# Plan\n\n- milk\n- eggs\n\t- free range"},
+		{strings.Repeat("é", MaxDescriptionLength), strings.Repeat("é", MaxDescriptionLength)},
+	}
+	for _, tc := range cases {
+		got, err := NormalizeDescription(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("NormalizeDescription(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+
+	for in, reason := range map[string]string{
+		strings.Repeat("x", MaxDescriptionLength+1): ReasonTooLong,
+		"bell\a":          ReasonControlChars,
+		"mark \x02me\x03": ReasonControlChars,
+		"bad \xff utf8":   ReasonInvalidUTF8,
+	} {
+		_, err := NormalizeDescription(in)
+		if e := apperr.From(err); e == nil || e.Code != "invalid_description" || e.Fields[0].Reason != reason {
+			t.Errorf("NormalizeDescription(%q): want reason %q, got %#v", in, reason, e)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{" Work", "home", "work", "HOME ", "Café"})
+	if err != nil || strings.Join(got, ",") != "café,home,work" {
+		t.Fatalf("normalizeTags = %q, %v", got, err)
+	}
+	if got, err := normalizeTags(nil); err != nil || got == nil || len(got) != 0 {
+		t.Fatalf("normalizeTags(nil) = %#v, %v; want an empty slice", got, err)
+	}
+
+	tooMany := make([]string, MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	for _, tc := range []struct {
+		in     []string
+		reason string
+	}{
+		{[]string{"ok", " "}, ReasonRequired},
+		{[]string{"a,b"}, ReasonControlChars},
+		{[]string{"tab\there"}, ReasonControlChars},
+		{[]string{strings.Repeat("t", MaxTagLength+1)}, ReasonTooLong},
+		{tooMany, ReasonTooLong},
+	} {
+		_, err := normalizeTags(tc.in)
+		if e := apperr.From(err); e == nil || e.Code != "invalid_tags" || e.Fields[0].Reason != tc.reason {
+			t.Errorf("normalizeTags(%q): want reason %q, got %#v", tc.in, tc.reason, e)
+		}
+	}
+}
+
+func TestNormalizeNewTask(t *testing.T) {
+	n, err := NormalizeNewTask(NewTask{Title: " Plan ", Tags: []string{"B", "a"}})
+	if err != nil || n.Title != "Plan" || n.Priority != PriorityNormal || strings.Join(n.Tags, ",") != "a,b" {
+		t.Fatalf("NormalizeNewTask = %+v, %v", n, err)
+	}
+	_, err = NormalizeNewTask(NewTask{Title: "x", Priority: "asap"})
+	if e := apperr.From(err); e == nil || e.Code != "invalid_priority" {
+		t.Fatalf("unknown priority: got %#v", e)
+	}
+}
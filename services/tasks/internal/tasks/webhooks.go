@@ -0,0 +1,400 @@
+package tasks
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	gen "github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/db/gen"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/safehttp"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/webhook"
+)
+
+// Webhook is a URL the owner wants their task events POSTed to.
+// Secret is the HMAC key for the X-Webhook-Signature header; it is only
+// returned when the webhook is created.
+type Webhook struct {
+	ID        int32     `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"` // topics to deliver; empty means all
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	OwnerID   string    `json:"owner_id"`
+}
+
+// WebhookInput is the body of POST /api/webhooks. An empty Secret asks
+// the server to generate one.
+type WebhookInput struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+// WebhookPatch is a partial update to a Webhook; nil means "leave unchanged".
+type WebhookPatch struct {
+	URL    *string   `json:"url"`
+	Events *[]string `json:"events"`
+	Active *bool     `json:"active"`
+}
+
+// WebhookDelivery is one entry in a webhook's delivery log.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int32           `json:"webhook_id"`
+	EventID        int64           `json:"event_id"`
+	Topic          string          `json:"topic"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         webhook.Status  `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	LastStatusCode *int32          `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	RedeliveryOf   *int64          `json:"redelivery_of"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+}
+
+// Delivery log page size bounds (?limit= on the deliveries listing).
+const (
+	DefaultDeliveryPageSize = 50
+	MaxDeliveryPageSize     = 200
+)
+
+// webhookTopics are the topics a webhook may subscribe to.
+var webhookTopics = []string{
+	OutboxTopic(EventCreated),
+	OutboxTopic(EventUpdated),
+	OutboxTopic(EventDeleted),
+}
+
+// normalizeWebhookURL accepts absolute http(s) URLs only, and not ones
+// that name a private or local address (see safehttp.CheckURL).
+func normalizeWebhookURL(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", apperr.Validation("invalid_url", "url must be an absolute http or https URL",
+			apperr.FieldError{Field: "url", Reason: "invalid"})
+	}
+	if err := safehttp.CheckURL(u); err != nil {
+		return "", apperr.Validation("blocked_url", "url must not point to a private or local address",
+			apperr.FieldError{Field: "url", Reason: "blocked"})
+	}
+	return u.String(), nil
+}
+
+// normalizeWebhookEvents checks events against webhookTopics and returns
+// them sorted and de-duplicated (never nil, so "all" is stored as '{}').
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	out := make([]string, 0, len(events))
+	for _, e := range events {
+		if !slices.Contains(webhookTopics, e) {
+			return nil, apperr.Validation("invalid_events", "unknown event "+e,
+				apperr.FieldError{Field: "events", Reason: "unknown_event"})
+		}
+		out = append(out, e)
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
+
+func (in WebhookInput) normalize() (WebhookInput, error) {
+	var err error
+	if in.URL, err = normalizeWebhookURL(in.URL); err != nil {
+		return in, err
+	}
+	if in.Events, err = normalizeWebhookEvents(in.Events); err != nil {
+		return in, err
+	}
+	if in.Secret == "" {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return in, apperr.Internal(err)
+		}
+		in.Secret = "whsec_" + hex.EncodeToString(b)
+	}
+	return in, nil
+}
+
+func (p WebhookPatch) normalize() (WebhookPatch, error) {
+	if p.URL == nil && p.Events == nil && p.Active == nil {
+		return p, apperr.Validation("empty_patch", "patch must set at least one of url, events, active")
+	}
+	if p.URL != nil {
+		u, err := normalizeWebhookURL(*p.URL)
+		if err != nil {
+			return p, err
+		}
+		p.URL = &u
+	}
+	if p.Events != nil {
+		ev, err := normalizeWebhookEvents(*p.Events)
+		if err != nil {
+			return p, err
+		}
+		p.Events = &ev
+	}
+	return p, nil
+}
+
+// ListWebhooks returns the caller's webhooks.
+func (s *Service) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListWebhooks(ctx, owner)
+}
+
+// CreateWebhook registers a webhook for the caller. The result is the only
+// place the secret is ever returned.
+func (s *Service) CreateWebhook(ctx context.Context, in WebhookInput) (Webhook, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if in, err = in.normalize(); err != nil {
+		return Webhook{}, err
+	}
+	return s.repo.CreateWebhook(ctx, owner, in)
+}
+
+// GetWebhook returns one of the caller's webhooks.
+func (s *Service) GetWebhook(ctx context.Context, id int32) (Webhook, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Webhook{}, err
+	}
+	return s.repo.GetWebhook(ctx, owner, id)
+}
+
+// UpdateWebhook changes the URL, events or active flag of a webhook.
+func (s *Service) UpdateWebhook(ctx context.Context, id int32, p WebhookPatch) (Webhook, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if p, err = p.normalize(); err != nil {
+		return Webhook{}, err
+	}
+	return s.repo.UpdateWebhook(ctx, owner, id, p)
+}
+
+// DeleteWebhook removes a webhook and its delivery log.
+func (s *Service) DeleteWebhook(ctx context.Context, id int32) error {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteWebhook(ctx, owner, id)
+}
+
+// ListWebhookDeliveries returns the newest limit entries of a webhook's
+// delivery log.
+func (s *Service) ListWebhookDeliveries(ctx context.Context, id int32, limit int) ([]WebhookDelivery, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetWebhook(ctx, owner, id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListWebhookDeliveries(ctx, id, limit)
+}
+
+// RedeliverWebhook queues a new delivery of the same event as deliveryID,
+// e.g. after fixing a receiver that had been failing.
+func (s *Service) RedeliverWebhook(ctx context.Context, id int32, deliveryID int64) (WebhookDelivery, error) {
+	owner, err := callerID(ctx)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	return s.repo.RedeliverWebhook(ctx, owner, id, deliveryID)
+}
+
+// ListWebhooks returns owner's webhooks without their secrets.
+func (r *Repo) ListWebhooks(ctx context.Context, owner string) ([]Webhook, error) {
+	rows, err := r.qry.ListWebhooks(ctx, owner)
+	if err != nil {
+		return nil, dbError(err, "webhook")
+	}
+	out := make([]Webhook, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, webhookFromRow(row, false))
+	}
+	return out, nil
+}
+
+// CreateWebhook inserts a webhook and returns it, secret included.
+func (r *Repo) CreateWebhook(ctx context.Context, owner string, in WebhookInput) (Webhook, error) {
+	row, err := r.qry.CreateWebhook(ctx, gen.CreateWebhookParams{
+		OwnerID: owner, Url: in.URL, Secret: in.Secret, Events: in.Events,
+	})
+	if err != nil {
+		return Webhook{}, dbError(err, "webhook")
+	}
+	return webhookFromRow(row, true), nil
+}
+
+// GetWebhook fetches one of owner's webhooks, without its secret.
+func (r *Repo) GetWebhook(ctx context.Context, owner string, id int32) (Webhook, error) {
+	row, err := r.qry.GetWebhook(ctx, gen.GetWebhookParams{ID: id, OwnerID: owner})
+	if err != nil {
+		return Webhook{}, dbError(err, "webhook")
+	}
+	return webhookFromRow(row, false), nil
+}
+
+// UpdateWebhook applies a partial update to one of owner's webhooks.
+func (r *Repo) UpdateWebhook(ctx context.Context, owner string, id int32, p WebhookPatch) (Webhook, error) {
+	params := gen.UpdateWebhookParams{ID: id, OwnerID: owner}
+	if p.URL != nil {
+		params.Url = pgtype.Text{String: *p.URL, Valid: true}
+	}
+	if p.Events != nil {
+		params.Events = *p.Events
+	}
+	if p.Active != nil {
+		params.Active = pgtype.Bool{Bool: *p.Active, Valid: true}
+	}
+	row, err := r.qry.UpdateWebhook(ctx, params)
+	if err != nil {
+		return Webhook{}, dbError(err, "webhook")
+	}
+	return webhookFromRow(row, false), nil
+}
+
+// DeleteWebhook removes one of owner's webhooks (and, by cascade, its log).
+func (r *Repo) DeleteWebhook(ctx context.Context, owner string, id int32) error {
+	n, err := r.qry.DeleteWebhook(ctx, gen.DeleteWebhookParams{ID: id, OwnerID: owner})
+	if err != nil {
+		return dbError(err, "webhook")
+	}
+	if n == 0 {
+		return apperr.NotFound("webhook_not_found", "webhook not found")
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns the newest deliveries of webhook id. The
+// caller must already have checked that the webhook is theirs.
+func (r *Repo) ListWebhookDeliveries(ctx context.Context, id int32, limit int) ([]WebhookDelivery, error) {
+	rows, err := r.qry.ListWebhookDeliveries(ctx, gen.ListWebhookDeliveriesParams{WebhookID: id, Lim: int32(limit)})
+	if err != nil {
+		return nil, dbError(err, "delivery")
+	}
+	out := make([]WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, deliveryFromRow(row))
+	}
+	return out, nil
+}
+
+// RedeliverWebhook copies delivery deliveryID of owner's webhook id into a
+// new pending delivery.
+func (r *Repo) RedeliverWebhook(ctx context.Context, owner string, id int32, deliveryID int64) (WebhookDelivery, error) {
+	row, err := r.qry.RedeliverWebhookDelivery(ctx, gen.RedeliverWebhookDeliveryParams{
+		ID: deliveryID, WebhookID: id, OwnerID: owner,
+	})
+	if err != nil {
+		return WebhookDelivery{}, dbError(err, "delivery")
+	}
+	return deliveryFromRow(row), nil
+}
+
+// The methods below make Repo a webhook.Store for the delivery worker.
+
+// ClaimWebhookDeliveries leases up to limit due deliveries, oldest first.
+func (r *Repo) ClaimWebhookDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]webhook.Delivery, error) {
+	rows, err := r.qry.ClaimWebhookDeliveries(ctx, gen.ClaimWebhookDeliveriesParams{
+		LeaseUntil: pgtype.Timestamptz{Time: leaseUntil, Valid: true},
+		Lim:        int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]webhook.Delivery, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, webhook.Delivery{
+			ID:        row.ID,
+			WebhookID: row.WebhookID,
+			URL:       row.Url,
+			Secret:    row.Secret,
+			EventID:   row.EventID,
+			Topic:     row.Topic,
+			Payload:   row.Payload,
+			Attempts:  row.Attempts,
+		})
+	}
+	slices.SortFunc(out, func(a, b webhook.Delivery) int { return cmp.Compare(a.ID, b.ID) })
+	return out, nil
+}
+
+// RecordWebhookAttempt stores the outcome of one delivery attempt.
+func (r *Repo) RecordWebhookAttempt(ctx context.Context, id int64, res webhook.Result) error {
+	params := gen.RecordWebhookAttemptParams{
+		ID:            id,
+		Status:        string(res.Status),
+		NextAttemptAt: pgtype.Timestamptz{Time: res.NextAttemptAt, Valid: true},
+	}
+	if res.StatusCode != 0 {
+		params.LastStatusCode = pgtype.Int4{Int32: int32(res.StatusCode), Valid: true}
+	}
+	if res.Error != "" {
+		params.LastError = pgtype.Text{String: res.Error, Valid: true}
+	}
+	return r.qry.RecordWebhookAttempt(ctx, params)
+}
+
+func webhookFromRow(row gen.Webhook, withSecret bool) Webhook {
+	w := Webhook{
+		ID:        row.ID,
+		URL:       row.Url,
+		Events:    row.Events,
+		Active:    row.Active,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+		OwnerID:   row.OwnerID,
+	}
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	if withSecret {
+		w.Secret = row.Secret
+	}
+	return w
+}
+
+func deliveryFromRow(row gen.WebhookDelivery) WebhookDelivery {
+	d := WebhookDelivery{
+		ID:        row.ID,
+		WebhookID: row.WebhookID,
+		EventID:   row.EventID,
+		Topic:     row.Topic,
+		Payload:   row.Payload,
+		Status:    webhook.Status(row.Status),
+		Attempts:  row.Attempts,
+		CreatedAt: row.CreatedAt.Time,
+	}
+	if row.LastStatusCode.Valid {
+		d.LastStatusCode = &row.LastStatusCode.Int32
+	}
+	if row.LastError.Valid {
+		d.LastError = &row.LastError.String
+	}
+	if row.RedeliveryOf.Valid {
+		d.RedeliveryOf = &row.RedeliveryOf.Int64
+	}
+	if row.DeliveredAt.Valid {
+		d.DeliveredAt = &row.DeliveredAt.Time
+	}
+	return d
+}
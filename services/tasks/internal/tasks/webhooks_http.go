@@ -0,0 +1,177 @@
+package tasks
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+)
+
+// WebhookManager is the optional capability behind /api/webhooks. Unlike
+// the per-task capabilities it is all-or-nothing: without it every webhook
+// route returns 501.
+type WebhookManager interface {
+	ListWebhooks(ctx context.Context) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, in WebhookInput) (Webhook, error)
+	GetWebhook(ctx context.Context, id int32) (Webhook, error)
+	UpdateWebhook(ctx context.Context, id int32, p WebhookPatch) (Webhook, error)
+	DeleteWebhook(ctx context.Context, id int32) error
+	ListWebhookDeliveries(ctx context.Context, id int32, limit int) ([]WebhookDelivery, error)
+	RedeliverWebhook(ctx context.Context, id int32, deliveryID int64) (WebhookDelivery, error)
+}
+
+// registerWebhookRoutes wires up /webhooks under r (see RegisterRoutes).
+func registerWebhookRoutes(r *gin.RouterGroup, svc TaskLister) {
+	// manager discovers the capability, writing a 501 if it is missing.
+	manager := func(c *gin.Context) (WebhookManager, bool) {
+		m, ok := svc.(WebhookManager)
+		if !ok {
+			apperr.Write(c, apperr.NotImplemented("webhooks_not_supported", "webhooks not supported"))
+		}
+		return m, ok
+	}
+
+	// GET /api/webhooks
+	r.GET("/webhooks", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		hooks, err := m.ListWebhooks(c.Request.Context())
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, hooks)
+	})
+
+	// POST /api/webhooks
+	r.POST("/webhooks", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		var in WebhookInput
+		if err := c.ShouldBindJSON(&in); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		w, err := m.CreateWebhook(c.Request.Context(), in)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusCreated, w)
+	})
+
+	// GET /api/webhooks/{id}
+	r.GET("/webhooks/:id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		w, err := m.GetWebhook(c.Request.Context(), id)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	})
+
+	// PATCH /api/webhooks/{id}
+	r.PATCH("/webhooks/:id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		var p WebhookPatch
+		if err := c.ShouldBindJSON(&p); err != nil {
+			apperr.Write(c, errInvalidBody)
+			return
+		}
+		w, err := m.UpdateWebhook(c.Request.Context(), id, p)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, w)
+	})
+
+	// DELETE /api/webhooks/{id}
+	r.DELETE("/webhooks/:id", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		if err := m.DeleteWebhook(c.Request.Context(), id); err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	// GET /api/webhooks/{id}/deliveries (newest first)
+	r.GET("/webhooks/:id/deliveries", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		limit := DefaultDeliveryPageSize
+		if v, set := c.GetQuery("limit"); set {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > MaxDeliveryPageSize {
+				apperr.Write(c, invalidParam("limit"))
+				return
+			}
+			limit = n
+		}
+		ds, err := m.ListWebhookDeliveries(c.Request.Context(), id, limit)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, ds)
+	})
+
+	// POST /api/webhooks/{id}/deliveries/{delivery_id}/redeliver
+	r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", func(c *gin.Context) {
+		m, ok := manager(c)
+		if !ok {
+			return
+		}
+		id, ok := parseID(c)
+		if !ok {
+			return
+		}
+		deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+		if err != nil || deliveryID <= 0 {
+			apperr.Write(c, invalidParam("delivery_id"))
+			return
+		}
+		d, err := m.RedeliverWebhook(c.Request.Context(), id, deliveryID)
+		if err != nil {
+			apperr.Write(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, d)
+	})
+}
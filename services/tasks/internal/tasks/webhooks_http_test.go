@@ -0,0 +1,228 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/apperr"
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/webhook"
+)
+
+// fakeWebhookSvc keeps webhooks in memory and validates like Service does.
+type fakeWebhookSvc struct {
+	listOnlySvc
+	hooks      map[int32]Webhook
+	redelivers []int64
+}
+
+func newFakeWebhookSvc() *fakeWebhookSvc {
+	return &fakeWebhookSvc{hooks: map[int32]Webhook{}}
+}
+
+func (f *fakeWebhookSvc) ListWebhooks(ctx context.Context) ([]Webhook, error) {
+	out := []Webhook{}
+	for _, w := range f.hooks {
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+func (f *fakeWebhookSvc) CreateWebhook(ctx context.Context, in WebhookInput) (Webhook, error) {
+	in, err := in.normalize()
+	if err != nil {
+		return Webhook{}, err
+	}
+	now := time.Now().UTC()
+	w := Webhook{
+		ID: int32(len(f.hooks) + 1), URL: in.URL, Events: in.Events, Active: true,
+		CreatedAt: now, UpdatedAt: now, OwnerID: "anonymous",
+	}
+	f.hooks[w.ID] = w
+	w.Secret = in.Secret
+	return w, nil
+}
+
+func (f *fakeWebhookSvc) GetWebhook(ctx context.Context, id int32) (Webhook, error) {
+	w, ok := f.hooks[id]
+	if !ok {
+		return Webhook{}, apperr.NotFound("webhook_not_found", "webhook not found")
+	}
+	return w, nil
+}
+
+func (f *fakeWebhookSvc) UpdateWebhook(ctx context.Context, id int32, p WebhookPatch) (Webhook, error) {
+	p, err := p.normalize()
+	if err != nil {
+		return Webhook{}, err
+	}
+	w, err := f.GetWebhook(ctx, id)
+	if err != nil {
+		return Webhook{}, err
+	}
+	if p.Active != nil {
+		w.Active = *p.Active
+	}
+	if p.Events != nil {
+		w.Events = *p.Events
+	}
+	f.hooks[id] = w
+	return w, nil
+}
+
+func (f *fakeWebhookSvc) DeleteWebhook(ctx context.Context, id int32) error {
+	if _, err := f.GetWebhook(ctx, id); err != nil {
+		return err
+	}
+	delete(f.hooks, id)
+	return nil
+}
+
+func (f *fakeWebhookSvc) ListWebhookDeliveries(ctx context.Context, id int32, limit int) ([]WebhookDelivery, error) {
+	if _, err := f.GetWebhook(ctx, id); err != nil {
+		return nil, err
+	}
+	code := int32(500)
+	return []WebhookDelivery{{
+		ID: 9, WebhookID: id, EventID: 3, Topic: "task.created",
+		Payload: json.RawMessage(`{"type":"created","task":{"id":1,"title":"t","done":false,"created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z","version":1,"owner_id":"anonymous","description":"","priority":"normal","tags":[]}}`),
+		Status:  webhook.StatusFailed, Attempts: 10, LastStatusCode: &code, CreatedAt: time.Now().UTC(),
+	}}, nil
+}
+
+func (f *fakeWebhookSvc) RedeliverWebhook(ctx context.Context, id int32, deliveryID int64) (WebhookDelivery, error) {
+	ds, err := f.ListWebhookDeliveries(ctx, id, 1)
+	if err != nil {
+		return WebhookDelivery{}, err
+	}
+	if deliveryID != ds[0].ID {
+		return WebhookDelivery{}, apperr.NotFound("delivery_not_found", "delivery not found")
+	}
+	f.redelivers = append(f.redelivers, deliveryID)
+	d := ds[0]
+	d.ID, d.Status, d.Attempts, d.LastStatusCode, d.RedeliveryOf = 10, webhook.StatusPending, 0, nil, &deliveryID
+	return d, nil
+}
+
+func doJSON(t *testing.T, h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestWebhooks_CreateShowsSecretOnce(t *testing.T) {
+	r := newTestRouter(newFakeWebhookSvc())
+
+	w := doJSON(t, r, http.MethodPost, "/api/webhooks",
+		`{"url":"https://example.com/hook","events":["task.updated","task.created","task.updated"]}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d; body=%s", w.Code, w.Body.String())
+	}
+	var created Webhook
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	if len(created.Secret) < 32 || strings.Join(created.Events, ",") != "task.created,task.updated" {
+		t.Fatalf("expected a generated secret and normalized events, got %+v", created)
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/webhooks/1", "")
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte(`"secret"`)) {
+		t.Fatalf("GET must not reveal the secret: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWebhooks_RejectInvalidInput(t *testing.T) {
+	r := newTestRouter(newFakeWebhookSvc())
+
+	cases := []struct {
+		body, code, field string
+	}{
+		{`{"url":"ftp://example.com"}`, "invalid_url", "url"},
+		{`{"url":"/relative"}`, "invalid_url", "url"},
+		{`{"url":"http://127.0.0.1:8080/hook"}`, "blocked_url", "url"},
+		{`{"url":"http://169.254.169.254/latest/meta-data"}`, "blocked_url", "url"},
+		{`{"url":"https://example.com","events":["task.exploded"]}`, "invalid_events", "events"},
+	}
+	for _, tc := range cases {
+		w := doJSON(t, r, http.MethodPost, "/api/webhooks", tc.body)
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tc.body, w.Code)
+		}
+		if p := decodeProblem(t, w); p.Code != tc.code || len(p.Errors) != 1 || p.Errors[0].Field != tc.field {
+			t.Fatalf("%s: unexpected problem %+v", tc.body, p)
+		}
+	}
+
+	doJSON(t, r, http.MethodPost, "/api/webhooks", `{"url":"https://example.com"}`)
+	if w := doJSON(t, r, http.MethodPatch, "/api/webhooks/1", `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("empty patch: expected 400, got %d", w.Code)
+	}
+}
+
+func TestWebhooks_UpdateDeleteAndDeliveries(t *testing.T) {
+	svc := newFakeWebhookSvc()
+	r := newTestRouter(svc)
+	doJSON(t, r, http.MethodPost, "/api/webhooks", `{"url":"https://example.com"}`)
+
+	w := doJSON(t, r, http.MethodPatch, "/api/webhooks/1", `{"active":false}`)
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"active":false`)) {
+		t.Fatalf("PATCH: %d %s", w.Code, w.Body.String())
+	}
+
+	w = doJSON(t, r, http.MethodGet, "/api/webhooks/1/deliveries?limit=10", "")
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"status":"failed"`)) {
+		t.Fatalf("deliveries: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodGet, "/api/webhooks/1/deliveries?limit=0", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("limit=0: expected 400, got %d", w.Code)
+	}
+
+	w = doJSON(t, r, http.MethodPost, "/api/webhooks/1/deliveries/9/redeliver", "")
+	if w.Code != http.StatusAccepted || len(svc.redelivers) != 1 || svc.redelivers[0] != 9 {
+		t.Fatalf("redeliver: %d %s", w.Code, w.Body.String())
+	}
+	if w := doJSON(t, r, http.MethodPost, "/api/webhooks/1/deliveries/8/redeliver", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("redeliver unknown delivery: expected 404, got %d", w.Code)
+	}
+
+	if w := doJSON(t, r, http.MethodDelete, "/api/webhooks/1", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: expected 204, got %d", w.Code)
+	}
+	if w := doJSON(t, r, http.MethodGet, "/api/webhooks/1", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("GET after delete: expected 404, got %d", w.Code)
+	}
+}
+
+func TestWebhooks_NotImplementedWithoutCapability(t *testing.T) {
+	r := newTestRouter(&listOnlySvc{})
+	if w := doJSON(t, r, http.MethodGet, "/api/webhooks", ""); w.Code != http.StatusNotImplemented {
+		t.Fatalf("expected 501, got %d", w.Code)
+	}
+}
+
+func Test_Server_Webhooks_MatchOpenAPI(t *testing.T) {
+	doc := loadSpec(t)
+	svc := newFakeWebhookSvc()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/webhooks", bytes.NewBufferString(`{"url":"https://example.com/hook"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if rec := serveAndValidateWith(t, doc, svc, req); rec.Code != http.StatusCreated {
+		t.Fatalf("POST: expected 201, got %d", rec.Code)
+	}
+	for _, path := range []string{"/api/webhooks", "/api/webhooks/1", "/api/webhooks/1/deliveries", "/api/webhooks/2"} {
+		serveAndValidateWith(t, doc, svc, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	req = httptest.NewRequest(http.MethodPost, "/api/webhooks/1/deliveries/9/redeliver", nil)
+	if rec := serveAndValidateWith(t, doc, svc, req); rec.Code != http.StatusAccepted {
+		t.Fatalf("redeliver: expected 202, got %d", rec.Code)
+	}
+}
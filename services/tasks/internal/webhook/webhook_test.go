@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"type":"created"}`)
+
+	sig := Sign("s3cret", now, body)
+	if err := Verify("s3cret", sig, body, now.Add(time.Minute), 5*time.Minute); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if err := Verify("other", sig, body, now, 5*time.Minute); err == nil {
+		t.Fatalf("expected a mismatch with the wrong secret")
+	}
+	if err := Verify("s3cret", sig, []byte(`{"type":"deleted"}`), now, 5*time.Minute); err == nil {
+		t.Fatalf("expected a mismatch for a modified body")
+	}
+	if err := Verify("s3cret", sig, body, now.Add(time.Hour), 5*time.Minute); err == nil {
+		t.Fatalf("expected an old signature to be rejected")
+	}
+	if err := Verify("s3cret", "garbage", body, now, 5*time.Minute); err == nil {
+		t.Fatalf("expected a malformed header to be rejected")
+	}
+}
+
+// memStore hands out its deliveries once each and records the results.
+type memStore struct {
+	mu      sync.Mutex
+	pending []Delivery
+	results map[int64][]Result
+}
+
+func (s *memStore) ClaimWebhookDeliveries(_ context.Context, limit int, _ time.Time) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := min(limit, len(s.pending))
+	out := s.pending[:n]
+	s.pending = s.pending[n:]
+	return out, nil
+}
+
+func (s *memStore) RecordWebhookAttempt(_ context.Context, id int64, r Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.results == nil {
+		s.results = map[int64][]Result{}
+	}
+	s.results[id] = append(s.results[id], r)
+	return nil
+}
+
+func TestWorker_SendsSignedRequest(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	store := &memStore{pending: []Delivery{{
+		ID: 5, WebhookID: 2, URL: srv.URL, Secret: "s3cret",
+		EventID: 42, Topic: "task.created", Payload: json.RawMessage(`{"type":"created"}`), Attempts: 1,
+	}}}
+	w := NewWorker(store, Options{Client: srv.Client()})
+	w.now = func() time.Time { return now }
+
+	if n, err := w.RunOnce(context.Background()); err != nil || n != 1 {
+		t.Fatalf("RunOnce = %d, %v", n, err)
+	}
+	if string(gotBody) != `{"type":"created"}` {
+		t.Fatalf("unexpected body %s", gotBody)
+	}
+	if err := Verify("s3cret", got.Header.Get(SignatureHeader), gotBody, now, time.Minute); err != nil {
+		t.Fatalf("signature: %v", err)
+	}
+	if got.Header.Get("X-Event-Id") != "42" || got.Header.Get("X-Event-Topic") != "task.created" ||
+		got.Header.Get("X-Webhook-Delivery") != "5" {
+		t.Fatalf("unexpected headers: %v", got.Header)
+	}
+	if r := store.results[5]; len(r) != 1 || r[0].Status != StatusDelivered || r[0].StatusCode != 200 {
+		t.Fatalf("unexpected result %+v", r)
+	}
+}
+
+func TestWorker_BacksOffThenGivesUp(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	d := Delivery{ID: 1, URL: srv.URL, Payload: json.RawMessage(`{}`)}
+	store := &memStore{}
+	w := NewWorker(store, Options{MinBackoff: time.Second, MaxBackoff: 3 * time.Second, MaxAttempts: 4, Client: srv.Client()})
+	w.now = func() time.Time { return now }
+
+	for attempt := int32(1); attempt <= 4; attempt++ {
+		d.Attempts = attempt
+		store.pending = []Delivery{d}
+		if _, err := w.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce: %v", err)
+		}
+	}
+
+	want := []struct {
+		status Status
+		wait   time.Duration
+	}{
+		{StatusPending, time.Second},
+		{StatusPending, 2 * time.Second},
+		{StatusPending, 3 * time.Second}, // capped
+		{StatusFailed, 0},
+	}
+	got := store.results[1]
+	if len(got) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), got)
+	}
+	for i, r := range got {
+		if r.Status != want[i].status || !r.NextAttemptAt.Equal(now.Add(want[i].wait)) ||
+			r.StatusCode != http.StatusInternalServerError || r.Error != "unexpected_status" {
+			t.Fatalf("attempt %d: got %+v, want %s retrying in %s", i+1, r, want[i].status, want[i].wait)
+		}
+	}
+}
+
+func TestWorker_RefusesPrivateAddresses(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer srv.Close()
+
+	store := &memStore{pending: []Delivery{{ID: 3, URL: srv.URL, Payload: json.RawMessage(`{}`), Attempts: 1}}}
+	w := NewWorker(store, Options{}) // the default client; srv is on loopback
+	if _, err := w.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+	if r := store.results[3]; hits != 0 || len(r) != 1 || r[0].Status != StatusPending || r[0].Error != "blocked_address" {
+		t.Fatalf("expected a blocked, unsent delivery; got %+v after %d requests", r, hits)
+	}
+}
@@ -0,0 +1,222 @@
+// Package webhook delivers task events to the URLs users register under
+// /api/webhooks.
+//
+// Deliveries are queued in Postgres in the same transaction as the change
+// they describe (see tasks.Repo). The Worker claims due deliveries, POSTs
+// each payload signed with the webhook's secret (see Sign), and records the
+// outcome: delivered on any 2xx response, otherwise retried with exponential
+// backoff until MaxAttempts, after which the delivery is marked failed and
+// can only be sent again by a manual redelivery.
+//
+// Webhook URLs are user-supplied, so by default deliveries go through a
+// safehttp client: no private or local addresses, no redirects. The delivery
+// log, which the webhook's owner can read, records only the class of an
+// error (see safehttp.ErrorClass), never the error itself.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alex-harvey-z3q/fullstack-golang-react-poc/services/tasks/internal/safehttp"
+)
+
+// Status is the state of a delivery in the delivery log.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusDelivered Status = "delivered"
+	StatusFailed    Status = "failed"
+)
+
+// Delivery is one claimed delivery, with what is needed to send it.
+type Delivery struct {
+	ID        int64
+	WebhookID int32
+	URL       string
+	Secret    string
+	EventID   int64
+	Topic     string
+	Payload   json.RawMessage
+	// Attempts counts delivery attempts, including the current one.
+	Attempts int32
+}
+
+// Result is the outcome of one attempt, as recorded in the delivery log.
+type Result struct {
+	Status        Status
+	StatusCode    int    // 0 if no response was received
+	Error         string // class of the failure (see failure); empty once delivered
+	NextAttemptAt time.Time
+}
+
+// Store is the persistence the Worker needs; tasks.Repo implements it.
+type Store interface {
+	// ClaimWebhookDeliveries leases up to limit due pending deliveries until
+	// leaseUntil and counts the attempt.
+	ClaimWebhookDeliveries(ctx context.Context, limit int, leaseUntil time.Time) ([]Delivery, error)
+	RecordWebhookAttempt(ctx context.Context, id int64, r Result) error
+}
+
+// Options tune the Worker. Zero values select the defaults noted below.
+type Options struct {
+	BatchSize    int           // deliveries claimed per poll (50)
+	PollInterval time.Duration // wait between polls when idle (1s)
+	Lease        time.Duration // how long a claimed batch is ours (5m)
+	Timeout      time.Duration // per-request deadline (10s)
+	MinBackoff   time.Duration // first retry delay, doubled per attempt (10s)
+	MaxBackoff   time.Duration // cap on the retry delay (1h)
+	MaxAttempts  int32         // attempts before a delivery is marked failed (10)
+	Client       *http.Client  // nil means safehttp.NewClient()
+}
+
+func (o Options) withDefaults() Options {
+	if o.BatchSize <= 0 {
+		o.BatchSize = 50
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = 5 * time.Minute
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = 10 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.Client == nil {
+		o.Client = safehttp.NewClient()
+	}
+	return o
+}
+
+// Worker sends pending deliveries from a Store.
+type Worker struct {
+	store Store
+	opts  Options
+	now   func() time.Time
+}
+
+// NewWorker returns a Worker delivering from store.
+func NewWorker(store Store, opts Options) *Worker {
+	return &Worker{store: store, opts: opts.withDefaults(), now: time.Now}
+}
+
+// Run sends deliveries until ctx is cancelled. Store errors are logged and
+// retried on the next poll.
+func (w *Worker) Run(ctx context.Context) error {
+	for {
+		n, err := w.RunOnce(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			log.Printf("webhook: %v", err)
+		}
+		if err == nil && n == w.opts.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(w.opts.PollInterval):
+		}
+	}
+}
+
+// RunOnce claims one batch of due deliveries and attempts each one,
+// recording the results. It returns how many deliveries it claimed.
+func (w *Worker) RunOnce(ctx context.Context) (int, error) {
+	ds, err := w.store.ClaimWebhookDeliveries(ctx, w.opts.BatchSize, w.now().Add(w.opts.Lease))
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range ds {
+		res := w.attempt(ctx, d)
+		if err := w.store.RecordWebhookAttempt(ctx, d.ID, res); err != nil {
+			return len(ds), err
+		}
+	}
+	return len(ds), nil
+}
+
+// attempt POSTs d once and decides what happens next.
+func (w *Worker) attempt(ctx context.Context, d Delivery) Result {
+	code, err := w.post(ctx, d)
+	now := w.now()
+	if err == nil {
+		return Result{Status: StatusDelivered, StatusCode: code, NextAttemptAt: now}
+	}
+	res := Result{Status: StatusPending, StatusCode: code, Error: failure(code, err)}
+	if d.Attempts >= w.opts.MaxAttempts {
+		res.Status = StatusFailed
+		res.NextAttemptAt = now
+		log.Printf("webhook: delivery %d to webhook %d failed after %d attempts: %v", d.ID, d.WebhookID, d.Attempts, err)
+		return res
+	}
+	res.NextAttemptAt = now.Add(w.backoff(d.Attempts))
+	return res
+}
+
+// post sends the signed request and returns the response status code.
+func (w *Worker) post(ctx context.Context, d Delivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.opts.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tasks-webhooks/1")
+	req.Header.Set("X-Event-Id", strconv.FormatInt(d.EventID, 10))
+	req.Header.Set("X-Event-Topic", d.Topic)
+	req.Header.Set("X-Webhook-Id", strconv.FormatInt(int64(d.WebhookID), 10))
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, w.now(), d.Payload))
+
+	res, err := w.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10)) // allow connection reuse
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+// failure is what the delivery log records for a failed attempt.
+func failure(code int, err error) string {
+	if code != 0 {
+		return "unexpected_status"
+	}
+	return safehttp.ErrorClass(err)
+}
+
+// backoff is the delay before retrying after the given attempt:
+// MinBackoff doubled for each earlier attempt, capped at MaxBackoff.
+func (w *Worker) backoff(attempt int32) time.Duration {
+	d := w.opts.MinBackoff
+	for i := int32(1); i < attempt && d < w.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, w.opts.MaxBackoff)
+}
@@ -0,0 +1,7 @@
+//go:build tools
+
+// Package tools pins code generators used by `make` targets so that
+// `go run` resolves them from go.mod instead of @latest.
+package tools
+
+import _ "github.com/99designs/gqlgen"
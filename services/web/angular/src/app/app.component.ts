@@ -1,9 +1,10 @@
 import { Component, inject } from '@angular/core';
+import { takeUntilDestroyed } from '@angular/core/rxjs-interop';
 import { CommonModule } from '@angular/common';
 import { FormsModule } from '@angular/forms';
 import { HttpErrorResponse } from '@angular/common/http';
 import { TaskService } from './task.service';
-import { Task } from './types';
+import { Problem, Task, TaskEvent } from './types';
 
 @Component({
   selector: 'app-root',
@@ -18,6 +19,7 @@ import { Task } from './types';
           class="input"
           type="text"
           placeholder="Add a new task…"
+          maxlength="200"
           [(ngModel)]="title"
           name="title"
           aria-label="Task title" />
@@ -29,8 +31,9 @@ import { Task } from './types';
 
       <ul *ngIf="!pending && !error" class="list">
         <li *ngFor="let t of tasks" class="list-item">
-          <input type="checkbox" [checked]="t.done" disabled />
+          <input type="checkbox" [checked]="t.done" (change)="toggle(t)" />
           <span [class.done]="t.done">{{ t.title }}</span>
+          <button class="btn-link" type="button" (click)="remove(t)" title="Delete task">✕</button>
         </li>
         <li *ngIf="(tasks ?? []).length === 0" class="muted">
           No tasks yet. Add your first one above.
@@ -49,6 +52,11 @@ export class AppComponent {
 
   constructor() {
     this.load();
+    // Apply changes made by others as they happen; re-fetch on reconnect.
+    this.api
+      .events(() => this.load())
+      .pipe(takeUntilDestroyed())
+      .subscribe((ev) => (this.tasks = applyTaskEvent(this.tasks ?? [], ev)));
   }
 
   get pending(): boolean {
@@ -59,14 +67,14 @@ export class AppComponent {
     return this.title.trim().length > 0 && !this.submitting;
   }
 
-  /** Extract `{ "error": "..." }` from backend responses when available. */
+  /** Extract the problem+json `detail` (or `title`) from backend responses when available. */
   private extractServerError(e: unknown): string | null {
     const err = e as HttpErrorResponse | undefined;
     if (!err) return null;
 
-    const payload = err.error;
-    if (payload && typeof payload === 'object' && 'error' in payload) {
-      const msg = (payload as any).error;
+    const payload = err.error as Partial<Problem> | string | null;
+    if (payload && typeof payload === 'object') {
+      const msg = payload.detail || payload.title;
       if (typeof msg === 'string' && msg.trim().length > 0) return msg;
     }
     if (typeof payload === 'string' && payload.trim().length > 0) {
@@ -94,7 +102,7 @@ export class AppComponent {
     const title = this.title.trim();
     this.api.createTask(title).subscribe({
       next: (t) => {
-        this.tasks = [...(this.tasks ?? []), t];
+        this.tasks = applyTaskEvent(this.tasks ?? [], { type: 'created', task: t });
         this.title = '';
       },
       error: (e) => {
@@ -104,4 +112,41 @@ export class AppComponent {
       complete: () => (this.submitting = false)
     });
   }
+
+  toggle(t: Task) {
+    this.error = null;
+    this.api.updateTask(t.id, { done: !t.done }, t.version).subscribe({
+      next: (updated) => {
+        this.tasks = (this.tasks ?? []).map((x) => (x.id === t.id ? updated : x));
+      },
+      error: (e) => {
+        const serverError = this.extractServerError(e);
+        this.error = serverError || (e as any)?.message || 'Failed to update task';
+      }
+    });
+  }
+
+  remove(t: Task) {
+    this.error = null;
+    this.api.deleteTask(t.id).subscribe({
+      next: () => {
+        this.tasks = (this.tasks ?? []).filter((x) => x.id !== t.id);
+      },
+      error: (e) => {
+        const serverError = this.extractServerError(e);
+        this.error = serverError || (e as any)?.message || 'Failed to delete task';
+      }
+    });
+  }
+}
+
+/** Returns tasks with ev applied (idempotent: our own changes also arrive as events). */
+function applyTaskEvent(tasks: Task[], ev: TaskEvent): Task[] {
+  const rest = tasks.filter((t) => t.id !== ev.task.id);
+  if (ev.type === 'deleted') return rest;
+  const existing = tasks.find((t) => t.id === ev.task.id);
+  if (existing && (existing.version ?? 0) > (ev.task.version ?? 0)) return tasks;
+  return existing
+    ? tasks.map((t) => (t.id === ev.task.id ? ev.task : t))
+    : [...rest, ev.task].sort((a, b) => a.id - b.id);
 }
@@ -1,7 +1,7 @@
 import { Injectable, inject } from '@angular/core';
 import { HttpClient } from '@angular/common/http';
 import { Observable } from 'rxjs';
-import type { Task } from './types';
+import type { Task, TaskEvent, TaskPatch } from './types';
 
 @Injectable({ providedIn: 'root' })
 export class TaskService {
@@ -14,4 +14,29 @@ export class TaskService {
   createTask(title: string): Observable<Task> {
     return this.http.post<Task>('/api/tasks', { title });
   }
+
+  /** With `version`, the update only applies if the task is unchanged (If-Match); else 412. */
+  updateTask(id: number, patch: TaskPatch, version?: number): Observable<Task> {
+    const headers: Record<string, string> = version !== undefined ? { 'If-Match': `"${version}"` } : {};
+    return this.http.patch<Task>(`/api/tasks/${id}`, patch, { headers });
+  }
+
+  deleteTask(id: number): Observable<void> {
+    return this.http.delete<void>(`/api/tasks/${id}`);
+  }
+
+  /**
+   * Live changes to your tasks (Server-Sent Events). EventSource reconnects by
+   * itself; `onOpen` runs on every (re)connect so callers can re-fetch what
+   * they missed. Unsubscribing closes the stream.
+   */
+  events(onOpen?: () => void): Observable<TaskEvent> {
+    return new Observable<TaskEvent>((sub) => {
+      const es = new EventSource('/api/tasks/stream');
+      const handle = (e: MessageEvent) => sub.next(JSON.parse(e.data));
+      for (const type of ['created', 'updated', 'deleted']) es.addEventListener(type, handle);
+      if (onOpen) es.addEventListener('open', onOpen);
+      return () => es.close();
+    });
+  }
 }
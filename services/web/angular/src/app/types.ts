@@ -4,4 +4,26 @@ export type Task = {
   done: boolean;
   created_at?: string;
   updated_at?: string;
+  version?: number;
+};
+
+export type TaskPatch = {
+  title?: string;
+  done?: boolean;
+};
+
+/** RFC 7807 error body returned by the API (application/problem+json). */
+export type Problem = {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  code: string;
+  errors?: { field: string; reason: string }[];
+};
+
+/** One change pushed by GET /api/tasks/stream. */
+export type TaskEvent = {
+  type: 'created' | 'updated' | 'deleted';
+  task: Task;
 };
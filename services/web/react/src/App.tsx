@@ -1,6 +1,6 @@
 import { useEffect, useMemo, useRef, useState } from 'react'
 import type { Task } from './types'
-import { createTask, fetchTasks } from './api'
+import { applyTaskEvent, createTask, deleteTask, fetchTasks, subscribeTasks, updateTask } from './api'
 
 export default function App() {
   const [tasks, setTasks] = useState<Task[] | null>(null)
@@ -10,15 +10,31 @@ export default function App() {
   const acRef = useRef<AbortController | null>(null)
 
   // load tasks on mount (and whenever you choose to refresh)
-  useEffect(() => {
+  function load() {
     acRef.current?.abort()
     const ac = new AbortController()
     acRef.current = ac
     setError(null)
     fetchTasks(ac.signal)
       .then(setTasks)
-      .catch((e) => setError(e.message))
-    return () => ac.abort()
+      .catch((e) => {
+        if (e.name !== 'AbortError') setError(e.message)
+      })
+  }
+
+  // Live updates: apply changes made by others (other tabs, other clients)
+  // as they happen. Every (re)connect re-fetches, covering the initial load
+  // and anything missed while disconnected.
+  useEffect(() => {
+    load()
+    const close = subscribeTasks(
+      (ev) => setTasks((prev) => (prev ? applyTaskEvent(prev, ev) : prev)),
+      load
+    )
+    return () => {
+      close()
+      acRef.current?.abort()
+    }
   }, [])
 
   const pending = tasks === null && !error
@@ -34,7 +50,7 @@ export default function App() {
     setError(null)
     try {
       const t = await createTask(title.trim())
-      setTasks((prev) => (prev ? [...prev, t] : [t]))
+      setTasks((prev) => applyTaskEvent(prev ?? [], { type: 'created', task: t }))
       setTitle('')
     } catch (e: any) {
       setError(e.message ?? 'Failed to add task')
@@ -43,6 +59,26 @@ export default function App() {
     }
   }
 
+  async function onToggle(t: Task) {
+    setError(null)
+    try {
+      const updated = await updateTask(t.id, { done: !t.done }, t.version)
+      setTasks((prev) => prev?.map((x) => (x.id === t.id ? updated : x)) ?? null)
+    } catch (e: any) {
+      setError(e.message ?? 'Failed to update task')
+    }
+  }
+
+  async function onDelete(t: Task) {
+    setError(null)
+    try {
+      await deleteTask(t.id)
+      setTasks((prev) => prev?.filter((x) => x.id !== t.id) ?? null)
+    } catch (e: any) {
+      setError(e.message ?? 'Failed to delete task')
+    }
+  }
+
   return (
     <div className="page">
       <header className="header">
@@ -55,6 +91,7 @@ export default function App() {
             className="input"
             type="text"
             placeholder="Add a new task…"
+            maxLength={200}
             value={title}
             onChange={(e) => setTitle(e.target.value)}
             aria-label="Task title"
@@ -76,8 +113,20 @@ export default function App() {
           <ul className="list">
             {tasks?.map((t) => (
               <li key={t.id} className="list-item">
-                <input type="checkbox" checked={t.done} readOnly />
+                <input
+                  type="checkbox"
+                  checked={t.done}
+                  onChange={() => onToggle(t)}
+                  aria-label={`Mark "${t.title}" as ${t.done ? 'not done' : 'done'}`}
+                />
                 <span className={t.done ? 'done' : ''}>{t.title}</span>
+                <button
+                  className="btn-link"
+                  type="button"
+                  onClick={() => onDelete(t)}
+                  title="Delete task">
+                  ✕
+                </button>
               </li>
             ))}
             {tasks?.length === 0 && (
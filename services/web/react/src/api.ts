@@ -1,31 +1,104 @@
-import type { Task } from './types'
+import type { Task, TaskEvent } from './types'
 
 const JSON_HEADERS = { 'Content-Type': 'application/json' }
 
+// Errors come back as RFC 7807 application/problem+json:
+// { type, title, status, detail, code, errors? }. `code` is stable
+// (e.g. "task_not_found", "invalid_title") so the UI can branch on it.
+export type Problem = {
+  type: string
+  title: string
+  status: number
+  detail?: string
+  code: string
+  errors?: { field: string; reason: string }[]
+}
+
+export class ApiError extends Error {
+  constructor(readonly status: number, readonly code: string, message: string) {
+    super(message)
+  }
+}
+
+// Builds an ApiError from a failed response, preferring the problem's detail.
+async function apiError(what: string, res: Response): Promise<ApiError> {
+  let p: Partial<Problem> = {}
+  try {
+    p = await res.json()
+  } catch {}
+  const msg = `${what} failed: ${res.status}${p.detail ? ` ${p.detail}` : ''}`
+  return new ApiError(res.status, p.code ?? 'unknown', msg)
+}
+
 export async function fetchTasks(signal?: AbortSignal) {
   const res = await fetch('/api/tasks', { signal })
-  if (!res.ok) {
-    let msg = `GET /api/tasks failed: ${res.status}`
-    try {
-      const body = await res.json()
-      if (body?.error) msg += ` ${body.error}`
-    } catch {}
-    throw new Error(msg)
-  }
+  if (!res.ok) throw await apiError('GET /api/tasks', res)
   return res.json()
 }
 
 // Requires POST /api/tasks on the backend.
 // If not implemented yet, this will throw and the UI will show the error.
+//
+// Every call sends a fresh Idempotency-Key, and a request that fails before
+// any response arrives (e.g. a dropped connection) is retried once with the
+// same key, so the server creates the task at most once.
 export async function createTask(title: string): Promise<Task> {
-  const res = await fetch('/api/tasks', {
+  const init: RequestInit = {
     method: 'POST',
-    headers: JSON_HEADERS,
+    headers: { ...JSON_HEADERS, 'Idempotency-Key': crypto.randomUUID() },
     body: JSON.stringify({ title })
-  })
-  if (!res.ok) {
-    const body = await res.text().catch(() => '')
-    throw new Error(`POST /api/tasks failed: ${res.status} ${body}`)
   }
+  let res: Response
+  try {
+    res = await fetch('/api/tasks', init)
+  } catch {
+    res = await fetch('/api/tasks', init)
+  }
+  if (!res.ok) throw await apiError('POST /api/tasks', res)
   return res.json()
 }
+
+export type TaskPatch = { title?: string; done?: boolean }
+
+// Pass the version you last saw to make the update conditional (If-Match):
+// if someone else changed the task meanwhile, this fails with 412 and
+// code "version_mismatch" instead of overwriting their change.
+export async function updateTask(id: number, patch: TaskPatch, version?: number): Promise<Task> {
+  const headers: Record<string, string> = { ...JSON_HEADERS }
+  if (version !== undefined) headers['If-Match'] = `"${version}"`
+  const res = await fetch(`/api/tasks/${id}`, {
+    method: 'PATCH',
+    headers,
+    body: JSON.stringify(patch)
+  })
+  if (!res.ok) throw await apiError(`PATCH /api/tasks/${id}`, res)
+  return res.json()
+}
+
+export async function deleteTask(id: number): Promise<void> {
+  const res = await fetch(`/api/tasks/${id}`, { method: 'DELETE' })
+  if (!res.ok) throw await apiError(`DELETE /api/tasks/${id}`, res)
+}
+
+// Live changes to your tasks (Server-Sent Events). EventSource reconnects by
+// itself; onOpen fires on every (re)connect so callers can re-fetch whatever
+// they missed while disconnected. Returns a function that closes the stream.
+export function subscribeTasks(onEvent: (ev: TaskEvent) => void, onOpen?: () => void): () => void {
+  const es = new EventSource('/api/tasks/stream')
+  const handle = (e: MessageEvent) => onEvent(JSON.parse(e.data))
+  for (const type of ['created', 'updated', 'deleted']) es.addEventListener(type, handle)
+  if (onOpen) es.addEventListener('open', onOpen)
+  return () => es.close()
+}
+
+// applyTaskEvent returns tasks with ev applied (idempotent: our own changes
+// arrive both as the API response and as an event).
+export function applyTaskEvent(tasks: Task[], ev: TaskEvent): Task[] {
+  const rest = tasks.filter((t) => t.id !== ev.task.id)
+  if (ev.type === 'deleted') return rest
+  const existing = tasks.find((t) => t.id === ev.task.id)
+  if (existing && (existing.version ?? 0) > (ev.task.version ?? 0)) return tasks
+  return existing
+    ? tasks.map((t) => (t.id === ev.task.id ? ev.task : t))
+    : [...rest, ev.task].sort((a, b) => a.id - b.id)
+}
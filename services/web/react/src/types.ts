@@ -4,4 +4,11 @@ export type Task = {
   done: boolean
   created_at?: string
   updated_at?: string
+  version?: number
+}
+
+// One change pushed by GET /api/tasks/stream.
+export type TaskEvent = {
+  type: 'created' | 'updated' | 'deleted'
+  task: Task
 }
@@ -10,6 +10,10 @@ export default defineConfig({
       '/api': {
         target: 'http://localhost:8081',
         changeOrigin: true
+      },
+      '/graphql': {
+        target: 'http://localhost:8081',
+        changeOrigin: true
       }
     }
   }